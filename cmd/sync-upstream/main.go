@@ -1,8 +1,8 @@
 package main
 
 import (
+	"bytes"
 	"context"
-	"crypto/sha256"
 	"encoding/json"
 	"flag"
 	"fmt"
@@ -16,17 +16,20 @@ import (
 	"time"
 
 	"gopkg.in/yaml.v3"
+
+	corepkg "github.com/LoriKarikari/compak/internal/core/package"
 )
 
 type Package struct {
-	Name        string         `yaml:"name"`
-	Version     string         `yaml:"version"`
-	Description string         `yaml:"description"`
-	Author      string         `yaml:"author"`
-	Homepage    string         `yaml:"homepage"`
-	Repository  string         `yaml:"repository"`
-	Source      string         `yaml:"source"`
-	Parameters  map[string]any `yaml:"parameters"`
+	Name         string          `yaml:"name"`
+	Version      string          `yaml:"version"`
+	Description  string          `yaml:"description"`
+	Author       string          `yaml:"author"`
+	Homepage     string          `yaml:"homepage"`
+	Repository   string          `yaml:"repository"`
+	Source       corepkg.Sources `yaml:"source"`
+	SourceDigest string          `yaml:"sourceDigest,omitempty"`
+	Parameters   map[string]any  `yaml:"parameters"`
 }
 
 type UpdateResult struct {
@@ -197,8 +200,8 @@ func checkPackage(pakFileName, pakName string, paksRoot, cacheRoot *os.Root, pak
 
 	composeChanged := false
 	composeChecksum := ""
-	if pkg.Source != "" {
-		composeContent, err := fetchURL(pkg.Source)
+	if len(pkg.Source) > 0 {
+		checksum, err := checksumSources(pkg.Source)
 		if err != nil {
 			return UpdateResult{
 				PackageName:    pakName,
@@ -208,7 +211,10 @@ func checkPackage(pakFileName, pakName string, paksRoot, cacheRoot *os.Root, pak
 			}
 		}
 
-		composeChecksum = checksumBytes(composeContent)
+		composeChecksum = checksum
+		if pkg.SourceDigest != "" && pkg.SourceDigest != checksum {
+			composeChanged = true
+		}
 		cacheFileName := pakName + ".sha256"
 
 		if cachedChecksum, err := cacheRoot.ReadFile(cacheFileName); err != nil {
@@ -221,7 +227,7 @@ func checkPackage(pakFileName, pakName string, paksRoot, cacheRoot *os.Root, pak
 				}
 			}
 		} else if string(cachedChecksum) != composeChecksum {
-			composeChanged = true
+			composeChanged = composeChanged || normalizeChecksum(string(cachedChecksum)) != composeChecksum
 			if writeErr := cacheRoot.WriteFile(cacheFileName, []byte(composeChecksum), 0o600); writeErr != nil {
 				return UpdateResult{
 					PackageName:    pakName,
@@ -278,9 +284,23 @@ func fetchURL(url string) ([]byte, error) {
 	return io.ReadAll(resp.Body)
 }
 
-func checksumBytes(data []byte) string {
-	hash := sha256.Sum256(data)
-	return fmt.Sprintf("%x", hash)
+func checksumSources(sources corepkg.Sources) (string, error) {
+	contents := make([][]byte, 0, len(sources))
+	for _, source := range sources {
+		content, err := fetchURL(source)
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", source, err)
+		}
+		contents = append(contents, content)
+	}
+	return corepkg.SourcesDigest(contents), nil
+}
+
+func normalizeChecksum(checksum string) string {
+	if strings.HasPrefix(checksum, "sha256:") {
+		return checksum
+	}
+	return "sha256:" + checksum
 }
 
 func getLatestGitHubRelease(repoURL string) (string, error) {
@@ -347,7 +367,7 @@ func createPR(result UpdateResult) error {
 		}
 	}
 
-	if err := updatePackageVersion(result.UpdatedFile, result.LatestVersion); err != nil {
+	if err := updatePackageFile(result.UpdatedFile, result.LatestVersion, result.ComposeChecksum); err != nil {
 		return fmt.Errorf("failed to update package file: %w", err)
 	}
 
@@ -385,7 +405,7 @@ func createPR(result UpdateResult) error {
 	return nil
 }
 
-func updatePackageVersion(filePath, newVersion string) error {
+func updatePackageFile(filePath, newVersion, digest string) error {
 	dir := filepath.Dir(filePath)
 	fileName := filepath.Base(filePath)
 
@@ -404,19 +424,68 @@ func updatePackageVersion(filePath, newVersion string) error {
 		return err
 	}
 
-	var pkg Package
-	if err := yaml.Unmarshal(data, &pkg); err != nil {
+	updated, err := setPackageFields(data, newVersion, digest)
+	if err != nil {
 		return err
 	}
 
-	pkg.Version = newVersion
+	return root.WriteFile(fileName, updated, 0o600)
+}
 
-	updated, err := yaml.Marshal(&pkg)
-	if err != nil {
-		return err
+func setPackageFields(data []byte, newVersion, digest string) ([]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("package file is not a YAML mapping")
 	}
 
-	return root.WriteFile(fileName, updated, 0o600)
+	mapping := doc.Content[0]
+	if newVersion != "" {
+		setMappingValue(mapping, "version", newVersion, "")
+	}
+	if digest != "" {
+		setMappingValue(mapping, "sourceDigest", digest, "source")
+	}
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&doc); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func setMappingValue(mapping *yaml.Node, key, value, after string) {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			mapping.Content[i+1].SetString(value)
+			return
+		}
+	}
+
+	keyNode := &yaml.Node{Kind: yaml.ScalarNode, Value: key}
+	valueNode := &yaml.Node{}
+	valueNode.SetString(value)
+
+	insertAt := len(mapping.Content)
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == after {
+			insertAt = i + 2
+			break
+		}
+	}
+
+	content := make([]*yaml.Node, 0, len(mapping.Content)+2)
+	content = append(content, mapping.Content[:insertAt]...)
+	content = append(content, keyNode, valueNode)
+	content = append(content, mapping.Content[insertAt:]...)
+	mapping.Content = content
 }
 
 func runCommand(name string, args ...string) error {
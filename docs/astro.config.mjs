@@ -41,6 +41,11 @@ export default defineConfig({
 						{ label: 'uninstall', slug: 'reference/commands/uninstall' },
 						{ label: 'list', slug: 'reference/commands/list' },
 						{ label: 'status', slug: 'reference/commands/status' },
+						{ label: 'logs', slug: 'reference/commands/logs' },
+						{ label: 'backup / restore', slug: 'reference/commands/backup' },
+						{ label: 'diff / check / repair', slug: 'reference/commands/diff' },
+						{ label: 'adopt', slug: 'reference/commands/adopt' },
+						{ label: 'apply', slug: 'reference/commands/apply' },
 						{ label: 'search', slug: 'reference/commands/search' },
 						{ label: 'update', slug: 'reference/commands/update' },
 						{ label: 'extract', slug: 'reference/commands/extract' },
@@ -52,6 +57,7 @@ export default defineConfig({
 					items: [
 						{ label: 'Package Format', slug: 'reference/package-format' },
 						{ label: 'Environment Variables', slug: 'reference/environment' },
+						{ label: 'Configuration File', slug: 'reference/configuration' },
 					],
 				},
 			],
@@ -0,0 +1,144 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	pkg "github.com/LoriKarikari/compak/internal/core/package"
+)
+
+var adoptCmd = &cobra.Command{
+	Use:   "adopt [dir]",
+	Short: "Bring an existing compose project under compak management",
+	Long: `Adopt a compose project that was started by hand with 'docker compose up'.
+
+The compose files and .env in the directory are read, parameters are inferred
+from ${VAR} usage, and the project files are copied into compak's package
+directory. Data directories that are bind-mounted stay where they are and the
+project's named volumes are reused, so no data is moved.
+
+If the index has a pak with the package name (or the one given with --from)
+that declares every variable the project uses, it is recorded as the package's
+source so later upgrades follow the pak.
+
+The project is then relabelled as compak-<name>. When no service publishes
+ports, sets a container name or mounts data, the new containers are started
+before the old ones are removed; otherwise the old project is stopped first.`,
+	Example: `  # Adopt ./blog as the package "blog"
+  compak adopt ./blog --name blog
+
+  # The project was started with -p, and matches the nextcloud pak
+  compak adopt /srv/cloud --name cloud --project-name cloud --from nextcloud`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		opts, err := readAdoptOptions(cmd)
+		if err != nil {
+			return err
+		}
+		return runAdopt(commandContext(cmd), args[0], opts)
+	},
+}
+
+type adoptOptions struct {
+	pkg.AdoptOptions
+	from    string
+	noIndex bool
+}
+
+var loadIndexPackage = func(ctx context.Context, name string) (*pkg.Package, error) {
+	p, _, err := loadFromIndex(ctx, name, "", false)
+	return p, err
+}
+
+func readAdoptOptions(cmd *cobra.Command) (adoptOptions, error) {
+	var opts adoptOptions
+	var err error
+
+	if opts.Name, err = cmd.Flags().GetString("name"); err != nil {
+		return opts, fmt.Errorf("failed to get name flag: %w", err)
+	}
+	if opts.Name == "" {
+		return opts, fmt.Errorf("--name is required")
+	}
+	if err := validatePackageName(opts.Name); err != nil {
+		return opts, err
+	}
+
+	if opts.ProjectName, err = cmd.Flags().GetString("project-name"); err != nil {
+		return opts, fmt.Errorf("failed to get project-name flag: %w", err)
+	}
+
+	if opts.ComposeFiles, err = cmd.Flags().GetStringSlice("file"); err != nil {
+		return opts, fmt.Errorf("failed to get file flag: %w", err)
+	}
+
+	if opts.from, err = cmd.Flags().GetString("from"); err != nil {
+		return opts, fmt.Errorf("failed to get from flag: %w", err)
+	}
+
+	if opts.noIndex, err = cmd.Flags().GetBool("no-index"); err != nil {
+		return opts, fmt.Errorf("failed to get no-index flag: %w", err)
+	}
+
+	return opts, nil
+}
+
+func runAdopt(ctx context.Context, dir string, opts adoptOptions) error {
+	dir, err := validateLocalPath(dir)
+	if err != nil {
+		return err
+	}
+
+	manager, err := driftManager()
+	if err != nil {
+		return err
+	}
+
+	if !opts.noIndex {
+		opts.Candidate, opts.ForceMatch = findAdoptCandidate(ctx, opts)
+	}
+
+	fmt.Printf("Adopting %s as %s...\n", dir, opts.Name)
+	installedPkg, err := manager.Adopt(ctx, dir, opts.AdoptOptions)
+	if err != nil {
+		return fmt.Errorf("failed to adopt %s: %w", dir, err)
+	}
+
+	if opts.Candidate != nil {
+		if installedPkg.Package.Version == opts.Candidate.Version {
+			fmt.Printf("Matched index pak %s@%s\n", opts.Candidate.Name, opts.Candidate.Version)
+		} else {
+			fmt.Printf("Note: %s does not match pak %s; adopted as a local package\n", dir, opts.Candidate.Name)
+		}
+	}
+	fmt.Printf("Adopted %s with %d parameter(s); the original files in %s are no longer used\n",
+		installedPkg.Package.Name, len(installedPkg.Package.Parameters), dir)
+	return nil
+}
+
+func findAdoptCandidate(ctx context.Context, opts adoptOptions) (*pkg.Package, bool) {
+	name := opts.from
+	if name == "" {
+		name = opts.Name
+	}
+
+	candidate, err := loadIndexPackage(ctx, name)
+	if err != nil {
+		if opts.from != "" {
+			fmt.Printf("Warning: %v\n", err)
+		}
+		return nil, false
+	}
+	return candidate, opts.from != ""
+}
+
+func init() {
+	adoptCmd.Flags().String("name", "", "package name to adopt the project as (required)")
+	adoptCmd.Flags().StringP("project-name", "p", "", "current compose project name (default from COMPOSE_PROJECT_NAME, the name attribute or the directory)")
+	adoptCmd.Flags().StringSliceP("file", "f", nil, "compose files to read (default docker-compose.yaml and its override)")
+	adoptCmd.Flags().String("from", "", "index pak to record as the package source, even if its parameters differ")
+	adoptCmd.Flags().Bool("no-index", false, "do not look the project up in the index")
+	rootCmd.AddCommand(adoptCmd)
+}
@@ -0,0 +1,71 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/LoriKarikari/compak/internal/core/compose"
+	pkg "github.com/LoriKarikari/compak/internal/core/package"
+)
+
+func TestAdoptEndToEnd(t *testing.T) {
+	tests := []struct {
+		name      string
+		candidate *pkg.Package
+		expected  string
+	}{
+		{name: "local", expected: "0.0.0"},
+		{
+			name:      "index match",
+			candidate: &pkg.Package{Name: "demo", Version: "1.4.0", Parameters: map[string]pkg.Param{"GREETING": {Type: "string"}}},
+			expected:  "1.4.0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine := useFakeEngine(t)
+			previous := loadIndexPackage
+			loadIndexPackage = func(context.Context, string) (*pkg.Package, error) {
+				if tt.candidate == nil {
+					return nil, errors.New("not found")
+				}
+				return tt.candidate, nil
+			}
+			t.Cleanup(func() { loadIndexPackage = previous })
+
+			dir := writeLocalPackage(t, "1.0.0", "nginx:1.27")
+			if err := os.WriteFile(filepath.Join(dir, ".env"), []byte("GREETING=hey\n"), 0o600); err != nil {
+				t.Fatalf("Expected no error but got: %v", err)
+			}
+			existing, err := compose.LoadProject(dir, "legacy")
+			if err != nil {
+				t.Fatalf("Expected no error but got: %v", err)
+			}
+			ctx := context.Background()
+			if err := engine.Up(ctx, existing, true, nil); err != nil {
+				t.Fatalf("Expected no error but got: %v", err)
+			}
+
+			opts := adoptOptions{AdoptOptions: pkg.AdoptOptions{Name: "demo", ProjectName: "legacy"}}
+			if err := runAdopt(ctx, dir, opts); err != nil {
+				t.Fatalf("adopt failed: %v", err)
+			}
+
+			client, _ := newTestManager(t, engine)
+			installed, err := client.GetInstalledPackage("demo")
+			if err != nil {
+				t.Fatalf("Expected demo to be installed: %v", err)
+			}
+			if installed.Package.Version != tt.expected || installed.Values["GREETING"] != "hey" {
+				t.Errorf("Expected demo@%s with GREETING=hey, got %+v", tt.expected, installed)
+			}
+			if engine.Running("legacy") || !engine.Running("compak-demo") {
+				t.Error("Expected the project to be relabelled as compak-demo")
+			}
+		})
+	}
+}
@@ -0,0 +1,334 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/LoriKarikari/compak/internal/config"
+	pkg "github.com/LoriKarikari/compak/internal/core/package"
+	"github.com/LoriKarikari/compak/internal/core/policy"
+	"github.com/LoriKarikari/compak/internal/core/stack"
+)
+
+var applyCmd = &cobra.Command{
+	Use:   "apply",
+	Short: "Install, upgrade and reconfigure packages from a stack file",
+	Long: `Bring the installed packages in line with a declarative stack file.
+
+The stack file lists packages by instance name, with the index pak (or a local
+path), a version or semver constraint, and parameter values. Values can be read
+from the environment or a file so that secrets stay out of the stack file:
+
+  packages:
+    - name: blog
+      pak: wordpress
+      version: ^6.4
+      values:
+        PORT: "8080"
+        DB_PASSWORD: {env: BLOG_DB_PASSWORD}
+        API_KEY: {file: /run/secrets/blog_api_key}
+
+apply compares the stack file with installed.json, prints a plan of installs,
+upgrades, reconfigurations and (with --prune) uninstalls, and executes it after
+confirmation. Secret values are never printed.`,
+	Example: `  # Show what would change
+  compak apply -f compak.yaml --dry-run
+
+  # Apply without asking, and uninstall packages not in the file
+  compak apply -f compak.yaml --prune --yes
+
+  # Wait for every installed or upgraded package to become healthy
+  compak apply --wait --wait-timeout 10m`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		opts, err := readApplyOptions(cmd)
+		if err != nil {
+			return err
+		}
+		return runApply(commandContext(cmd), opts)
+	},
+}
+
+type applyOptions struct {
+	file              string
+	prune             bool
+	dryRun            bool
+	yes               bool
+	quietUnpinned     bool
+	requireSignatures bool
+	acceptRisk        bool
+	wait              bool
+	waitTimeout       time.Duration
+}
+
+func readApplyOptions(cmd *cobra.Command) (applyOptions, error) {
+	var opts applyOptions
+	var err error
+
+	if opts.file, err = cmd.Flags().GetString("file"); err != nil {
+		return opts, fmt.Errorf("failed to get file flag: %w", err)
+	}
+	if opts.prune, err = cmd.Flags().GetBool("prune"); err != nil {
+		return opts, fmt.Errorf("failed to get prune flag: %w", err)
+	}
+	if opts.dryRun, err = cmd.Flags().GetBool("dry-run"); err != nil {
+		return opts, fmt.Errorf("failed to get dry-run flag: %w", err)
+	}
+	if opts.yes, err = cmd.Flags().GetBool("yes"); err != nil {
+		return opts, fmt.Errorf("failed to get yes flag: %w", err)
+	}
+	if opts.quietUnpinned, err = cmd.Flags().GetBool("quiet-unpinned"); err != nil {
+		return opts, fmt.Errorf("failed to get quiet-unpinned flag: %w", err)
+	}
+	if opts.requireSignatures, err = cmd.Flags().GetBool("require-signatures"); err != nil {
+		return opts, fmt.Errorf("failed to get require-signatures flag: %w", err)
+	}
+	if opts.acceptRisk, err = cmd.Flags().GetBool("accept-risk"); err != nil {
+		return opts, fmt.Errorf("failed to get accept-risk flag: %w", err)
+	}
+	if opts.wait, opts.waitTimeout, err = readWaitFlags(cmd); err != nil {
+		return opts, err
+	}
+	return opts, nil
+}
+
+func runApply(ctx context.Context, opts applyOptions) error {
+	file, err := stack.Load(opts.file)
+	if err != nil {
+		return err
+	}
+
+	manager, err := driftManager()
+	if err != nil {
+		return err
+	}
+	client, err := contextClient()
+	if err != nil {
+		return err
+	}
+	installed, err := client.List()
+	if err != nil {
+		return fmt.Errorf("failed to list packages: %w", err)
+	}
+
+	desired, err := resolveStack(ctx, manager, file, installed, opts.requireSignatures)
+	if err != nil {
+		return err
+	}
+
+	plan, err := stack.NewPlan(desired, installed, opts.prune)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Plan for %s:\n%s", opts.file, plan)
+	if plan.Empty() {
+		fmt.Println("Nothing to do")
+		return nil
+	}
+	if opts.dryRun {
+		return nil
+	}
+	if !opts.yes && !confirm("Apply this plan?") {
+		return fmt.Errorf("apply cancelled")
+	}
+
+	return executePlan(manager, plan, opts)
+}
+
+func contextClient() (*pkg.Client, error) {
+	stateDir, err := contextStateDir()
+	if err != nil {
+		return nil, err
+	}
+	return pkg.NewClient(stateDir), nil
+}
+
+func resolveStack(ctx context.Context, manager *pkg.Manager, file stack.File, installed []pkg.InstalledPackage, requireSignatures bool) ([]stack.Desired, error) {
+	byName := make(map[string]*pkg.InstalledPackage, len(installed))
+	for i := range installed {
+		byName[installed[i].Package.Name] = &installed[i]
+	}
+
+	desired := make([]stack.Desired, 0, len(file.Packages))
+	for _, entry := range file.Packages {
+		if err := validatePackageName(entry.Name); err != nil {
+			return nil, err
+		}
+
+		values, err := entry.ResolveValues()
+		if err != nil {
+			return nil, err
+		}
+
+		target, err := resolveEntryPackage(ctx, manager, entry, byName[entry.Name], requireSignatures)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", entry.Name, err)
+		}
+		target.Name = entry.Name
+
+		if err := validateParameters(&target, values); err != nil {
+			return nil, fmt.Errorf("%s: parameter validation failed: %w", entry.Name, err)
+		}
+		desired = append(desired, stack.Desired{Entry: entry, Package: target, Values: values})
+	}
+	return desired, nil
+}
+
+func resolveEntryPackage(ctx context.Context, manager *pkg.Manager, entry stack.Entry, current *pkg.InstalledPackage, requireSignatures bool) (pkg.Package, error) {
+	if entry.Path != "" {
+		local, err := manager.LoadPackageFromDir(entry.Path)
+		if err != nil {
+			return pkg.Package{}, fmt.Errorf("failed to load package from %s: %w", entry.Path, err)
+		}
+		return *local, nil
+	}
+
+	pak := entry.PakName()
+	if stack.IsExactVersion(entry.Version) {
+		return loadIndexVersion(ctx, pak, entry.Version, requireSignatures)
+	}
+
+	latest, err := loadStackPackage(ctx, pak, requireSignatures)
+	if err != nil {
+		return pkg.Package{}, err
+	}
+	if entry.Version == "" || entry.Version == "latest" {
+		return *latest, nil
+	}
+	versions, err := loadStackVersions(ctx, pak, requireSignatures)
+	if err != nil {
+		return pkg.Package{}, err
+	}
+	version, err := stack.ResolveVersion(entry.Version, latest.Version, versions)
+	if err != nil {
+		return pkg.Package{}, err
+	}
+	switch {
+	case version == latest.Version:
+		return *latest, nil
+	case current != nil && current.Package.Version == version:
+		return current.Package, nil
+	}
+	return loadIndexVersion(ctx, pak, version, requireSignatures)
+}
+
+var loadStackPackage = func(ctx context.Context, name string, requireSignatures bool) (*pkg.Package, error) {
+	p, _, err := loadFromIndex(ctx, name, "", requireSignatures)
+	return p, err
+}
+
+var loadStackVersions = func(ctx context.Context, name string, requireSignatures bool) ([]string, error) {
+	indexClient, err := newIndexClient(requireSignatures)
+	if err != nil {
+		return nil, err
+	}
+	versions, err := indexClient.Versions(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list versions of %q in the index: %w", name, err)
+	}
+	return versions, nil
+}
+
+func loadIndexVersion(ctx context.Context, pak, version string, requireSignatures bool) (pkg.Package, error) {
+	versioned, err := loadStackPackage(ctx, pak+"@"+version, requireSignatures)
+	if err != nil {
+		return pkg.Package{}, err
+	}
+	if strings.TrimPrefix(versioned.Version, "v") != strings.TrimPrefix(version, "v") {
+		return pkg.Package{}, fmt.Errorf("version %s of %s not found in the index", version, pak)
+	}
+	return *versioned, nil
+}
+
+func executePlan(manager *pkg.Manager, plan stack.Plan, opts applyOptions) error {
+	stateDir, err := config.GetStateDir()
+	if err != nil {
+		return fmt.Errorf("failed to get state directory: %w", err)
+	}
+	cfg, err := config.Load(stateDir)
+	if err != nil {
+		return err
+	}
+
+	for i, step := range plan.Steps {
+		fmt.Printf("\n[%d/%d] %s\n", i+1, len(plan.Steps), step)
+		if err := executeStep(manager, step, cfg.Policy, opts); err != nil {
+			return fmt.Errorf("apply stopped after %d of %d step(s): %s: %w", i, len(plan.Steps), step.Name, err)
+		}
+	}
+
+	fmt.Printf("\nApplied %d change(s)\n", len(plan.Steps))
+	return nil
+}
+
+func executeStep(manager *pkg.Manager, step stack.Step, deployPolicy policy.Policy, applyOpts applyOptions) error {
+	switch step.Action {
+	case stack.ActionUninstall:
+		removal, err := manager.PlanRemoval(step.Name, pkg.RemoveOptions{KeepData: true})
+		if err != nil {
+			return err
+		}
+		return manager.RemoveWithPlan(removal)
+	case stack.ActionUpgrade:
+		return performUpgrade(manager, step.Name, step.Installed, step.Desired.Package, upgradeOptions{
+			quietUnpinned:     applyOpts.quietUnpinned,
+			requireSignatures: applyOpts.requireSignatures,
+			acceptRisk:        applyOpts.acceptRisk,
+			wait:              applyOpts.wait,
+			waitTimeout:       applyOpts.waitTimeout,
+			policy:            deployPolicy,
+			values:            step.Desired.Values,
+			sourcePath:        step.Desired.Entry.Path,
+			indexCommit:       stackIndexCommit(step.Desired),
+		})
+	}
+
+	opts := pkg.DeployOptions{
+		SourcePath:    step.Desired.Entry.Path,
+		QuietUnpinned: applyOpts.quietUnpinned,
+		Policy:        deployPolicy,
+		AcceptRisk:    applyOpts.acceptRisk,
+		Wait:          applyOpts.wait,
+		WaitTimeout:   applyOpts.waitTimeout,
+		Pak:           stackPak(step.Desired),
+		IndexCommit:   stackIndexCommit(step.Desired),
+		RemoteHost:    remoteHost(),
+	}
+	if step.Installed != nil {
+		opts.Overlays = step.Installed.Overlays
+		opts.Pak = step.Installed.Pak
+		opts.PinImages = step.Installed.ImagesPinned
+	}
+	return manager.DeployWithOptions(step.Desired.Package, step.Desired.Values, opts)
+}
+
+func stackPak(desired *stack.Desired) string {
+	if pak := desired.Entry.PakName(); pak != desired.Package.Name {
+		return pak
+	}
+	return ""
+}
+
+func stackIndexCommit(desired *stack.Desired) string {
+	if desired.Entry.Path != "" {
+		return ""
+	}
+	return indexRevision()
+}
+
+func init() {
+	applyCmd.Flags().StringP("file", "f", stack.DefaultFile, "stack file to apply")
+	applyCmd.Flags().Bool("prune", false, "uninstall packages that are not in the stack file (keeping their data)")
+	applyCmd.Flags().Bool("dry-run", false, "print the plan without executing it")
+	applyCmd.Flags().BoolP("yes", "y", false, "do not ask for confirmation")
+	applyCmd.Flags().Bool("quiet-unpinned", false, "do not warn when a compose source has no sourceDigest (a digest mismatch still fails)")
+	applyCmd.Flags().Bool("require-signatures", false, "refuse index packages without a signature from a trusted key")
+	applyCmd.Flags().Bool("accept-risk", false, "deploy even if the security policy denies a compose configuration")
+	addWaitFlags(applyCmd)
+	rootCmd.AddCommand(applyCmd)
+}
@@ -0,0 +1,178 @@
+package cli
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	pkg "github.com/LoriKarikari/compak/internal/core/package"
+	"github.com/LoriKarikari/compak/internal/core/stack"
+)
+
+func writeStackFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "compak.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write stack file: %v", err)
+	}
+	return path
+}
+
+func TestApplyEndToEnd(t *testing.T) {
+	engine := useFakeEngine(t)
+	ctx := context.Background()
+	t.Setenv("BLOG_GREETING", "hi")
+
+	v1 := writeLocalPackage(t, "1.0.0", "nginx:1.26")
+	v2 := writeLocalPackage(t, "2.0.0", "nginx:1.27")
+
+	initial := writeStackFile(t, "packages:\n"+
+		"  - name: blog\n    path: "+v1+"\n    values:\n      GREETING: {env: BLOG_GREETING}\n"+
+		"  - name: shop\n    path: "+v1+"\n")
+	if err := runApply(ctx, applyOptions{file: initial, yes: true}); err != nil {
+		t.Fatalf("apply failed: %v", err)
+	}
+
+	client, _ := newTestManager(t, engine)
+	blog, err := client.GetInstalledPackage("blog")
+	if err != nil {
+		t.Fatalf("Expected blog to be installed: %v", err)
+	}
+	if blog.Values["GREETING"] != "hi" || !engine.Running("compak-blog") || !engine.Running("compak-shop") {
+		t.Errorf("Expected blog and shop to be running with the secret value, got %+v", blog)
+	}
+
+	calls := len(engine.Calls())
+	if err := runApply(ctx, applyOptions{file: initial, yes: true}); err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+	if len(engine.Calls()) != calls {
+		t.Errorf("Expected an unchanged stack to do nothing, got %+v", engine.Calls()[calls:])
+	}
+
+	updated := writeStackFile(t, "packages:\n  - name: blog\n    path: "+v2+"\n")
+	if err := runApply(ctx, applyOptions{file: updated, dryRun: true}); err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+	if len(engine.Calls()) != calls {
+		t.Error("Expected a dry run to leave the engine untouched")
+	}
+
+	if err := runApply(ctx, applyOptions{file: updated, prune: true, yes: true}); err != nil {
+		t.Fatalf("apply failed: %v", err)
+	}
+	blog, err = client.GetInstalledPackage("blog")
+	if err != nil {
+		t.Fatalf("Expected blog to stay installed: %v", err)
+	}
+	if blog.Package.Version != "2.0.0" || blog.Values["GREETING"] != "hello" {
+		t.Errorf("Expected blog@2.0.0 with the default greeting, got %+v", blog)
+	}
+	if _, err := client.GetInstalledPackage("shop"); err == nil || engine.Running("compak-shop") {
+		t.Error("Expected shop to be pruned")
+	}
+}
+
+func TestApplyWaitMarksFailed(t *testing.T) {
+	engine := useFakeEngine(t)
+	engine.HealthNext("unhealthy")
+	source := writeLocalPackage(t, "1.0.0", "nginx:1.27")
+
+	file := writeStackFile(t, "packages:\n  - name: blog\n    path: "+source+"\n")
+	if err := runApply(context.Background(), applyOptions{file: file, yes: true, wait: true, waitTimeout: 50 * time.Millisecond}); err == nil {
+		t.Fatal("Expected apply to fail when blog does not become healthy")
+	}
+
+	client, _ := newTestManager(t, engine)
+	blog, err := client.GetInstalledPackage("blog")
+	if err != nil {
+		t.Fatalf("Expected blog to be recorded: %v", err)
+	}
+	if blog.Status != "failed" {
+		t.Errorf("Expected status failed, got %s", blog.Status)
+	}
+}
+
+func TestLoadIndexVersion(t *testing.T) {
+	previous := loadStackPackage
+	t.Cleanup(func() { loadStackPackage = previous })
+
+	var requested []bool
+	loadStackPackage = func(_ context.Context, name string, requireSignatures bool) (*pkg.Package, error) {
+		requested = append(requested, requireSignatures)
+		return &pkg.Package{Name: "nginx", Version: "1.27.0"}, nil
+	}
+
+	tests := []struct {
+		name      string
+		version   string
+		expectErr bool
+	}{
+		{name: "exact", version: "1.27.0"},
+		{name: "v prefix", version: "v1.27.0"},
+		{name: "other version", version: "1.26.0", expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := loadIndexVersion(context.Background(), "nginx", tt.version, true)
+			if tt.expectErr != (err != nil) {
+				t.Errorf("Expected error %v, got %v", tt.expectErr, err)
+			}
+		})
+	}
+	for _, requireSignatures := range requested {
+		if !requireSignatures {
+			t.Error("Expected --require-signatures to be passed to the index")
+		}
+	}
+}
+
+func TestResolveEntryPackageConstraint(t *testing.T) {
+	previousPackage, previousVersions := loadStackPackage, loadStackVersions
+	t.Cleanup(func() { loadStackPackage, loadStackVersions = previousPackage, previousVersions })
+
+	var loaded []string
+	loadStackPackage = func(_ context.Context, name string, _ bool) (*pkg.Package, error) {
+		loaded = append(loaded, name)
+		version := "1.4.0"
+		if _, v, ok := strings.Cut(name, "@"); ok {
+			version = v
+		}
+		return &pkg.Package{Name: "nginx", Version: version}, nil
+	}
+	loadStackVersions = func(context.Context, string, bool) ([]string, error) {
+		return []string{"1.4.0", "1.2.3", "1.2.0"}, nil
+	}
+
+	tests := []struct {
+		name      string
+		current   *pkg.InstalledPackage
+		expected  string
+		fromIndex bool
+	}{
+		{name: "not installed", expected: "1.2.3", fromIndex: true},
+		{name: "older patch installed", current: &pkg.InstalledPackage{Package: pkg.Package{Name: "nginx", Version: "1.2.0"}}, expected: "1.2.3", fromIndex: true},
+		{name: "highest match installed", current: &pkg.InstalledPackage{Package: pkg.Package{Name: "nginx", Version: "1.2.3"}}, expected: "1.2.3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loaded = nil
+			got, err := resolveEntryPackage(context.Background(), nil, stack.Entry{Name: "nginx", Version: "~1.2.0"}, tt.current, false)
+			if err != nil {
+				t.Fatalf("Expected no error but got: %v", err)
+			}
+			if got.Version != tt.expected {
+				t.Errorf("Expected version %s, got %s", tt.expected, got.Version)
+			}
+			if fromIndex := slices.Contains(loaded, "nginx@"+tt.expected); fromIndex != tt.fromIndex {
+				t.Errorf("Expected loading nginx@%s from the index to be %v, got loads %v", tt.expected, tt.fromIndex, loaded)
+			}
+		})
+	}
+}
@@ -0,0 +1,148 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	pkg "github.com/LoriKarikari/compak/internal/core/package"
+)
+
+var backupCmd = &cobra.Command{
+	Use:   "backup [package]",
+	Short: "Back up a package's files and volumes",
+	Long: `Back up an installed package into a single .tar.zst archive.
+
+The package is stopped (or paused with --pause) while its named volumes, its
+package directory with rendered files and bind-mounted data, and its entry in
+installed.json are archived, and started again afterwards. Bind mounts outside
+the package directory and external volumes are not included.`,
+	Example: `  # Back up immich to immich-<version>-<timestamp>.tar.zst
+  compak backup immich
+
+  # Pause instead of stopping and choose the output file
+  compak backup immich --pause -o /backups/immich.tar.zst`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		output, err := cmd.Flags().GetString("output")
+		if err != nil {
+			return fmt.Errorf("failed to get output flag: %w", err)
+		}
+		pause, err := cmd.Flags().GetBool("pause")
+		if err != nil {
+			return fmt.Errorf("failed to get pause flag: %w", err)
+		}
+
+		return runBackup(commandContext(cmd), args[0], output, pkg.BackupOptions{Pause: pause})
+	},
+}
+
+var restoreCmd = &cobra.Command{
+	Use:   "restore [file]",
+	Short: "Restore a package from a backup archive",
+	Long: `Restore a package from an archive created with 'compak backup'.
+
+The package directory, named volumes and installed.json entry are recreated in
+the current Docker context and the package is started. The package must not be
+installed already.`,
+	Example: `  compak restore immich-1.145.0-20250101-120000.tar.zst`,
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runRestore(commandContext(cmd), args[0])
+	},
+}
+
+func runBackup(ctx context.Context, packageName, output string, opts pkg.BackupOptions) error {
+	if err := validatePackageName(packageName); err != nil {
+		return err
+	}
+
+	stateDir, err := contextStateDir()
+	if err != nil {
+		return err
+	}
+
+	client := pkg.NewClient(stateDir)
+	installedPkg, err := client.GetInstalledPackage(packageName)
+	if err != nil {
+		return fmt.Errorf("package '%s' is not installed", packageName)
+	}
+
+	engine, err := newEngine()
+	if err != nil {
+		return fmt.Errorf("failed to create compose client: %w", err)
+	}
+
+	if output == "" {
+		output = backupFileName(installedPkg, time.Now())
+	}
+
+	manager := pkg.NewManager(client, engine, stateDir)
+	if err := writeBackupFile(ctx, manager, packageName, output, opts); err != nil {
+		return err
+	}
+
+	fmt.Printf("Backed up %s to %s\n", packageName, output)
+	return nil
+}
+
+func writeBackupFile(ctx context.Context, manager *pkg.Manager, packageName, output string, opts pkg.BackupOptions) error {
+	if err := os.MkdirAll(filepath.Dir(output), 0o750); err != nil {
+		return fmt.Errorf("failed to create backup directory: %w", err)
+	}
+
+	file, err := os.OpenFile(output, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to create backup file: %w", err)
+	}
+
+	err = manager.Backup(ctx, packageName, file, opts)
+	err = errors.Join(err, file.Close())
+	if err != nil {
+		_ = os.Remove(output)
+		return fmt.Errorf("failed to back up %s: %w", packageName, err)
+	}
+	return nil
+}
+
+func backupFileName(installedPkg pkg.InstalledPackage, now time.Time) string {
+	return fmt.Sprintf("%s-%s-%s.tar.zst", installedPkg.Package.Name, installedPkg.Package.Version, now.Format("20060102-150405"))
+}
+
+func runRestore(ctx context.Context, file string) error {
+	stateDir, err := contextStateDir()
+	if err != nil {
+		return err
+	}
+
+	archive, err := os.Open(file)
+	if err != nil {
+		return fmt.Errorf("failed to open backup: %w", err)
+	}
+	defer func() { _ = archive.Close() }()
+
+	engine, err := newEngine()
+	if err != nil {
+		return fmt.Errorf("failed to create compose client: %w", err)
+	}
+
+	manager := pkg.NewManager(pkg.NewClient(stateDir), engine, stateDir)
+	installedPkg, err := manager.Restore(ctx, archive)
+	if err != nil {
+		return fmt.Errorf("failed to restore %s: %w", file, err)
+	}
+
+	fmt.Printf("Restored %s@%s\n", installedPkg.Package.Name, installedPkg.Package.Version)
+	return nil
+}
+
+func init() {
+	backupCmd.Flags().StringP("output", "o", "", "archive file to write (default <package>-<version>-<timestamp>.tar.zst)")
+	backupCmd.Flags().Bool("pause", false, "pause containers instead of stopping them during the backup")
+	rootCmd.AddCommand(backupCmd, restoreCmd)
+}
@@ -0,0 +1,51 @@
+package cli
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/LoriKarikari/compak/internal/core/compose/composetest"
+	pkg "github.com/LoriKarikari/compak/internal/core/package"
+)
+
+func TestBackupRestoreEndToEnd(t *testing.T) {
+	engine := useFakeEngine(t)
+	source := writeLocalPackage(t, "1.0.0", "nginx:1.27")
+
+	ctx := context.Background()
+	if err := runInstall(ctx, "demo", installOptions{localPath: source, setValues: []string{"GREETING=hi"}}); err != nil {
+		t.Fatalf("install failed: %v", err)
+	}
+
+	archive := filepath.Join(t.TempDir(), "demo.tar.zst")
+	if err := runBackup(ctx, "demo", archive, pkg.BackupOptions{}); err != nil {
+		t.Fatalf("backup failed: %v", err)
+	}
+	if err := runBackup(ctx, "demo", archive, pkg.BackupOptions{}); err == nil {
+		t.Error("Expected error when the backup file already exists")
+	}
+
+	if err := runRestore(ctx, archive); err == nil {
+		t.Error("Expected error restoring a package that is still installed")
+	}
+
+	if err := runUninstall("demo", pkg.RemoveOptions{Purge: true}, true); err != nil {
+		t.Fatalf("uninstall failed: %v", err)
+	}
+	if err := runRestore(ctx, archive); err != nil {
+		t.Fatalf("restore failed: %v", err)
+	}
+
+	client, _ := newTestManager(t, engine)
+	installed, err := client.GetInstalledPackage("demo")
+	if err != nil {
+		t.Fatalf("Expected demo to be installed after restore: %v", err)
+	}
+	if installed.Values["GREETING"] != "hi" || installed.Status != "installed" {
+		t.Errorf("Expected restored values and installed status, got %+v", installed)
+	}
+	if !engine.Running("compak-demo") || engine.Count(composetest.MethodUp) != 2 {
+		t.Errorf("Expected restored project to be started, got %+v", engine.Calls())
+	}
+}
@@ -0,0 +1,176 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	pkg "github.com/LoriKarikari/compak/internal/core/package"
+)
+
+var diffCmd = &cobra.Command{
+	Use:   "diff [package]",
+	Short: "Show drift between a package's recorded state and what is running",
+	Long: `Compare an installed package with what compak recorded at install time.
+
+The following differences are reported:
+  value    a value in the package's .env file was added, removed or changed
+  file     a compose file was modified or removed since install
+  missing  a service has no container
+  image    a container runs a different image than the compose file declares
+  config   a container was created from a different service configuration
+  state    a container is not running although the package is not stopped
+  orphan   a container belongs to the project but not to the compose file
+
+Values are never printed, only the names of the keys that differ.`,
+	Example: `  compak diff immich`,
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		manager, err := driftManager()
+		if err != nil {
+			return err
+		}
+
+		report, err := manager.Diff(commandContext(cmd), args[0])
+		if err != nil {
+			return err
+		}
+		writeDrift(os.Stdout, report)
+		return nil
+	},
+}
+
+var checkCmd = &cobra.Command{
+	Use:   "check [package...]",
+	Short: "Check installed packages for drift",
+	Long: `Check one or more installed packages for drift, or every installed package
+when none are given. The command fails if any drift is found, which makes it
+suitable for cron jobs and monitoring.`,
+	Example: `  # Check every installed package
+  compak check
+
+  # Check specific packages
+  compak check immich nextcloud`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		manager, err := driftManager()
+		if err != nil {
+			return err
+		}
+
+		if len(args) == 0 {
+			if args, err = installedPackageNames(); err != nil {
+				return err
+			}
+		}
+		return runCheck(commandContext(cmd), os.Stdout, manager, args)
+	},
+}
+
+var repairCmd = &cobra.Command{
+	Use:   "repair [package]",
+	Short: "Reconcile a package with its recorded state",
+	Long: `Bring a package back in line with the state compak recorded at install time.
+
+The .env file is rewritten from the stored values and the compose project is
+brought up again, which recreates missing, stopped or outdated containers and
+removes orphans. Modified compose files cannot be restored; use --accept to
+record the files and values currently on disk as the new desired state instead.`,
+	Example: `  # Restore the recorded values and recreate drifted containers
+  compak repair immich
+
+  # Keep local edits and make them the new recorded state
+  compak repair immich --accept`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		accept, err := cmd.Flags().GetBool("accept")
+		if err != nil {
+			return fmt.Errorf("failed to get accept flag: %w", err)
+		}
+
+		manager, err := driftManager()
+		if err != nil {
+			return err
+		}
+
+		report, err := manager.Repair(commandContext(cmd), args[0], pkg.RepairOptions{Accept: accept})
+		if err != nil {
+			return fmt.Errorf("failed to repair %s: %w", args[0], err)
+		}
+		if report.Clean() {
+			fmt.Printf("No drift detected for %s\n", args[0])
+			return nil
+		}
+		fmt.Printf("Repaired %d difference(s) in %s\n", len(report.Drifts), args[0])
+		return nil
+	},
+}
+
+func driftManager() (*pkg.Manager, error) {
+	stateDir, err := contextStateDir()
+	if err != nil {
+		return nil, err
+	}
+
+	engine, err := newEngine()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create compose client: %w", err)
+	}
+	return pkg.NewManager(pkg.NewClient(stateDir), engine, stateDir), nil
+}
+
+func installedPackageNames() ([]string, error) {
+	stateDir, err := contextStateDir()
+	if err != nil {
+		return nil, err
+	}
+
+	packages, err := pkg.NewClient(stateDir).List()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list packages: %w", err)
+	}
+
+	names := make([]string, 0, len(packages))
+	for _, installedPkg := range packages {
+		names = append(names, installedPkg.Package.Name)
+	}
+	return names, nil
+}
+
+func runCheck(ctx context.Context, out io.Writer, manager *pkg.Manager, packages []string) error {
+	drifted := 0
+	for _, packageName := range packages {
+		report, err := manager.Diff(ctx, packageName)
+		if err != nil {
+			return fmt.Errorf("failed to check %s: %w", packageName, err)
+		}
+		writeDrift(out, report)
+		if !report.Clean() {
+			drifted++
+		}
+	}
+
+	if drifted > 0 {
+		return fmt.Errorf("%d of %d package(s) drifted", drifted, len(packages))
+	}
+	return nil
+}
+
+func writeDrift(out io.Writer, report pkg.DriftReport) {
+	if report.Clean() {
+		_, _ = fmt.Fprintf(out, "%s: no drift detected\n", report.Package)
+		return
+	}
+
+	_, _ = fmt.Fprintf(out, "%s: %d difference(s)\n", report.Package, len(report.Drifts))
+	for _, drift := range report.Drifts {
+		_, _ = fmt.Fprintf(out, "  %s\n", drift)
+	}
+}
+
+func init() {
+	repairCmd.Flags().Bool("accept", false, "record the files and values on disk as the desired state instead of restoring them")
+	rootCmd.AddCommand(diffCmd, checkCmd, repairCmd)
+}
@@ -0,0 +1,49 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	pkg "github.com/LoriKarikari/compak/internal/core/package"
+)
+
+func TestCheckAndRepairEndToEnd(t *testing.T) {
+	engine := useFakeEngine(t)
+	source := writeLocalPackage(t, "1.0.0", "nginx:1.27")
+
+	ctx := context.Background()
+	if err := runInstall(ctx, "demo", installOptions{localPath: source}); err != nil {
+		t.Fatalf("install failed: %v", err)
+	}
+
+	manager, err := driftManager()
+	if err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+
+	var out bytes.Buffer
+	if err := runCheck(ctx, &out, manager, []string{"demo"}); err != nil {
+		t.Fatalf("Expected a fresh install to have no drift, got %v:\n%s", err, out.String())
+	}
+
+	if err := engine.SetImage("compak-demo", "web", "nginx:1.25"); err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+	out.Reset()
+	if err := runCheck(ctx, &out, manager, []string{"demo"}); err == nil {
+		t.Error("Expected check to fail for a drifted package")
+	}
+	if !strings.Contains(out.String(), "runs nginx:1.25, expected nginx:1.27") {
+		t.Errorf("Expected image drift in output, got:\n%s", out.String())
+	}
+
+	if _, err := manager.Repair(ctx, "demo", pkg.RepairOptions{}); err != nil {
+		t.Fatalf("repair failed: %v", err)
+	}
+	out.Reset()
+	if err := runCheck(ctx, &out, manager, []string{"demo"}); err != nil {
+		t.Errorf("Expected no drift after repair, got %v:\n%s", err, out.String())
+	}
+}
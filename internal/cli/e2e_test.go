@@ -0,0 +1,511 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/LoriKarikari/compak/internal/config"
+	"github.com/LoriKarikari/compak/internal/core/compose"
+	"github.com/LoriKarikari/compak/internal/core/compose/composetest"
+	pkg "github.com/LoriKarikari/compak/internal/core/package"
+)
+
+func useFakeEngine(t *testing.T) *composetest.Engine {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("DOCKER_CONFIG", filepath.Join(home, ".docker"))
+	t.Setenv("DOCKER_HOST", "")
+	t.Setenv("DOCKER_CONTEXT", "")
+
+	engine := composetest.NewEngine()
+	previous := newEngine
+	newEngine = func() (compose.Engine, error) { return engine, nil }
+	t.Cleanup(func() { newEngine = previous })
+	return engine
+}
+
+func writeLocalPackage(t *testing.T, version, image string) string {
+	t.Helper()
+	dir := t.TempDir()
+
+	packageYAML := "name: demo\nversion: " + version + "\ndescription: demo\nparameters:\n  GREETING:\n    type: string\n    default: hello\n"
+	composeYAML := "services:\n  web:\n    image: " + image + "\n    environment:\n      GREETING: ${GREETING}\n"
+
+	if err := os.WriteFile(filepath.Join(dir, "package.yaml"), []byte(packageYAML), 0o600); err != nil {
+		t.Fatalf("Failed to write package.yaml: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "docker-compose.yaml"), []byte(composeYAML), 0o600); err != nil {
+		t.Fatalf("Failed to write compose file: %v", err)
+	}
+	return dir
+}
+
+func newTestManager(t *testing.T, engine compose.Engine) (*pkg.Client, *pkg.Manager) {
+	t.Helper()
+	stateDir, err := config.GetStateDir()
+	if err != nil {
+		t.Fatalf("Failed to get state dir: %v", err)
+	}
+	client := pkg.NewClient(stateDir)
+	return client, pkg.NewManager(client, engine, stateDir)
+}
+
+func TestInstallUninstallEndToEnd(t *testing.T) {
+	engine := useFakeEngine(t)
+	source := writeLocalPackage(t, "1.0.0", "nginx:1.27")
+
+	err := runInstall(context.Background(), "demo", installOptions{
+		localPath: source,
+		setValues: []string{"GREETING=hi"},
+	})
+	if err != nil {
+		t.Fatalf("install failed: %v", err)
+	}
+
+	if engine.Count(composetest.MethodPull) != 1 || engine.Count(composetest.MethodUp) != 1 {
+		t.Errorf("Expected one pull and one up, got %+v", engine.Calls())
+	}
+	project := engine.Project("compak-demo")
+	if project == nil {
+		t.Fatal("Expected compak-demo project to be running")
+	}
+	if greeting := project.Services["web"].Environment["GREETING"]; greeting == nil || *greeting != "hi" {
+		t.Errorf("Expected GREETING=hi in deployed project, got %v", greeting)
+	}
+
+	client, _ := newTestManager(t, engine)
+	installed, err := client.GetInstalledPackage("demo")
+	if err != nil {
+		t.Fatalf("Expected demo to be recorded: %v", err)
+	}
+	if installed.Status != "installed" || installed.Values["GREETING"] != "hi" {
+		t.Errorf("Unexpected installed state: %+v", installed)
+	}
+
+	if err := uninstallCmd.RunE(uninstallCmd, []string{"demo"}); err != nil {
+		t.Fatalf("uninstall failed: %v", err)
+	}
+	if engine.Running("compak-demo") {
+		t.Error("Expected containers to be removed")
+	}
+	if _, err := client.GetInstalledPackage("demo"); err == nil {
+		t.Error("Expected demo to be removed from state")
+	}
+}
+
+func TestInstallFailureIsNotRecorded(t *testing.T) {
+	engine := useFakeEngine(t)
+	engine.Fail(composetest.MethodUp, errors.New("daemon unavailable"))
+	source := writeLocalPackage(t, "1.0.0", "nginx:1.27")
+
+	if err := runInstall(context.Background(), "demo", installOptions{localPath: source}); err == nil {
+		t.Fatal("Expected install to fail")
+	}
+
+	client, _ := newTestManager(t, engine)
+	if _, err := client.GetInstalledPackage("demo"); err == nil {
+		t.Error("Expected failed install not to be recorded")
+	}
+}
+
+func TestUpgradeRollbackEndToEnd(t *testing.T) {
+	engine := useFakeEngine(t)
+	source := writeLocalPackage(t, "1.0.0", "nginx:1.26")
+
+	if err := runInstall(context.Background(), "demo", installOptions{localPath: source}); err != nil {
+		t.Fatalf("install failed: %v", err)
+	}
+
+	client, manager := newTestManager(t, engine)
+	installed, err := client.GetInstalledPackage("demo")
+	if err != nil {
+		t.Fatalf("GetInstalledPackage failed: %v", err)
+	}
+
+	latest := installed.Package
+	latest.Version = "2.0.0"
+	latest.Parameters = map[string]pkg.Param{"GREETING": {Type: "string", Default: "hello"}}
+
+	engine.FailNext(composetest.MethodUp, errors.New("image not found"))
+
+	err = performUpgrade(manager, "demo", &installed, latest, upgradeOptions{})
+	if err == nil {
+		t.Fatal("Expected upgrade to fail")
+	}
+
+	if engine.Count(composetest.MethodDown) != 1 || engine.Count(composetest.MethodUp) != 3 {
+		t.Errorf("Expected stop, failed deploy and rollback, got %+v", engine.Calls())
+	}
+	if !engine.Running("compak-demo") {
+		t.Error("Expected rolled back version to be running")
+	}
+
+	rolledBack, err := client.GetInstalledPackage("demo")
+	if err != nil {
+		t.Fatalf("Expected demo to be recorded after rollback: %v", err)
+	}
+	if rolledBack.Package.Version != "1.0.0" {
+		t.Errorf("Expected version 1.0.0 after rollback, got %s", rolledBack.Package.Version)
+	}
+}
+
+func TestUpgradeRollbackRestoresPinnedDigests(t *testing.T) {
+	engine := useFakeEngine(t)
+	installedDigest := "sha256:" + strings.Repeat("a", 64)
+	engine.SetDigest("nginx:1.26", installedDigest)
+	source := writeLocalPackage(t, "1.0.0", "nginx:1.26")
+
+	if err := runInstall(context.Background(), "demo", installOptions{localPath: source, pinImages: true}); err != nil {
+		t.Fatalf("install failed: %v", err)
+	}
+
+	client, manager := newTestManager(t, engine)
+	installed, err := client.GetInstalledPackage("demo")
+	if err != nil {
+		t.Fatalf("GetInstalledPackage failed: %v", err)
+	}
+
+	engine.SetDigest("nginx:1.26", "sha256:"+strings.Repeat("b", 64))
+	latest := installed.Package
+	latest.Version = "2.0.0"
+	engine.FailNext(composetest.MethodUp, errors.New("image not found"))
+
+	if err := performUpgrade(manager, "demo", &installed, latest, upgradeOptions{sourcePath: source}); err == nil {
+		t.Fatal("Expected upgrade to fail")
+	}
+
+	if image := engine.Project("compak-demo").Services["web"].Image; image != "nginx:1.26@"+installedDigest {
+		t.Errorf("Expected the rollback to run the installed digest, got %s", image)
+	}
+	rolledBack, err := client.GetInstalledPackage("demo")
+	if err != nil {
+		t.Fatalf("Expected demo to be recorded after rollback: %v", err)
+	}
+	if !reflect.DeepEqual(rolledBack.Images, installed.Images) || !rolledBack.ImagesPinned {
+		t.Errorf("Expected images %v to be restored, got %v", installed.Images, rolledBack.Images)
+	}
+}
+
+func TestUpgradeKeepsBindMountedData(t *testing.T) {
+	engine := useFakeEngine(t)
+	if err := runInstall(context.Background(), "demo", installOptions{localPath: writeDataPackage(t)}); err != nil {
+		t.Fatalf("install failed: %v", err)
+	}
+
+	client, manager := newTestManager(t, engine)
+	stateDir, err := config.GetStateDir()
+	if err != nil {
+		t.Fatalf("Failed to get state dir: %v", err)
+	}
+	photo := filepath.Join(stateDir, "packages", "demo", "library", "photo.jpg")
+	if err := os.MkdirAll(filepath.Dir(photo), 0o750); err != nil {
+		t.Fatalf("Failed to create library: %v", err)
+	}
+	if err := os.WriteFile(photo, []byte("pixels"), 0o600); err != nil {
+		t.Fatalf("Failed to write library data: %v", err)
+	}
+
+	for _, tt := range []struct {
+		name    string
+		version string
+		fail    bool
+	}{
+		{name: "rolled back upgrade", version: "2.0.0", fail: true},
+		{name: "successful upgrade", version: "2.0.0"},
+	} {
+		installed, err := client.GetInstalledPackage("demo")
+		if err != nil {
+			t.Fatalf("GetInstalledPackage failed: %v", err)
+		}
+		latest := installed.Package
+		latest.Version = tt.version
+
+		if tt.fail {
+			engine.FailNext(composetest.MethodUp, errors.New("image not found"))
+		}
+		err = performUpgrade(manager, "demo", &installed, latest, upgradeOptions{sourcePath: writeDataPackage(t)})
+		if (err != nil) != tt.fail {
+			t.Fatalf("%s: unexpected result: %v", tt.name, err)
+		}
+
+		if data, err := os.ReadFile(photo); err != nil || string(data) != "pixels" {
+			t.Errorf("%s: expected bind-mounted data to survive, got %q (%v)", tt.name, data, err)
+		}
+		if !engine.Running("compak-demo") {
+			t.Errorf("%s: expected demo to be running", tt.name)
+		}
+	}
+}
+
+func TestUpgradePolicyDenialKeepsOldVersion(t *testing.T) {
+	engine := useFakeEngine(t)
+	source := writeLocalPackage(t, "1.0.0", "nginx:1.26")
+
+	if err := runInstall(context.Background(), "demo", installOptions{localPath: source}); err != nil {
+		t.Fatalf("install failed: %v", err)
+	}
+
+	client, manager := newTestManager(t, engine)
+	installed, err := client.GetInstalledPackage("demo")
+	if err != nil {
+		t.Fatalf("GetInstalledPackage failed: %v", err)
+	}
+
+	privileged := writeLocalPackage(t, "2.0.0", "nginx:1.27")
+	composeYAML := "services:\n  web:\n    image: nginx:1.27\n    privileged: true\n"
+	if err := os.WriteFile(filepath.Join(privileged, "docker-compose.yaml"), []byte(composeYAML), 0o600); err != nil {
+		t.Fatalf("Failed to write compose file: %v", err)
+	}
+
+	latest := installed.Package
+	latest.Version = "2.0.0"
+
+	err = performUpgrade(manager, "demo", &installed, latest, upgradeOptions{sourcePath: privileged})
+	if err == nil || !strings.Contains(err.Error(), "blocked by security policy") {
+		t.Fatalf("Expected the policy to block the upgrade, got %v", err)
+	}
+	if engine.Count(composetest.MethodDown) != 0 || engine.Count(composetest.MethodUp) != 1 || !engine.Running("compak-demo") {
+		t.Errorf("Expected the old version to keep running untouched, got %+v", engine.Calls())
+	}
+}
+
+func TestUpgradeHealthRollbackEndToEnd(t *testing.T) {
+	engine := useFakeEngine(t)
+	source := writeLocalPackage(t, "1.0.0", "nginx:1.26")
+
+	if err := runInstall(context.Background(), "demo", installOptions{localPath: source, wait: true, waitTimeout: time.Second}); err != nil {
+		t.Fatalf("install failed: %v", err)
+	}
+
+	client, manager := newTestManager(t, engine)
+	installed, err := client.GetInstalledPackage("demo")
+	if err != nil {
+		t.Fatalf("GetInstalledPackage failed: %v", err)
+	}
+
+	latest := installed.Package
+	latest.Version = "2.0.0"
+	latest.Parameters = map[string]pkg.Param{"GREETING": {Type: "string", Default: "hello"}}
+
+	engine.HealthNext("unhealthy")
+	engine.HealthNext("healthy")
+
+	err = performUpgrade(manager, "demo", &installed, latest, upgradeOptions{wait: true, waitTimeout: 50 * time.Millisecond})
+	if err == nil || !strings.Contains(err.Error(), "rolled back to 1.0.0") || !strings.Contains(err.Error(), "(unhealthy)") {
+		t.Fatalf("Expected unhealthy upgrade to fail, got: %v", err)
+	}
+
+	rolledBack, err := client.GetInstalledPackage("demo")
+	if err != nil {
+		t.Fatalf("Expected demo to be recorded after rollback: %v", err)
+	}
+	if rolledBack.Package.Version != "1.0.0" || rolledBack.Status != "installed" {
+		t.Errorf("Expected healthy 1.0.0 after rollback, got %s (%s)", rolledBack.Package.Version, rolledBack.Status)
+	}
+}
+
+func TestInstallWaitMarksFailed(t *testing.T) {
+	engine := useFakeEngine(t)
+	engine.HealthNext("unhealthy")
+	source := writeLocalPackage(t, "1.0.0", "nginx:1.27")
+
+	err := runInstall(context.Background(), "demo", installOptions{localPath: source, wait: true, waitTimeout: 50 * time.Millisecond})
+	if err == nil {
+		t.Fatal("Expected install to fail")
+	}
+
+	client, _ := newTestManager(t, engine)
+	installed, err := client.GetInstalledPackage("demo")
+	if err != nil {
+		t.Fatalf("Expected unhealthy install to be recorded: %v", err)
+	}
+	if installed.Status != "failed" {
+		t.Errorf("Expected status failed, got %s", installed.Status)
+	}
+
+	if err := runInstall(context.Background(), "demo", installOptions{localPath: source}); err != nil {
+		t.Fatalf("Expected failed install to be retried: %v", err)
+	}
+	if installed, _ = client.GetInstalledPackage("demo"); installed.Status != "installed" {
+		t.Errorf("Expected status installed after retry, got %s", installed.Status)
+	}
+}
+
+func TestInstallLockedEndToEnd(t *testing.T) {
+	engine := useFakeEngine(t)
+	ctx := context.Background()
+	source := writeLocalPackage(t, "1.0.0", "nginx:1.27")
+	locked := "sha256:" + strings.Repeat("a", 64)
+	engine.SetDigest("nginx:1.27", locked)
+
+	if err := runInstall(ctx, "demo", installOptions{localPath: source}); err != nil {
+		t.Fatalf("install failed: %v", err)
+	}
+
+	stateDir, err := contextStateDir()
+	if err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(stateDir, pkg.LockFile))
+	if err != nil {
+		t.Fatalf("Expected a lockfile to be written: %v", err)
+	}
+	lockfile := filepath.Join(t.TempDir(), pkg.LockFile)
+	if err := os.WriteFile(lockfile, data, 0o600); err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+
+	if err := uninstallCmd.RunE(uninstallCmd, []string{"demo"}); err != nil {
+		t.Fatalf("uninstall failed: %v", err)
+	}
+	engine.SetDigest("nginx:1.27", "sha256:"+strings.Repeat("b", 64))
+
+	opts := installOptions{localPath: source, locked: true, lockfile: lockfile}
+	if err := runInstall(ctx, "demo", opts); err != nil {
+		t.Fatalf("locked install failed: %v", err)
+	}
+	if image := engine.Project("compak-demo").Services["web"].Image; image != "nginx:1.27@"+locked {
+		t.Errorf("Expected the locked digest to be deployed, got %s", image)
+	}
+
+	_, manager := newTestManager(t, engine)
+	if err := manager.Remove("demo", pkg.RemoveOptions{Purge: true}); err != nil {
+		t.Fatalf("uninstall failed: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		pkgName string
+		opts    installOptions
+		wantErr string
+	}{
+		{name: "not locked", pkgName: "other", opts: opts, wantErr: "other is not in"},
+		{name: "missing path", pkgName: "demo", opts: installOptions{locked: true, lockfile: lockfile}, wantErr: "pass it with --path"},
+		{name: "version", pkgName: "demo", opts: installOptions{localPath: source, locked: true, lockfile: lockfile, version: "1.0.0"}, wantErr: "--version cannot be used"},
+		{name: "version drift", pkgName: "demo", opts: installOptions{localPath: writeLocalPackage(t, "1.0.1", "nginx:1.27"), locked: true, lockfile: lockfile}, wantErr: "version is 1.0.1, locked 1.0.0"},
+		{name: "compose drift", pkgName: "demo", opts: installOptions{localPath: writeLocalPackage(t, "1.0.0", "nginx:1.28"), locked: true, lockfile: lockfile}, wantErr: "compose files drifted"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := runInstall(ctx, tt.pkgName, tt.opts)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+			if engine.Running("compak-" + tt.pkgName) {
+				t.Error("Expected nothing to be deployed")
+			}
+		})
+	}
+}
+
+func TestInstallPinImagesEndToEnd(t *testing.T) {
+	engine := useFakeEngine(t)
+	ctx := context.Background()
+	digest := "sha256:" + strings.Repeat("d", 64)
+	engine.SetDigest("nginx:1.27", digest)
+
+	if err := runInstall(ctx, "demo", installOptions{localPath: writeLocalPackage(t, "1.0.0", "nginx:1.27"), pinImages: true}); err != nil {
+		t.Fatalf("install failed: %v", err)
+	}
+	if image := engine.Project("compak-demo").Services["web"].Image; image != "nginx:1.27@"+digest {
+		t.Errorf("Expected web to be pinned, got %s", image)
+	}
+
+	client, manager := newTestManager(t, engine)
+	status, err := manager.Status(ctx, "demo")
+	if err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+	var out bytes.Buffer
+	if err := writeStatus(&out, status, time.Now()); err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+	if !strings.Contains(out.String(), "Image digests (pinned):") || !strings.Contains(out.String(), "web  "+digest) {
+		t.Errorf("Expected status to show the pinned digest\nGot:\n%s", out.String())
+	}
+
+	installed, err := client.GetInstalledPackage("demo")
+	if err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+	upgraded := digest[:len(digest)-1] + "e"
+	engine.SetDigest("nginx:1.28", upgraded)
+	source := writeLocalPackage(t, "2.0.0", "nginx:1.28")
+	latest, err := manager.LoadPackageFromDir(source)
+	if err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+	if err := performUpgrade(manager, "demo", &installed, *latest, upgradeOptions{sourcePath: source}); err != nil {
+		t.Fatalf("upgrade failed: %v", err)
+	}
+	if image := engine.Project("compak-demo").Services["web"].Image; image != "nginx:1.28@"+upgraded {
+		t.Errorf("Expected the upgrade to keep pinning, got %s", image)
+	}
+}
+
+func TestInstallDryRunCreatesNoState(t *testing.T) {
+	engine := useFakeEngine(t)
+	source := writeLocalPackage(t, "1.0.0", "nginx:1.27")
+
+	previous := contextFlag
+	contextFlag = "prod"
+	t.Cleanup(func() { contextFlag = previous })
+
+	if err := runInstall(context.Background(), "demo", installOptions{localPath: source, dryRun: true}); err != nil {
+		t.Fatalf("dry run failed: %v", err)
+	}
+
+	stateDir, err := config.GetStateDir()
+	if err != nil {
+		t.Fatalf("Failed to get state dir: %v", err)
+	}
+	if _, err := os.Stat(stateDir); !os.IsNotExist(err) {
+		t.Errorf("Expected a dry run not to create %s, got %v", stateDir, err)
+	}
+	if len(engine.Calls()) != 0 {
+		t.Errorf("Expected a dry run to leave the engine untouched, got %+v", engine.Calls())
+	}
+}
+
+func TestUpgradeRemovesPreviousFiles(t *testing.T) {
+	engine := useFakeEngine(t)
+	v1 := writeLocalPackage(t, "1.0.0", "nginx:1.26")
+	override := "services:\n  worker:\n    image: busybox\n"
+	if err := os.WriteFile(filepath.Join(v1, "compose.override.yaml"), []byte(override), 0o600); err != nil {
+		t.Fatalf("Failed to write override: %v", err)
+	}
+	if err := runInstall(context.Background(), "demo", installOptions{localPath: v1}); err != nil {
+		t.Fatalf("install failed: %v", err)
+	}
+
+	client, manager := newTestManager(t, engine)
+	installed, err := client.GetInstalledPackage("demo")
+	if err != nil {
+		t.Fatalf("GetInstalledPackage failed: %v", err)
+	}
+	latest := installed.Package
+	latest.Version = "2.0.0"
+	if err := performUpgrade(manager, "demo", &installed, latest, upgradeOptions{sourcePath: writeLocalPackage(t, "2.0.0", "nginx:1.27")}); err != nil {
+		t.Fatalf("upgrade failed: %v", err)
+	}
+
+	stateDir, err := config.GetStateDir()
+	if err != nil {
+		t.Fatalf("Failed to get state dir: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(stateDir, "packages", "demo", "compose.override.yaml")); !os.IsNotExist(err) {
+		t.Errorf("Expected the old override to be removed, got %v", err)
+	}
+	if services := engine.Project("compak-demo").ServiceNames(); !reflect.DeepEqual(services, []string{"web"}) {
+		t.Errorf("Expected only web after the upgrade, got %v", services)
+	}
+}
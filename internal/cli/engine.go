@@ -0,0 +1,58 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/LoriKarikari/compak/internal/config"
+	"github.com/LoriKarikari/compak/internal/core/compose"
+)
+
+var (
+	engineFlag  string
+	contextFlag string
+)
+
+var newEngine = func() (compose.Engine, error) {
+	name, err := engineName()
+	if err != nil {
+		return nil, err
+	}
+	return compose.NewEngine(name, contextFlag)
+}
+
+func engineName() (string, error) {
+	if engineFlag != "" {
+		return engineFlag, nil
+	}
+
+	stateDir, err := config.GetStateDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get state directory: %w", err)
+	}
+
+	cfg, err := config.Load(stateDir)
+	if err != nil {
+		return "", err
+	}
+	return cfg.Engine, nil
+}
+
+func remoteHost() bool {
+	return !config.IsLocalContext(config.ResolveContext(contextFlag))
+}
+
+func contextStateDir() (string, error) {
+	stateDir, err := config.GetStateDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get state directory: %w", err)
+	}
+	return config.ContextStateDir(stateDir, config.StateContext(contextFlag))
+}
+
+func lookupContextStateDir() (string, error) {
+	stateDir, err := config.GetStateDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get state directory: %w", err)
+	}
+	return config.LookupContextStateDir(stateDir, config.StateContext(contextFlag))
+}
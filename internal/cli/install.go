@@ -6,6 +6,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/samber/lo"
 	"github.com/spf13/cobra"
@@ -13,7 +14,6 @@ import (
 
 	"github.com/LoriKarikari/compak/internal/config"
 	"github.com/LoriKarikari/compak/internal/core/compose"
-	"github.com/LoriKarikari/compak/internal/core/index"
 	pkg "github.com/LoriKarikari/compak/internal/core/package"
 )
 
@@ -37,100 +37,301 @@ Parameters can be customized using the --set flag, which accepts key=value pairs
   # Install with custom parameters
   compak install nginx --set PORT=8080 --set SERVER_NAME=localhost
 
+  # Show the rendered compose config without deploying
+  compak install nginx --set PORT=8080 --dry-run
+
+  # Wait up to 10 minutes for all services to be running and healthy
+  compak install immich --wait --wait-timeout 10m
+
+  # Pin floating image tags to the digests pulled now
+  compak install immich --pin-images
+
+  # Reproduce an install from a lockfile copied from another host
+  compak install immich --locked --lockfile ./compak.lock
+
+  # Install with a local compose overlay
+  compak install nginx --overlay ./my-overrides.yaml
+
   # Install with multiple parameter overrides
   compak install immich \
     --set DB_PASSWORD=secure123 \
     --set UPLOAD_LOCATION=/mnt/photos`,
 	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		ctx := cmd.Context()
 		packageName := args[0]
 
 		if err := validatePackageName(packageName); err != nil {
 			return err
 		}
 
-		version, err := cmd.Flags().GetString("version")
+		opts, err := readInstallOptions(cmd)
 		if err != nil {
-			return fmt.Errorf("failed to get version flag: %w", err)
+			return err
 		}
 
-		localPath, err := cmd.Flags().GetString("path")
-		if err != nil {
-			return fmt.Errorf("failed to get path flag: %w", err)
-		}
+		return runInstall(commandContext(cmd), packageName, opts)
+	},
+}
 
-		if localPath != "" {
-			normalizedPath, err := validateLocalPath(localPath)
-			if err != nil {
-				return err
-			}
-			localPath = normalizedPath
-		}
+type installOptions struct {
+	version           string
+	localPath         string
+	setValues         []string
+	overlays          []pkg.Overlay
+	quietUnpinned     bool
+	requireSignatures bool
+	acceptRisk        bool
+	dryRun            bool
+	wait              bool
+	waitTimeout       time.Duration
+	locked            bool
+	lockfile          string
+	pinImages         bool
+}
 
-		setValues, err := cmd.Flags().GetStringSlice("set")
-		if err != nil {
-			return fmt.Errorf("failed to get set flag: %w", err)
+func readInstallOptions(cmd *cobra.Command) (installOptions, error) {
+	var opts installOptions
+	var err error
+
+	if opts.version, err = cmd.Flags().GetString("version"); err != nil {
+		return opts, fmt.Errorf("failed to get version flag: %w", err)
+	}
+
+	if opts.localPath, err = cmd.Flags().GetString("path"); err != nil {
+		return opts, fmt.Errorf("failed to get path flag: %w", err)
+	}
+
+	if opts.localPath != "" {
+		if opts.localPath, err = validateLocalPath(opts.localPath); err != nil {
+			return opts, err
 		}
+	}
 
-		composeClient, err := compose.NewClient()
-		if err != nil {
+	if opts.setValues, err = cmd.Flags().GetStringSlice("set"); err != nil {
+		return opts, fmt.Errorf("failed to get set flag: %w", err)
+	}
+
+	overlayPaths, err := cmd.Flags().GetStringSlice("overlay")
+	if err != nil {
+		return opts, fmt.Errorf("failed to get overlay flag: %w", err)
+	}
+
+	if opts.overlays, err = pkg.ReadOverlays(overlayPaths); err != nil {
+		return opts, err
+	}
+
+	if opts.quietUnpinned, err = cmd.Flags().GetBool("quiet-unpinned"); err != nil {
+		return opts, fmt.Errorf("failed to get quiet-unpinned flag: %w", err)
+	}
+
+	if opts.requireSignatures, err = cmd.Flags().GetBool("require-signatures"); err != nil {
+		return opts, fmt.Errorf("failed to get require-signatures flag: %w", err)
+	}
+
+	if opts.acceptRisk, err = cmd.Flags().GetBool("accept-risk"); err != nil {
+		return opts, fmt.Errorf("failed to get accept-risk flag: %w", err)
+	}
+
+	if opts.dryRun, err = cmd.Flags().GetBool("dry-run"); err != nil {
+		return opts, fmt.Errorf("failed to get dry-run flag: %w", err)
+	}
+
+	if opts.wait, opts.waitTimeout, err = readWaitFlags(cmd); err != nil {
+		return opts, err
+	}
+
+	if opts.locked, err = cmd.Flags().GetBool("locked"); err != nil {
+		return opts, fmt.Errorf("failed to get locked flag: %w", err)
+	}
+
+	if opts.lockfile, err = cmd.Flags().GetString("lockfile"); err != nil {
+		return opts, fmt.Errorf("failed to get lockfile flag: %w", err)
+	}
+
+	if opts.pinImages, err = cmd.Flags().GetBool("pin-images"); err != nil {
+		return opts, fmt.Errorf("failed to get pin-images flag: %w", err)
+	}
+
+	return opts, nil
+}
+
+func readWaitFlags(cmd *cobra.Command) (bool, time.Duration, error) {
+	wait, err := cmd.Flags().GetBool("wait")
+	if err != nil {
+		return false, 0, fmt.Errorf("failed to get wait flag: %w", err)
+	}
+
+	timeout, err := cmd.Flags().GetDuration("wait-timeout")
+	if err != nil {
+		return false, 0, fmt.Errorf("failed to get wait-timeout flag: %w", err)
+	}
+	if timeout <= 0 {
+		return false, 0, fmt.Errorf("--wait-timeout must be positive")
+	}
+
+	return wait || cmd.Flags().Changed("wait-timeout"), timeout, nil
+}
+
+func addWaitFlags(cmd *cobra.Command) {
+	cmd.Flags().Bool("wait", false, "wait until all services are running and healthy, marking the package failed otherwise")
+	cmd.Flags().Duration("wait-timeout", pkg.DefaultWaitTimeout, "how long --wait waits for services to become healthy")
+}
+
+func runInstall(ctx context.Context, packageName string, opts installOptions) error {
+	var engine compose.Engine
+	if !opts.dryRun {
+		var err error
+		if engine, err = newEngine(); err != nil {
 			return fmt.Errorf("failed to create compose client: %w", err)
 		}
+	}
 
-		stateDir, err := config.GetStateDir()
-		if err != nil {
-			return fmt.Errorf("failed to get state directory: %w", err)
-		}
+	stateDir, err := config.GetStateDir()
+	if err != nil {
+		return fmt.Errorf("failed to get state directory: %w", err)
+	}
 
-		client := pkg.NewClient(stateDir)
-		manager := pkg.NewManager(client, composeClient, stateDir)
+	cfg, err := config.Load(stateDir)
+	if err != nil {
+		return err
+	}
 
-		packageToInstall, sourcePath, err := loadPackage(ctx, packageName, version, localPath, manager)
-		if err != nil {
-			return err
-		}
+	resolveStateDir := contextStateDir
+	if opts.dryRun {
+		resolveStateDir = lookupContextStateDir
+	}
+	packageStateDir, err := resolveStateDir()
+	if err != nil {
+		return err
+	}
 
-		existingPkg, err := client.GetInstalledPackage(packageToInstall.Name)
-		if err == nil {
-			if existingPkg.Package.Version == packageToInstall.Version {
-				fmt.Printf("Package %s@%s is already installed\n",
-					packageToInstall.Name, packageToInstall.Version)
-				fmt.Println("Use 'compak upgrade' to update or 'compak uninstall' to reinstall")
-				return nil
-			}
+	client := pkg.NewClient(packageStateDir)
+	manager := pkg.NewManager(client, engine, packageStateDir)
 
+	if opts.lockfile == "" {
+		opts.lockfile = filepath.Join(packageStateDir, pkg.LockFile)
+	}
+
+	packageToInstall, sourcePath, lockEntry, err := resolveInstallPackage(ctx, packageName, opts, manager)
+	if err != nil {
+		return err
+	}
+
+	if existingPkg, err := client.GetInstalledPackage(packageToInstall.Name); err == nil {
+		switch {
+		case opts.dryRun:
+			fmt.Printf("Note: %s@%s is already installed; showing what a fresh install would deploy\n",
+				existingPkg.Package.Name, existingPkg.Package.Version)
+		case existingPkg.Status == "failed":
+			fmt.Printf("Retrying failed install of %s@%s\n", existingPkg.Package.Name, existingPkg.Package.Version)
+		case existingPkg.Package.Version == packageToInstall.Version:
+			fmt.Printf("Package %s@%s is already installed\n",
+				packageToInstall.Name, packageToInstall.Version)
+			fmt.Println("Use 'compak upgrade' to update or 'compak uninstall' to reinstall")
+			return nil
+		default:
 			return fmt.Errorf("package %s is already installed with version %s (requested: %s). Use 'compak upgrade' to update or 'compak uninstall' first",
 				packageToInstall.Name, existingPkg.Package.Version, packageToInstall.Version)
 		}
+	}
 
-		fmt.Printf("Installing package: %s@%s\n", packageToInstall.Name, packageToInstall.Version)
+	action := "Installing"
+	if opts.dryRun {
+		action = "Dry run for"
+	}
+	fmt.Printf("%s package: %s@%s\n", action, packageToInstall.Name, packageToInstall.Version)
 
-		displayPackageInfo(packageToInstall)
+	values, err := parseSetValues(opts.setValues)
+	if err != nil {
+		return err
+	}
 
-		values, err := parseSetValues(setValues)
-		if err != nil {
-			return err
-		}
+	displayPackageInfo(packageToInstall, values)
 
-		if err := validateParameters(packageToInstall, values); err != nil {
-			return fmt.Errorf("parameter validation failed: %w", err)
-		}
+	if err := validateParameters(packageToInstall, values); err != nil {
+		return fmt.Errorf("parameter validation failed: %w", err)
+	}
 
-		if sourcePath != "" {
-			return manager.DeployFromPath(*packageToInstall, values, sourcePath)
-		}
+	deployOpts := pkg.DeployOptions{
+		SourcePath:    sourcePath,
+		Overlays:      opts.overlays,
+		QuietUnpinned: opts.quietUnpinned,
+		Policy:        cfg.Policy,
+		AcceptRisk:    opts.acceptRisk,
+		Wait:          opts.wait,
+		WaitTimeout:   opts.waitTimeout,
+		Lock:          lockEntry,
+		PinImages:     opts.pinImages,
+		RemoteHost:    remoteHost(),
+	}
 
-		return manager.Deploy(*packageToInstall, values)
-	},
+	deployOpts.IndexCommit, deployOpts.Pak = installSource(packageName, sourcePath, lockEntry, opts.dryRun)
+
+	if opts.dryRun {
+		return manager.DryRun(*packageToInstall, values, deployOpts)
+	}
+
+	return manager.DeployWithOptions(*packageToInstall, values, deployOpts)
 }
 
-func loadPackage(ctx context.Context, packageName, version, localPath string, manager *pkg.Manager) (*pkg.Package, string, error) {
+func loadPackage(ctx context.Context, packageName, version, localPath string, requireSignatures bool, manager *pkg.Manager) (*pkg.Package, string, error) {
 	if localPath != "" {
 		return loadFromLocalPath(localPath, manager)
 	}
 
-	return loadFromIndex(ctx, packageName, version)
+	return loadFromIndex(ctx, packageName, version, requireSignatures)
+}
+
+func resolveInstallPackage(ctx context.Context, packageName string, opts installOptions, manager *pkg.Manager) (*pkg.Package, string, *pkg.LockEntry, error) {
+	if !opts.locked {
+		packageToInstall, sourcePath, err := loadPackage(ctx, packageName, opts.version, opts.localPath, opts.requireSignatures, manager)
+		return packageToInstall, sourcePath, nil, err
+	}
+
+	if opts.version != "" {
+		return nil, "", nil, fmt.Errorf("--version cannot be used with --locked; the version comes from the lockfile")
+	}
+
+	lock, err := pkg.LoadLock(opts.lockfile)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	entry, ok := lock.Packages[packageName]
+	if !ok {
+		return nil, "", nil, fmt.Errorf("%s is not in %s", packageName, opts.lockfile)
+	}
+
+	var packageToInstall *pkg.Package
+	var sourcePath string
+	switch {
+	case entry.IndexCommit != "":
+		packageToInstall, err = loadFromIndexRevision(ctx, entry, opts.requireSignatures)
+	case opts.localPath != "":
+		packageToInstall, sourcePath, err = loadFromLocalPath(opts.localPath, manager)
+	default:
+		err = fmt.Errorf("%s was installed from a local directory; pass it with --path", packageName)
+	}
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	packageToInstall.Name = packageName
+	if err := entry.Verify(*packageToInstall); err != nil {
+		return nil, "", nil, err
+	}
+	return packageToInstall, sourcePath, &entry, nil
+}
+
+func installSource(packageName, sourcePath string, lockEntry *pkg.LockEntry, dryRun bool) (indexCommit, pak string) {
+	switch {
+	case lockEntry != nil && lockEntry.Pak != packageName:
+		return lockEntry.IndexCommit, lockEntry.Pak
+	case lockEntry != nil:
+		return lockEntry.IndexCommit, ""
+	case sourcePath == "" && !dryRun:
+		return indexRevision(), ""
+	}
+	return "", ""
 }
 
 func validateLocalPath(localPath string) (string, error) {
@@ -157,16 +358,19 @@ func loadFromLocalPath(localPath string, manager *pkg.Manager) (*pkg.Package, st
 	return packageToInstall, localPath, nil
 }
 
-func loadFromIndex(ctx context.Context, packageName, version string) (*pkg.Package, string, error) {
+func loadFromIndex(ctx context.Context, packageName, version string, requireSignatures bool) (*pkg.Package, string, error) {
 	lookupName := packageName
 	if version != "" && !strings.Contains(packageName, "@") {
 		lookupName = fmt.Sprintf("%s@%s", packageName, version)
 	}
 
-	indexClient := index.NewClient()
+	indexClient, err := newIndexClient(requireSignatures)
+	if err != nil {
+		return nil, "", err
+	}
 	packageData, err := indexClient.LoadPackageFromIndex(ctx, lookupName)
 	if err != nil {
-		return nil, "", fmt.Errorf("package %q not found in index: %w", lookupName, err)
+		return nil, "", fmt.Errorf("failed to load %q from index: %w", lookupName, err)
 	}
 
 	var packageToInstall pkg.Package
@@ -174,22 +378,67 @@ func loadFromIndex(ctx context.Context, packageName, version string) (*pkg.Packa
 		return nil, "", fmt.Errorf("failed to parse package from index: %w", err)
 	}
 
-	fmt.Printf("Loaded %s from index (source: %s)\n", lookupName, packageToInstall.Source)
+	if err := pkg.ValidateParamDefinitions(packageToInstall.Parameters); err != nil {
+		return nil, "", fmt.Errorf("invalid package %q in index: %w", lookupName, err)
+	}
+
+	fmt.Printf("Loaded %s from index (source: %s)\n", lookupName, packageToInstall.Source.String())
 	return &packageToInstall, "", nil
 }
 
-func displayPackageInfo(pkg *pkg.Package) {
-	if len(pkg.Parameters) > 0 {
+func loadFromIndexRevision(ctx context.Context, entry pkg.LockEntry, requireSignatures bool) (*pkg.Package, error) {
+	indexClient, err := newIndexClient(requireSignatures)
+	if err != nil {
+		return nil, err
+	}
+	packageData, err := indexClient.LoadPackageAtRevision(ctx, entry.Pak, entry.Version, entry.IndexCommit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load %s@%s from index revision %s: %w", entry.Pak, entry.Version, entry.IndexCommit, err)
+	}
+
+	var packageToInstall pkg.Package
+	if err := yaml.Unmarshal(packageData, &packageToInstall); err != nil {
+		return nil, fmt.Errorf("failed to parse package from index: %w", err)
+	}
+
+	if err := pkg.ValidateParamDefinitions(packageToInstall.Parameters); err != nil {
+		return nil, fmt.Errorf("invalid package %q in index: %w", entry.Pak, err)
+	}
+
+	fmt.Printf("Loaded %s@%s from index revision %s\n", entry.Pak, entry.Version, entry.IndexCommit)
+	return &packageToInstall, nil
+}
+
+func indexRevision() string {
+	indexClient, err := newIndexClient(false)
+	if err != nil {
+		fmt.Printf("Warning: failed to read index revision: %v\n", err)
+		return ""
+	}
+	revision, err := indexClient.Revision()
+	if err != nil {
+		fmt.Printf("Warning: failed to read index revision: %v\n", err)
+		return ""
+	}
+	return revision
+}
+
+func displayPackageInfo(p *pkg.Package, values map[string]string) {
+	if len(p.Parameters) > 0 {
+		merged := pkg.MergeValues(*p, values)
 		fmt.Println("\nAvailable parameters:")
-		for name, param := range pkg.Parameters {
+		for name, param := range p.Parameters {
+			if visible, err := param.IsVisible(merged); err == nil && !visible {
+				continue
+			}
 			defaultValue := param.Default
-			if pkg.Values != nil {
-				if override, exists := pkg.Values[name]; exists {
+			if p.Values != nil {
+				if override, exists := p.Values[name]; exists {
 					defaultValue = override
 				}
 			}
 			required := ""
-			if param.Required {
+			if isRequired, err := param.IsRequired(merged); err == nil && isRequired {
 				required = " (required)"
 			}
 			fmt.Printf("  %s=%s%s - %s\n", name, defaultValue, required, param.Description)
@@ -198,22 +447,31 @@ func displayPackageInfo(pkg *pkg.Package) {
 	}
 }
 
-func validateParameters(pkg *pkg.Package, values map[string]string) error {
+func validateParameters(p *pkg.Package, values map[string]string) error {
 	var errors []string
 
-	for key := range values {
-		if _, exists := pkg.Parameters[key]; !exists {
+	for key, value := range values {
+		param, exists := p.Parameters[key]
+		if !exists {
 			errors = append(errors, fmt.Sprintf("unknown parameter: %s", key))
+			continue
+		}
+		if value != "" {
+			if err := pkg.ValidateParameterValue(key, value, param); err != nil {
+				errors = append(errors, err.Error())
+			}
 		}
 	}
 
-	for key, param := range pkg.Parameters {
-		if param.Required {
-			_, hasValue := values[key]
-			_, hasDefault := pkg.Values[key]
-			if !hasValue && !hasDefault && param.Default == "" {
-				errors = append(errors, fmt.Sprintf("required parameter missing: %s", key))
-			}
+	merged := pkg.MergeValues(*p, values)
+	for key, param := range p.Parameters {
+		required, err := param.IsRequired(merged)
+		if err != nil {
+			errors = append(errors, fmt.Sprintf("parameter %s: %v", key, err))
+			continue
+		}
+		if required && merged[key] == "" {
+			errors = append(errors, fmt.Sprintf("required parameter missing: %s", key))
 		}
 	}
 
@@ -246,5 +504,14 @@ func init() {
 	installCmd.Flags().String("version", "", "package version to install")
 	installCmd.Flags().String("path", "", "path to local package directory")
 	installCmd.Flags().StringSlice("set", []string{}, "set values (e.g. --set PORT=9090 --set SERVER_NAME=myserver)")
+	installCmd.Flags().StringSlice("overlay", []string{}, "compose file merged on top of the package compose file (repeatable)")
+	installCmd.Flags().Bool("quiet-unpinned", false, "do not warn when the compose source has no sourceDigest (a digest mismatch still fails)")
+	installCmd.Flags().Bool("require-signatures", false, "refuse index packages without a signature from a trusted key")
+	installCmd.Flags().Bool("accept-risk", false, "deploy even if the security policy denies the compose configuration")
+	installCmd.Flags().Bool("dry-run", false, "render and validate the package without pulling, starting or recording anything")
+	installCmd.Flags().Bool("pin-images", false, "pin every service image to the digest it resolved to after pulling")
+	installCmd.Flags().Bool("locked", false, "install exactly the version, compose files and image digests recorded in the lockfile")
+	installCmd.Flags().String("lockfile", "", "lockfile to use with --locked (default compak.lock in the state directory)")
+	addWaitFlags(installCmd)
 	rootCmd.AddCommand(installCmd)
 }
@@ -42,7 +42,7 @@ func TestInstallCmdArgs(t *testing.T) {
 }
 
 func TestInstallCmdFlags(t *testing.T) {
-	flags := []string{"version", "path", "set"}
+	flags := []string{"version", "path", "set", "overlay", "quiet-unpinned", "require-signatures", "accept-risk", "dry-run"}
 	for _, flag := range flags {
 		if installCmd.Flags().Lookup(flag) == nil {
 			t.Errorf("Expected --%s flag to be defined", flag)
@@ -97,7 +97,7 @@ func TestDisplayPackageInfo(t *testing.T) {
 			os.Stdout = nil
 			defer func() { os.Stdout = old }()
 
-			displayPackageInfo(tt.pkg)
+			displayPackageInfo(tt.pkg, nil)
 		})
 	}
 }
@@ -153,3 +153,45 @@ parameters:
 		t.Error("expected parameters to be loaded")
 	}
 }
+
+func TestValidateParametersConditional(t *testing.T) {
+	p := &pkg.Package{
+		Name:    "mail-app",
+		Version: "1.0.0",
+		Parameters: map[string]pkg.Param{
+			"ENABLE_MAIL": {Type: "boolean", Default: "false"},
+			"SMTP_HOST":   {Type: "hostname", RequiredIf: "ENABLE_MAIL == true", VisibleIf: "ENABLE_MAIL"},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		values  map[string]string
+		wantErr bool
+	}{
+		{
+			name:    "mail disabled",
+			values:  map[string]string{},
+			wantErr: false,
+		},
+		{
+			name:    "mail enabled without host",
+			values:  map[string]string{"ENABLE_MAIL": "true"},
+			wantErr: true,
+		},
+		{
+			name:    "mail enabled with host",
+			values:  map[string]string{"ENABLE_MAIL": "true", "SMTP_HOST": "smtp.example.com"},
+			wantErr: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateParameters(p, tt.values)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateParameters: wantErr=%v, got=%v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	pkg "github.com/LoriKarikari/compak/internal/core/package"
+)
+
+type lifecycleAction func(manager *pkg.Manager, ctx context.Context, packageName string, services []string) error
+
+var startCmd = newLifecycleCmd("start", "Start a stopped package",
+	"Start the containers of an installed package that was stopped with 'compak stop'.",
+	"Started", (*pkg.Manager).Start)
+
+var stopCmd = newLifecycleCmd("stop", "Stop a package without uninstalling it",
+	`Stop the containers of an installed package. Package files, volumes and state are
+kept so the package can be started again with 'compak start'. Stopping the whole
+package marks it as stopped in 'compak list'.`,
+	"Stopped", (*pkg.Manager).Stop)
+
+var restartCmd = newLifecycleCmd("restart", "Restart a package",
+	"Restart the containers of an installed package.",
+	"Restarted", (*pkg.Manager).Restart)
+
+func newLifecycleCmd(name, short, long, done string, action lifecycleAction) *cobra.Command {
+	return &cobra.Command{
+		Use:   name + " [package] [service...]",
+		Short: short,
+		Long:  long + "\n\nPass service names after the package to act on those services only.",
+		Example: fmt.Sprintf(`  compak %[1]s immich
+  compak %[1]s immich immich-server`, name),
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runLifecycle(commandContext(cmd), args[0], args[1:], done, action)
+		},
+	}
+}
+
+func runLifecycle(ctx context.Context, packageName string, services []string, done string, action lifecycleAction) error {
+	if err := validatePackageName(packageName); err != nil {
+		return err
+	}
+
+	stateDir, err := contextStateDir()
+	if err != nil {
+		return err
+	}
+
+	client := pkg.NewClient(stateDir)
+	if _, err := client.GetInstalledPackage(packageName); err != nil {
+		return fmt.Errorf("package '%s' is not installed", packageName)
+	}
+
+	engine, err := newEngine()
+	if err != nil {
+		return fmt.Errorf("failed to create compose client: %w", err)
+	}
+
+	manager := pkg.NewManager(client, engine, stateDir)
+	if err := action(manager, ctx, packageName, services); err != nil {
+		return err
+	}
+
+	fmt.Printf("%s %s\n", done, packageName)
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(startCmd, stopCmd, restartCmd)
+}
@@ -0,0 +1,65 @@
+package cli
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/LoriKarikari/compak/internal/core/compose/composetest"
+	pkg "github.com/LoriKarikari/compak/internal/core/package"
+)
+
+func TestLifecycleEndToEnd(t *testing.T) {
+	engine := useFakeEngine(t)
+	source := writeLocalPackage(t, "1.0.0", "nginx:1.27")
+
+	ctx := context.Background()
+	if err := runInstall(ctx, "demo", installOptions{localPath: source}); err != nil {
+		t.Fatalf("install failed: %v", err)
+	}
+	client, _ := newTestManager(t, engine)
+
+	steps := []struct {
+		name     string
+		services []string
+		action   lifecycleAction
+		state    string
+		status   string
+	}{
+		{name: "stop", action: (*pkg.Manager).Stop, state: "exited", status: "stopped"},
+		{name: "start service", services: []string{"web"}, action: (*pkg.Manager).Start, state: "running", status: "stopped"},
+		{name: "start", action: (*pkg.Manager).Start, state: "running", status: "installed"},
+		{name: "stop service", services: []string{"web"}, action: (*pkg.Manager).Stop, state: "exited", status: "installed"},
+		{name: "restart service", services: []string{"web"}, action: (*pkg.Manager).Restart, state: "running", status: "installed"},
+	}
+
+	for _, step := range steps {
+		if err := runLifecycle(ctx, "demo", step.services, "Done", step.action); err != nil {
+			t.Fatalf("%s failed: %v", step.name, err)
+		}
+		if state := engine.State("compak-demo", "web"); state != step.state {
+			t.Errorf("%s: expected container state %s, got %s", step.name, step.state, state)
+		}
+		installed, err := client.GetInstalledPackage("demo")
+		if err != nil {
+			t.Fatalf("%s: expected demo to stay installed: %v", step.name, err)
+		}
+		if installed.Status != step.status {
+			t.Errorf("%s: expected status %s, got %s", step.name, step.status, installed.Status)
+		}
+	}
+
+	if engine.Count(composetest.MethodDown) != 0 {
+		t.Errorf("Expected lifecycle commands to keep containers, got %+v", engine.Calls())
+	}
+
+	err := runLifecycle(ctx, "demo", []string{"db"}, "Done", (*pkg.Manager).Stop)
+	if err == nil || !strings.Contains(err.Error(), `no service "db"`) {
+		t.Errorf("Expected unknown service error, got: %v", err)
+	}
+
+	err = runLifecycle(ctx, "missing", nil, "Done", (*pkg.Manager).Start)
+	if err == nil || !strings.Contains(err.Error(), "is not installed") {
+		t.Errorf("Expected not installed error, got: %v", err)
+	}
+}
@@ -1,8 +1,12 @@
 package cli
 
 import (
+	"context"
 	"fmt"
+	"io"
+	"maps"
 	"os"
+	"slices"
 	"text/tabwriter"
 
 	"github.com/spf13/cobra"
@@ -14,31 +18,113 @@ import (
 var listCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List installed packages",
+	Example: `  # List packages installed in the current Docker context
+  compak list
+
+  # Keep refreshing the live state of every package
+  compak list --watch
+
+  # List packages across every context compak has deployed to
+  compak list --all-contexts`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		stateDir, err := config.GetStateDir()
+		allContexts, err := cmd.Flags().GetBool("all-contexts")
 		if err != nil {
-			return fmt.Errorf("failed to get state directory: %w", err)
+			return fmt.Errorf("failed to get all-contexts flag: %w", err)
 		}
 
-		client := pkg.NewClient(stateDir)
+		if allContexts {
+			return listAllContexts()
+		}
 
-		packages, err := client.List()
+		interval, err := readWatchFlags(cmd)
 		if err != nil {
-			return fmt.Errorf("failed to list packages: %w", err)
+			return err
 		}
 
-		if len(packages) == 0 {
-			fmt.Println("No packages installed")
-			return nil
+		return render(commandContext(cmd), interval, listPackages)
+	},
+}
+
+func listPackages(ctx context.Context, out io.Writer) error {
+	stateDir, err := contextStateDir()
+	if err != nil {
+		return err
+	}
+
+	client := pkg.NewClient(stateDir)
+	packages, err := client.List()
+	if err != nil {
+		return fmt.Errorf("failed to list packages: %w", err)
+	}
+
+	if len(packages) == 0 {
+		_, _ = fmt.Fprintln(out, "No packages installed")
+		return nil
+	}
+
+	var manager *pkg.Manager
+	if engine, err := newEngine(); err == nil {
+		manager = pkg.NewManager(client, engine, stateDir)
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	if _, err := fmt.Fprintln(w, "NAME\tVERSION\tSTATUS\tSTATE\tINSTALLED"); err != nil {
+		return fmt.Errorf("failed to write header: %w", err)
+	}
+
+	for _, pkg := range packages {
+		if _, err := fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+			pkg.Package.Name,
+			pkg.Package.Version,
+			pkg.Status,
+			liveState(ctx, manager, pkg.Package.Name),
+			pkg.InstallTime.Format("2006-01-02 15:04:05"),
+		); err != nil {
+			return fmt.Errorf("failed to write package info: %w", err)
 		}
+	}
 
-		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-		if _, err := fmt.Fprintln(w, "NAME\tVERSION\tSTATUS\tINSTALLED"); err != nil {
-			return fmt.Errorf("failed to write header: %w", err)
+	return w.Flush()
+}
+
+func liveState(ctx context.Context, manager *pkg.Manager, packageName string) string {
+	if manager == nil {
+		return "unknown"
+	}
+	status, err := manager.Status(ctx, packageName)
+	if err != nil {
+		return "unknown"
+	}
+	return status.State
+}
+
+func listAllContexts() error {
+	stateDir, err := config.GetStateDir()
+	if err != nil {
+		return fmt.Errorf("failed to get state directory: %w", err)
+	}
+
+	dirs, err := config.ContextStateDirs(stateDir)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	if _, err := fmt.Fprintln(w, "CONTEXT\tNAME\tVERSION\tSTATUS\tINSTALLED"); err != nil {
+		return fmt.Errorf("failed to write header: %w", err)
+	}
+
+	found := false
+	for _, context := range slices.Sorted(maps.Keys(dirs)) {
+		packages, err := pkg.NewClient(dirs[context]).List()
+		if err != nil {
+			return fmt.Errorf("failed to list packages for context %s: %w", context, err)
 		}
 
 		for _, pkg := range packages {
-			if _, err := fmt.Fprintf(w, "%s\t%s\t%s\t%s\n",
+			found = true
+			if _, err := fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+				context,
 				pkg.Package.Name,
 				pkg.Package.Version,
 				pkg.Status,
@@ -47,11 +133,18 @@ var listCmd = &cobra.Command{
 				return fmt.Errorf("failed to write package info: %w", err)
 			}
 		}
+	}
 
-		return w.Flush()
-	},
+	if !found {
+		fmt.Println("No packages installed")
+		return nil
+	}
+
+	return w.Flush()
 }
 
 func init() {
+	listCmd.Flags().Bool("all-contexts", false, "list packages installed in every Docker context")
+	addWatchFlags(listCmd)
 	rootCmd.AddCommand(listCmd)
 }
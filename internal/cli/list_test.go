@@ -190,3 +190,70 @@ func TestListCmdArgs(t *testing.T) {
 		})
 	}
 }
+
+func TestListCmdAllContexts(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Setenv("HOME", tempDir)
+	t.Setenv("DOCKER_CONFIG", tempDir)
+	t.Setenv("DOCKER_HOST", "")
+	t.Setenv("DOCKER_CONTEXT", "")
+
+	stateDir, err := config.GetStateDir()
+	if err != nil {
+		t.Fatalf("failed to get state dir: %v", err)
+	}
+
+	installs := map[string]pkg.Package{
+		config.DefaultContext:     {Name: "test-nginx", Version: "1.0.0"},
+		"host-ssh://admin@prod-1": {Name: "test-postgres", Version: "14.0.0"},
+	}
+	for context, p := range installs {
+		dir, err := config.ContextStateDir(stateDir, context)
+		if err != nil {
+			t.Fatalf("failed to get state dir for %s: %v", context, err)
+		}
+		if err := pkg.NewClient(dir).Install(p, nil); err != nil {
+			t.Fatalf("failed to install %s in %s: %v", p.Name, context, err)
+		}
+	}
+
+	run := func(args ...string) string {
+		old := os.Stdout
+		r, w, pipeErr := os.Pipe()
+		if pipeErr != nil {
+			t.Fatalf("failed to create pipe: %v", pipeErr)
+		}
+		os.Stdout = w
+
+		cmd := &cobra.Command{Use: "compak"}
+		cmd.AddCommand(listCmd)
+		cmd.SetArgs(append([]string{"list"}, args...))
+		err := cmd.Execute()
+
+		if closeErr := w.Close(); closeErr != nil {
+			t.Errorf("failed to close pipe: %v", closeErr)
+		}
+		os.Stdout = old
+
+		var buf bytes.Buffer
+		if _, readErr := buf.ReadFrom(r); readErr != nil {
+			t.Fatalf("failed to read from pipe: %v", readErr)
+		}
+		if err != nil {
+			t.Fatalf("list command failed: %v", err)
+		}
+		return buf.String()
+	}
+
+	output := run("--all-contexts")
+	for _, expected := range []string{"CONTEXT", "default", "test-nginx", "host-ssh://admin@prod-1", "test-postgres"} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("output doesn't contain %q\nGot:\n%s", expected, output)
+		}
+	}
+
+	output = run("--all-contexts=false")
+	if !strings.Contains(output, "test-nginx") || strings.Contains(output, "test-postgres") {
+		t.Errorf("expected only the default context's packages\nGot:\n%s", output)
+	}
+}
@@ -0,0 +1,203 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/docker/compose/v2/pkg/api"
+	"github.com/spf13/cobra"
+
+	pkg "github.com/LoriKarikari/compak/internal/core/package"
+)
+
+var serviceColors = []int{36, 33, 32, 35, 34, 31, 96, 93, 92, 95, 94, 91}
+
+var logsCmd = &cobra.Command{
+	Use:   "logs [package] [service...]",
+	Short: "Show container logs for a package",
+	Long: `Show the logs of an installed package's containers.
+
+Each line is prefixed with the service it came from. Pass service names after
+the package to limit the output to those services. Use --json to emit one JSON
+object per line for shipping logs to other tools.`,
+	Example: `  # Show all logs for nginx
+  compak logs nginx
+
+  # Follow the last 100 lines of the web service
+  compak logs immich immich-server --follow --tail 100
+
+  # Logs from the last 10 minutes as JSON lines
+  compak logs immich --since 10m --json`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		opts, err := readLogsOptions(cmd)
+		if err != nil {
+			return err
+		}
+		opts.Services = args[1:]
+
+		return runLogs(commandContext(cmd), args[0], opts)
+	},
+}
+
+type logsOptions struct {
+	api.LogOptions
+	json    bool
+	noColor bool
+}
+
+func readLogsOptions(cmd *cobra.Command) (logsOptions, error) {
+	var opts logsOptions
+	var err error
+
+	if opts.Follow, err = cmd.Flags().GetBool("follow"); err != nil {
+		return opts, fmt.Errorf("failed to get follow flag: %w", err)
+	}
+	if opts.Tail, err = cmd.Flags().GetString("tail"); err != nil {
+		return opts, fmt.Errorf("failed to get tail flag: %w", err)
+	}
+	if opts.Since, err = cmd.Flags().GetString("since"); err != nil {
+		return opts, fmt.Errorf("failed to get since flag: %w", err)
+	}
+	if opts.Timestamps, err = cmd.Flags().GetBool("timestamps"); err != nil {
+		return opts, fmt.Errorf("failed to get timestamps flag: %w", err)
+	}
+	if opts.json, err = cmd.Flags().GetBool("json"); err != nil {
+		return opts, fmt.Errorf("failed to get json flag: %w", err)
+	}
+	if opts.noColor, err = cmd.Flags().GetBool("no-color"); err != nil {
+		return opts, fmt.Errorf("failed to get no-color flag: %w", err)
+	}
+
+	if opts.Tail != "all" {
+		if lines, err := strconv.Atoi(opts.Tail); err != nil || lines < 0 {
+			return opts, fmt.Errorf("invalid --tail %q: must be a non-negative number or \"all\"", opts.Tail)
+		}
+	}
+
+	return opts, nil
+}
+
+func runLogs(ctx context.Context, packageName string, opts logsOptions) error {
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+	defer stop()
+
+	stateDir, err := contextStateDir()
+	if err != nil {
+		return err
+	}
+
+	client := pkg.NewClient(stateDir)
+	if _, err := client.GetInstalledPackage(packageName); err != nil {
+		return fmt.Errorf("package '%s' is not installed", packageName)
+	}
+
+	engine, err := newEngine()
+	if err != nil {
+		return fmt.Errorf("failed to create compose client: %w", err)
+	}
+
+	consumer := newLogConsumer(os.Stdout, os.Stderr, opts.json, opts.Timestamps, useColor(opts))
+	manager := pkg.NewManager(client, engine, stateDir)
+
+	err = manager.Logs(ctx, packageName, consumer, opts.LogOptions)
+	if errors.Is(err, context.Canceled) {
+		return nil
+	}
+	return err
+}
+
+func useColor(opts logsOptions) bool {
+	if opts.json || opts.noColor || os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	info, err := os.Stdout.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+type logConsumer struct {
+	mu         sync.Mutex
+	out        io.Writer
+	errOut     io.Writer
+	json       bool
+	timestamps bool
+	color      bool
+	width      int
+}
+
+type logEntry struct {
+	Service   string `json:"service"`
+	Stream    string `json:"stream"`
+	Timestamp string `json:"timestamp,omitempty"`
+	Message   string `json:"message"`
+}
+
+func newLogConsumer(out, errOut io.Writer, jsonLines, timestamps, color bool) *logConsumer {
+	return &logConsumer{out: out, errOut: errOut, json: jsonLines, timestamps: timestamps, color: color}
+}
+
+func (c *logConsumer) Log(service, message string) {
+	c.write(c.out, service, "stdout", message)
+}
+
+func (c *logConsumer) Err(service, message string) {
+	c.write(c.errOut, service, "stderr", message)
+}
+
+func (c *logConsumer) Status(service, message string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, _ = fmt.Fprintf(c.errOut, "%s: %s\n", service, message)
+}
+
+func (c *logConsumer) write(w io.Writer, service, stream, message string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.json {
+		entry := logEntry{Service: service, Stream: stream, Message: message}
+		if c.timestamps {
+			if timestamp, rest, found := strings.Cut(message, " "); found {
+				entry.Timestamp, entry.Message = timestamp, rest
+			}
+		}
+		data, err := json.Marshal(entry)
+		if err != nil {
+			return
+		}
+		_, _ = fmt.Fprintf(c.out, "%s\n", data)
+		return
+	}
+
+	c.width = max(c.width, len(service))
+	prefix := fmt.Sprintf("%-*s |", c.width, service)
+	if c.color {
+		prefix = fmt.Sprintf("\033[%dm%s\033[0m", serviceColor(service), prefix)
+	}
+	_, _ = fmt.Fprintf(w, "%s %s\n", prefix, message)
+}
+
+func serviceColor(service string) int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(service))
+	return serviceColors[h.Sum32()%uint32(len(serviceColors))]
+}
+
+func init() {
+	logsCmd.Flags().BoolP("follow", "f", false, "follow log output")
+	logsCmd.Flags().String("tail", "all", "number of lines to show from the end of the logs")
+	logsCmd.Flags().String("since", "", "show logs since a timestamp (e.g. 2024-01-02T13:23:37Z) or relative duration (e.g. 10m)")
+	logsCmd.Flags().BoolP("timestamps", "t", false, "show timestamps")
+	logsCmd.Flags().Bool("json", false, "output one JSON object per line")
+	logsCmd.Flags().Bool("no-color", false, "disable colored service prefixes")
+	rootCmd.AddCommand(logsCmd)
+}
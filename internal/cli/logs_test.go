@@ -0,0 +1,112 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/docker/compose/v2/pkg/api"
+
+	"github.com/LoriKarikari/compak/internal/core/compose/composetest"
+)
+
+func TestLogConsumer(t *testing.T) {
+	tests := []struct {
+		name       string
+		json       bool
+		timestamps bool
+		color      bool
+		expected   string
+	}{
+		{
+			name:     "text",
+			expected: "web | hello\nworker | a | b\n",
+		},
+		{
+			name:     "color",
+			color:    true,
+			expected: "\033[" + strconv.Itoa(serviceColor("web")) + "mweb |\033[0m hello\n\033[" + strconv.Itoa(serviceColor("worker")) + "mworker |\033[0m a | b\n",
+		},
+		{
+			name:     "json",
+			json:     true,
+			expected: `{"service":"web","stream":"stdout","message":"hello"}` + "\n" + `{"service":"worker","stream":"stdout","message":"a | b"}` + "\n",
+		},
+		{
+			name:       "json timestamps",
+			json:       true,
+			timestamps: true,
+			expected:   `{"service":"web","stream":"stdout","timestamp":"2024-01-02T13:23:37Z","message":"hello"}` + "\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out, errOut bytes.Buffer
+			consumer := newLogConsumer(&out, &errOut, tt.json, tt.timestamps, tt.color)
+
+			if tt.timestamps {
+				consumer.Log("web", "2024-01-02T13:23:37Z hello")
+			} else {
+				consumer.Log("web", "hello")
+				consumer.Log("worker", "a | b")
+			}
+
+			if out.String() != tt.expected {
+				t.Errorf("Expected output %q, got %q", tt.expected, out.String())
+			}
+		})
+	}
+}
+
+func TestLogsEndToEnd(t *testing.T) {
+	engine := useFakeEngine(t)
+	source := writeLocalPackage(t, "1.0.0", "nginx:1.27")
+
+	if err := runInstall(context.Background(), "demo", installOptions{localPath: source}); err != nil {
+		t.Fatalf("install failed: %v", err)
+	}
+	engine.AddLogs("compak-demo", "web", "starting", "ready")
+
+	old := os.Stdout
+	r, w, pipeErr := os.Pipe()
+	if pipeErr != nil {
+		t.Fatalf("failed to create pipe: %v", pipeErr)
+	}
+	os.Stdout = w
+
+	err := runLogs(context.Background(), "demo", logsOptions{
+		LogOptions: api.LogOptions{Services: []string{"web"}, Tail: "1"},
+		json:       true,
+	})
+
+	if closeErr := w.Close(); closeErr != nil {
+		t.Errorf("failed to close pipe: %v", closeErr)
+	}
+	os.Stdout = old
+
+	var buf bytes.Buffer
+	if _, readErr := buf.ReadFrom(r); readErr != nil {
+		t.Fatalf("failed to read from pipe: %v", readErr)
+	}
+	if err != nil {
+		t.Fatalf("logs failed: %v", err)
+	}
+
+	expected := `{"service":"web","stream":"stdout","message":"ready"}`
+	if strings.TrimSpace(buf.String()) != expected {
+		t.Errorf("Expected %s, got %q", expected, buf.String())
+	}
+
+	err = runLogs(context.Background(), "demo", logsOptions{LogOptions: api.LogOptions{Services: []string{"db"}}})
+	if err == nil || !strings.Contains(err.Error(), `no service "db"`) {
+		t.Errorf("Expected unknown service error, got: %v", err)
+	}
+
+	if engine.Count(composetest.MethodLogs) != 1 {
+		t.Errorf("Expected one logs call, got %+v", engine.Calls())
+	}
+}
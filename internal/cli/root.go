@@ -1,7 +1,12 @@
 package cli
 
 import (
+	"context"
+	"strings"
+
 	"github.com/spf13/cobra"
+
+	"github.com/LoriKarikari/compak/internal/core/compose"
 )
 
 var rootCmd = &cobra.Command{
@@ -10,13 +15,28 @@ var rootCmd = &cobra.Command{
 	Long: `Compak is a CLI tool for managing Docker Compose applications as packages.
 
 Compak allows you to install, manage, and deploy multi-container applications
-using a simple package format. It supports both Docker Compose and Podman Compose,
-automatically detecting the best available compose command.`,
+using a simple package format. It supports Docker Compose, Podman Compose and
+the standalone docker-compose, automatically detecting the best available engine.
+Use --engine or the engine setting in config.yaml to choose one explicitly.`,
 	CompletionOptions: cobra.CompletionOptions{
 		DisableDefaultCmd: false,
 	},
 }
 
+func init() {
+	rootCmd.PersistentFlags().StringVar(&engineFlag, "engine", "",
+		"container engine to use ("+strings.Join(compose.Engines(), ", ")+")")
+	rootCmd.PersistentFlags().StringVar(&contextFlag, "context", "",
+		"Docker context (or Podman connection) to deploy to; installed packages are tracked per context")
+}
+
+func commandContext(cmd *cobra.Command) context.Context {
+	if ctx := cmd.Context(); ctx != nil {
+		return ctx
+	}
+	return context.Background()
+}
+
 func Execute() error {
 	return rootCmd.Execute()
 }
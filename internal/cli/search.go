@@ -47,7 +47,10 @@ func searchPackages(query string, limit int) error {
 		fmt.Printf("Listing available paks...\n\n")
 	}
 
-	client := index.NewClient()
+	client, err := newIndexClient(false)
+	if err != nil {
+		return err
+	}
 	ctx := context.Background()
 
 	results, err := client.Search(ctx, query, limit)
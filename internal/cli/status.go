@@ -1,12 +1,15 @@
 package cli
 
 import (
+	"context"
 	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
 
 	"github.com/spf13/cobra"
 
-	"github.com/LoriKarikari/compak/internal/config"
-	"github.com/LoriKarikari/compak/internal/core/compose"
 	pkg "github.com/LoriKarikari/compak/internal/core/package"
 )
 
@@ -15,46 +18,124 @@ var statusCmd = &cobra.Command{
 	Short: "Show package status",
 	Long: `Show the status of an installed package.
 
-This command displays the current status of containers for the specified package
-using the underlying compose command (docker compose ps or similar).`,
+The package state is computed from its containers:
+  running   every service is running (and healthy if it has a healthcheck)
+  degraded  some services are running, others are stopped, missing or unhealthy
+  stopped   no container is running
+  missing   no containers exist for the package
+
+Each container is listed with its image, state, health, published ports,
+uptime and restart count, followed by the image digests recorded at install
+time (marked pinned if the package was installed with --pin-images).`,
 	Example: `  # Show status of nginx package
   compak status nginx
 
-  # Show status with verbose output
-  compak status nginx --verbose`,
+  # Refresh the status every 5 seconds
+  compak status nginx --watch --interval 5s`,
 	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		packageName := args[0]
 
-		composeClient, err := compose.NewClient()
+		interval, err := readWatchFlags(cmd)
 		if err != nil {
-			return fmt.Errorf("failed to create compose client: %w", err)
+			return err
 		}
 
-		stateDir, err := config.GetStateDir()
+		stateDir, err := contextStateDir()
 		if err != nil {
-			return fmt.Errorf("failed to get state directory: %w", err)
+			return err
 		}
 
 		client := pkg.NewClient(stateDir)
-		manager := pkg.NewManager(client, composeClient, stateDir)
-
 		if _, err := client.GetInstalledPackage(packageName); err != nil {
 			return fmt.Errorf("package '%s' is not installed", packageName)
 		}
 
-		status, err := manager.Status(packageName)
+		engine, err := newEngine()
 		if err != nil {
-			return fmt.Errorf("failed to get status: %w", err)
+			return fmt.Errorf("failed to create compose client: %w", err)
+		}
+
+		manager := pkg.NewManager(client, engine, stateDir)
+		return render(commandContext(cmd), interval, func(ctx context.Context, w io.Writer) error {
+			status, err := manager.Status(ctx, packageName)
+			if err != nil {
+				return fmt.Errorf("failed to get status: %w", err)
+			}
+			return writeStatus(w, status, time.Now())
+		})
+	},
+}
+
+func writeStatus(out io.Writer, status pkg.PackageStatus, now time.Time) error {
+	if _, err := fmt.Fprintf(out, "Package: %s@%s\nState:   %s\n\n", status.Package, status.Version, status.State); err != nil {
+		return fmt.Errorf("failed to write status: %w", err)
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	if _, err := fmt.Fprintln(w, "SERVICE\tCONTAINER\tIMAGE\tSTATE\tHEALTH\tPORTS\tUPTIME\tRESTARTS"); err != nil {
+		return fmt.Errorf("failed to write header: %w", err)
+	}
+
+	for _, service := range status.Services {
+		if _, err := fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%d\n",
+			service.Service,
+			orDash(service.Container),
+			orDash(service.Image),
+			containerState(service),
+			orDash(service.Health),
+			orDash(strings.Join(service.Ports, ", ")),
+			formatUptime(service.Uptime(now)),
+			service.Restarts,
+		); err != nil {
+			return fmt.Errorf("failed to write service status: %w", err)
 		}
+	}
 
-		fmt.Printf("Package: %s\n", packageName)
-		fmt.Printf("Status:\n%s", status)
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	return writeDigests(out, status)
+}
 
+func writeDigests(out io.Writer, status pkg.PackageStatus) error {
+	var b strings.Builder
+	for _, service := range status.Services {
+		if service.Digest != "" {
+			fmt.Fprintf(&b, "  %s\t%s\n", service.Service, service.Digest)
+		}
+	}
+	if b.Len() == 0 {
 		return nil
-	},
+	}
+
+	heading := "Image digests"
+	if status.ImagesPinned {
+		heading += " (pinned)"
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	if _, err := fmt.Fprintf(w, "\n%s:\n%s", heading, b.String()); err != nil {
+		return fmt.Errorf("failed to write image digests: %w", err)
+	}
+	return w.Flush()
+}
+
+func containerState(service pkg.ServiceStatus) string {
+	if service.State == "exited" {
+		return fmt.Sprintf("exited (%d)", service.ExitCode)
+	}
+	return service.State
+}
+
+func orDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
 }
 
 func init() {
+	addWatchFlags(statusCmd)
 	rootCmd.AddCommand(statusCmd)
 }
@@ -2,9 +2,11 @@ package cli
 
 import (
 	"bytes"
+	"context"
 	"os"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/spf13/cobra"
 
@@ -178,3 +180,64 @@ func TestStatusCmdFlags(t *testing.T) {
 		t.Error("expected error for two arguments")
 	}
 }
+
+func TestStatusAndListLiveState(t *testing.T) {
+	engine := useFakeEngine(t)
+	source := writeLocalPackage(t, "1.0.0", "nginx:1.27")
+
+	ctx := context.Background()
+	if err := runInstall(ctx, "demo", installOptions{localPath: source}); err != nil {
+		t.Fatalf("install failed: %v", err)
+	}
+	if err := engine.SetRestartCount("compak-demo", "web", 2); err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+
+	_, manager := newTestManager(t, engine)
+	status, err := manager.Status(ctx, "demo")
+	if err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+
+	var out bytes.Buffer
+	if err := writeStatus(&out, status, status.Services[0].StartedAt.Add(90*time.Minute)); err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+	for _, expected := range []string{"Package: demo@1.0.0", "State:   running", "compak-demo-web-1", "nginx:1.27", "1h30m", "2\n"} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("Expected status output to contain %q\nGot:\n%s", expected, out.String())
+		}
+	}
+
+	if err := engine.SetState("compak-demo", "web", "exited"); err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+	out.Reset()
+	if err := listPackages(ctx, &out); err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+	if !strings.Contains(out.String(), "STATE") || !strings.Contains(out.String(), "installed  stopped") {
+		t.Errorf("Expected list to show the live stopped state\nGot:\n%s", out.String())
+	}
+}
+
+func TestFormatUptime(t *testing.T) {
+	tests := []struct {
+		duration time.Duration
+		expected string
+	}{
+		{duration: 0, expected: "-"},
+		{duration: 42 * time.Second, expected: "42s"},
+		{duration: 5*time.Minute + 10*time.Second, expected: "5m"},
+		{duration: 3*time.Hour + 12*time.Minute, expected: "3h12m"},
+		{duration: 50 * time.Hour, expected: "2d2h"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expected, func(t *testing.T) {
+			if got := formatUptime(tt.duration); got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
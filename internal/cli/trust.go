@@ -0,0 +1,156 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/LoriKarikari/compak/internal/config"
+	"github.com/LoriKarikari/compak/internal/core/index"
+	"github.com/LoriKarikari/compak/internal/core/trust"
+)
+
+var trustCmd = &cobra.Command{
+	Use:   "trust",
+	Short: "Manage public keys trusted to sign index packages",
+	Long: `Manage the SSH public keys used to verify package signatures from the index.
+
+Packages in the index can ship a detached signature next to their definition
+(paks/<name>.yaml.sig) created with 'ssh-keygen -Y sign -n compak'. When a
+signature is present it must verify against one of the trusted keys. Use
+--require-signatures on install, upgrade and apply, requireSignatures in the
+policy section of config.yaml, or COMPAK_REQUIRE_SIGNATURES=1 to refuse
+unsigned packages.`,
+}
+
+var trustAddCmd = &cobra.Command{
+	Use:   "add [public-key-file]",
+	Short: "Add a public key to the trust store",
+	Example: `  compak trust add ./compak-index.pub
+  compak trust add ~/.ssh/id_ed25519.pub --name my-index`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name, err := cmd.Flags().GetString("name")
+		if err != nil {
+			return fmt.Errorf("failed to get name flag: %w", err)
+		}
+		if name == "" {
+			name = strings.TrimSuffix(filepath.Base(args[0]), ".pub")
+		}
+
+		data, err := os.ReadFile(args[0])
+		if err != nil {
+			return fmt.Errorf("failed to read public key: %w", err)
+		}
+
+		store, err := trustStore()
+		if err != nil {
+			return err
+		}
+
+		keys, err := store.Add(name, data)
+		if err != nil {
+			return err
+		}
+
+		for _, key := range keys {
+			fmt.Printf("Trusted key %s\n", key)
+		}
+		return nil
+	},
+}
+
+var trustListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List trusted public keys",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		store, err := trustStore()
+		if err != nil {
+			return err
+		}
+
+		keys, err := store.Keys()
+		if err != nil {
+			return err
+		}
+
+		if len(keys) == 0 {
+			fmt.Println("No trusted keys")
+			return nil
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		if _, err := fmt.Fprintln(w, "NAME\tTYPE\tFINGERPRINT\tCOMMENT"); err != nil {
+			return fmt.Errorf("failed to write header: %w", err)
+		}
+
+		for _, key := range keys {
+			if _, err := fmt.Fprintf(w, "%s\t%s\t%s\t%s\n",
+				key.Name,
+				key.PublicKey.Type(),
+				key.Fingerprint,
+				key.Comment,
+			); err != nil {
+				return fmt.Errorf("failed to write key info: %w", err)
+			}
+		}
+
+		return w.Flush()
+	},
+}
+
+var trustRemoveCmd = &cobra.Command{
+	Use:   "remove [name]",
+	Short: "Remove a public key from the trust store",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		store, err := trustStore()
+		if err != nil {
+			return err
+		}
+
+		if err := store.Remove(args[0]); err != nil {
+			return err
+		}
+
+		fmt.Printf("Removed key %s\n", args[0])
+		return nil
+	},
+}
+
+func trustStore() (*trust.Store, error) {
+	stateDir, err := config.GetStateDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get state directory: %w", err)
+	}
+	return trust.NewStore(filepath.Join(stateDir, "trust")), nil
+}
+
+func newIndexClient(requireSignatures bool) (*index.Client, error) {
+	store, err := trustStore()
+	if err != nil {
+		return nil, err
+	}
+	stateDir, err := config.GetStateDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get state directory: %w", err)
+	}
+	cfg, err := config.Load(stateDir)
+	if err != nil {
+		return nil, err
+	}
+
+	client := index.NewClient(store)
+	client.RequireSignatures(requireSignatures || cfg.Policy.RequireSignatures)
+	return client, nil
+}
+
+func init() {
+	trustAddCmd.Flags().String("name", "", "name to store the key under (defaults to the file name)")
+	trustCmd.AddCommand(trustAddCmd, trustListCmd, trustRemoveCmd)
+	rootCmd.AddCommand(trustCmd)
+}
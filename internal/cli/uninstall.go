@@ -1,39 +1,106 @@
 package cli
 
 import (
+	"bufio"
 	"fmt"
+	"io"
+	"os"
+	"strings"
 
 	"github.com/spf13/cobra"
 
-	"github.com/LoriKarikari/compak/internal/config"
-	"github.com/LoriKarikari/compak/internal/core/compose"
 	pkg "github.com/LoriKarikari/compak/internal/core/package"
 )
 
+var confirmInput io.Reader = os.Stdin
+
 var uninstallCmd = &cobra.Command{
 	Use:   "uninstall [package]",
 	Short: "Uninstall a package",
-	Args:  cobra.ExactArgs(1),
+	Long: `Uninstall a package by removing its containers and networks.
+
+By default (--keep-data) named volumes are preserved and the package directory,
+including any bind-mounted data inside it, is moved to ~/.compak/trash/.
+--purge instead deletes the package directory, volumes, images and networks.
+Anything that deletes data (--purge or --keep-data=false) is listed and
+confirmed before it is removed.`,
+	Example: `  # Remove nginx but keep its data
+  compak uninstall nginx
+
+  # Remove immich and everything it stored
+  compak uninstall immich --purge`,
+	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		packageName := args[0]
 
-		composeClient, err := compose.NewClient()
+		keepData, err := cmd.Flags().GetBool("keep-data")
 		if err != nil {
-			return fmt.Errorf("failed to create compose client: %w", err)
+			return fmt.Errorf("failed to get keep-data flag: %w", err)
 		}
-
-		stateDir, err := config.GetStateDir()
+		purge, err := cmd.Flags().GetBool("purge")
 		if err != nil {
-			return fmt.Errorf("failed to get state directory: %w", err)
+			return fmt.Errorf("failed to get purge flag: %w", err)
+		}
+		yes, err := cmd.Flags().GetBool("yes")
+		if err != nil {
+			return fmt.Errorf("failed to get yes flag: %w", err)
 		}
 
-		client := pkg.NewClient(stateDir)
-		manager := pkg.NewManager(client, composeClient, stateDir)
-
-		return manager.Stop(packageName)
+		return runUninstall(packageName, pkg.RemoveOptions{KeepData: keepData && !purge, Purge: purge}, yes)
 	},
 }
 
+func runUninstall(packageName string, opts pkg.RemoveOptions, yes bool) error {
+	stateDir, err := contextStateDir()
+	if err != nil {
+		return err
+	}
+
+	client := pkg.NewClient(stateDir)
+	if _, err := client.GetInstalledPackage(packageName); err != nil {
+		return fmt.Errorf("package not found: %w", err)
+	}
+
+	engine, err := newEngine()
+	if err != nil {
+		return fmt.Errorf("failed to create compose client: %w", err)
+	}
+
+	manager := pkg.NewManager(client, engine, stateDir)
+	plan, err := manager.PlanRemoval(packageName, opts)
+	if err != nil {
+		return err
+	}
+
+	fmt.Print(plan)
+	if plan.DeletesData() && !yes && !confirm("Proceed?") {
+		return fmt.Errorf("uninstall of %s cancelled", packageName)
+	}
+
+	return manager.RemoveWithPlan(plan)
+}
+
+func confirm(question string) bool {
+	fmt.Printf("%s [y/N] ", question)
+
+	answer, err := bufio.NewReader(confirmInput).ReadString('\n')
+	if err != nil && answer == "" {
+		fmt.Println()
+		return false
+	}
+
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true
+	default:
+		return false
+	}
+}
+
 func init() {
+	uninstallCmd.Flags().Bool("keep-data", true, "keep volumes and move the package directory to the trash")
+	uninstallCmd.Flags().Bool("purge", false, "delete the package directory, volumes, images and networks")
+	uninstallCmd.Flags().BoolP("yes", "y", false, "do not ask before deleting data")
+	uninstallCmd.MarkFlagsMutuallyExclusive("keep-data", "purge")
 	rootCmd.AddCommand(uninstallCmd)
 }
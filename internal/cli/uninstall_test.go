@@ -2,7 +2,9 @@ package cli
 
 import (
 	"bytes"
+	"context"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -193,3 +195,128 @@ func TestUninstallCmdFlags(t *testing.T) {
 		t.Error("expected error for two arguments")
 	}
 }
+
+func writeDataPackage(t *testing.T) string {
+	t.Helper()
+	dir := writeLocalPackage(t, "1.0.0", "nginx:1.27")
+	composeYAML := "services:\n  web:\n    image: nginx:1.27\n    volumes:\n      - ./library:/library\n      - cache:/cache\n      - /srv/media:/media:ro\nvolumes:\n  cache: {}\n"
+	if err := os.WriteFile(filepath.Join(dir, "docker-compose.yaml"), []byte(composeYAML), 0o600); err != nil {
+		t.Fatalf("Failed to write compose file: %v", err)
+	}
+	return dir
+}
+
+func TestUninstallDataRetention(t *testing.T) {
+	tests := []struct {
+		name        string
+		opts        pkg.RemoveOptions
+		answer      string
+		wantErr     bool
+		wantVolumes bool
+		wantTrash   bool
+		wantOutput  []string
+	}{
+		{
+			name:       "keep data",
+			opts:       pkg.RemoveOptions{KeepData: true},
+			wantTrash:  true,
+			wantOutput: []string{"package directory moves to:", "bind-mounted data moved with it: ./library", "volumes kept: compak-demo_cache", "not touched): /srv/media"},
+		},
+		{
+			name:        "purge confirmed",
+			opts:        pkg.RemoveOptions{Purge: true},
+			answer:      "y\n",
+			wantVolumes: true,
+			wantOutput:  []string{"will permanently delete:", "bind-mounted data in the package directory: ./library", "volumes: compak-demo_cache", "images: nginx:1.27", "networks: compak-demo_default"},
+		},
+		{
+			name:       "delete confirmed",
+			opts:       pkg.RemoveOptions{},
+			answer:     "y\n",
+			wantOutput: []string{"package directory is deleted:", "bind-mounted data deleted with it: ./library", "volumes kept: compak-demo_cache"},
+		},
+		{
+			name:    "delete declined",
+			opts:    pkg.RemoveOptions{},
+			answer:  "n\n",
+			wantErr: true,
+		},
+		{
+			name:    "purge declined",
+			opts:    pkg.RemoveOptions{Purge: true},
+			answer:  "n\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine := useFakeEngine(t)
+			if err := runInstall(context.Background(), "demo", installOptions{localPath: writeDataPackage(t)}); err != nil {
+				t.Fatalf("install failed: %v", err)
+			}
+
+			previous := confirmInput
+			confirmInput = strings.NewReader(tt.answer)
+			t.Cleanup(func() { confirmInput = previous })
+
+			old := os.Stdout
+			r, w, pipeErr := os.Pipe()
+			if pipeErr != nil {
+				t.Fatalf("failed to create pipe: %v", pipeErr)
+			}
+			os.Stdout = w
+
+			err := runUninstall("demo", tt.opts, false)
+
+			if closeErr := w.Close(); closeErr != nil {
+				t.Errorf("failed to close pipe: %v", closeErr)
+			}
+			os.Stdout = old
+
+			var buf bytes.Buffer
+			if _, readErr := buf.ReadFrom(r); readErr != nil {
+				t.Fatalf("failed to read from pipe: %v", readErr)
+			}
+			output := buf.String()
+
+			client, _ := newTestManager(t, engine)
+			_, getErr := client.GetInstalledPackage("demo")
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("Expected uninstall to be cancelled")
+				}
+				if getErr != nil || !engine.Running("compak-demo") {
+					t.Error("Expected demo to stay installed and running")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("uninstall failed: %v\n%s", err, output)
+			}
+			if getErr == nil {
+				t.Error("Expected demo to be removed from state")
+			}
+
+			for _, expected := range tt.wantOutput {
+				if !strings.Contains(output, expected) {
+					t.Errorf("output doesn't contain %q\nGot:\n%s", expected, output)
+				}
+			}
+
+			down := engine.DownOptions("compak-demo")
+			if down.Volumes != tt.wantVolumes || (down.Images == "all") != tt.wantVolumes {
+				t.Errorf("Unexpected down options: %+v", down)
+			}
+
+			stateDir, _ := config.GetStateDir()
+			if _, err := os.Stat(filepath.Join(stateDir, "packages", "demo")); !os.IsNotExist(err) {
+				t.Errorf("Expected package directory to be gone, got: %v", err)
+			}
+			trashed, _ := filepath.Glob(filepath.Join(stateDir, "trash", "demo-*", "docker-compose.yaml"))
+			if (len(trashed) == 1) != tt.wantTrash {
+				t.Errorf("Expected trashed package files: %v, got %v", tt.wantTrash, trashed)
+			}
+		})
+	}
+}
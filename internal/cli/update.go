@@ -5,8 +5,6 @@ import (
 	"fmt"
 
 	"github.com/spf13/cobra"
-
-	"github.com/LoriKarikari/compak/internal/core/index"
 )
 
 func init() {
@@ -30,7 +28,10 @@ Examples:
 func updateIndex() error {
 	fmt.Println("Updating compak pak index...")
 
-	client := index.NewClient()
+	client, err := newIndexClient(false)
+	if err != nil {
+		return err
+	}
 	ctx := context.Background()
 
 	if err := client.Update(ctx); err != nil {
@@ -3,16 +3,17 @@ package cli
 import (
 	"context"
 	"fmt"
+	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/Masterminds/semver/v3"
 	"github.com/spf13/cobra"
 	"gopkg.in/yaml.v3"
 
 	"github.com/LoriKarikari/compak/internal/config"
-	"github.com/LoriKarikari/compak/internal/core/compose"
-	"github.com/LoriKarikari/compak/internal/core/index"
 	pkg "github.com/LoriKarikari/compak/internal/core/package"
+	"github.com/LoriKarikari/compak/internal/core/policy"
 )
 
 var upgradeCmd = &cobra.Command{
@@ -29,11 +30,17 @@ while preserving your parameter settings.`,
   # Upgrade to specific version
   compak upgrade immich --version 1.145.0
 
+  # Roll back if the new version is not healthy within 5 minutes
+  compak upgrade immich --wait
+
+  # Back up the package before upgrading
+  compak upgrade immich --backup
+
   # Upgrade all packages
   compak upgrade --all`,
 	Args: cobra.MaximumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		ctx := cmd.Context()
+		ctx := commandContext(cmd)
 
 		all, err := cmd.Flags().GetBool("all")
 		if err != nil {
@@ -45,19 +52,75 @@ while preserving your parameter settings.`,
 			return fmt.Errorf("failed to get version flag: %w", err)
 		}
 
+		quietUnpinned, err := cmd.Flags().GetBool("quiet-unpinned")
+		if err != nil {
+			return fmt.Errorf("failed to get quiet-unpinned flag: %w", err)
+		}
+
+		requireSignatures, err := cmd.Flags().GetBool("require-signatures")
+		if err != nil {
+			return fmt.Errorf("failed to get require-signatures flag: %w", err)
+		}
+
+		acceptRisk, err := cmd.Flags().GetBool("accept-risk")
+		if err != nil {
+			return fmt.Errorf("failed to get accept-risk flag: %w", err)
+		}
+
+		backup, err := cmd.Flags().GetBool("backup")
+		if err != nil {
+			return fmt.Errorf("failed to get backup flag: %w", err)
+		}
+
+		pinImages, err := cmd.Flags().GetBool("pin-images")
+		if err != nil {
+			return fmt.Errorf("failed to get pin-images flag: %w", err)
+		}
+
+		wait, waitTimeout, err := readWaitFlags(cmd)
+		if err != nil {
+			return err
+		}
+
+		opts := upgradeOptions{
+			targetVersion:     targetVersion,
+			quietUnpinned:     quietUnpinned,
+			requireSignatures: requireSignatures,
+			acceptRisk:        acceptRisk,
+			backup:            backup,
+			pinImages:         pinImages,
+			wait:              wait,
+			waitTimeout:       waitTimeout,
+		}
+
 		if all {
-			return upgradeAll(ctx, targetVersion)
+			return upgradeAll(ctx, opts)
 		}
 
 		if len(args) == 0 {
 			return fmt.Errorf("package name required (or use --all)")
 		}
 
-		return upgradePackage(ctx, args[0], targetVersion)
+		return upgradePackage(ctx, args[0], opts)
 	},
 }
 
-func upgradePackage(ctx context.Context, packageName, targetVersion string) error {
+type upgradeOptions struct {
+	targetVersion     string
+	quietUnpinned     bool
+	requireSignatures bool
+	acceptRisk        bool
+	backup            bool
+	pinImages         bool
+	wait              bool
+	waitTimeout       time.Duration
+	policy            policy.Policy
+	values            map[string]string
+	sourcePath        string
+	indexCommit       string
+}
+
+func upgradePackage(ctx context.Context, packageName string, opts upgradeOptions) error {
 	if err := validatePackageName(packageName); err != nil {
 		return err
 	}
@@ -67,17 +130,35 @@ func upgradePackage(ctx context.Context, packageName, targetVersion string) erro
 		return fmt.Errorf("failed to get state directory: %w", err)
 	}
 
-	client := pkg.NewClient(stateDir)
+	cfg, err := config.Load(stateDir)
+	if err != nil {
+		return err
+	}
+	opts.policy = cfg.Policy
+
+	packageStateDir, err := contextStateDir()
+	if err != nil {
+		return err
+	}
+
+	client := pkg.NewClient(packageStateDir)
 
 	installedPkg, err := client.GetInstalledPackage(packageName)
 	if err != nil {
 		return fmt.Errorf("package %s is not installed: %w", packageName, err)
 	}
 
-	latestPkg, err := fetchLatestPackage(ctx, packageName, targetVersion)
+	pakName := packageName
+	if installedPkg.Pak != "" {
+		pakName = installedPkg.Pak
+	}
+
+	latestPkg, err := fetchLatestPackage(ctx, pakName, opts.targetVersion, opts.requireSignatures)
 	if err != nil {
 		return err
 	}
+	latestPkg.Name = packageName
+	opts.indexCommit = indexRevision()
 
 	if shouldUpgrade, reason := compareVersions(installedPkg.Package.Version, latestPkg.Version); !shouldUpgrade {
 		fmt.Printf("Package %s is already %s\n", packageName, reason)
@@ -86,24 +167,35 @@ func upgradePackage(ctx context.Context, packageName, targetVersion string) erro
 
 	fmt.Printf("Upgrading %s: %s → %s\n", packageName, installedPkg.Package.Version, latestPkg.Version)
 
-	composeClient, err := compose.NewClient()
+	engine, err := newEngine()
 	if err != nil {
 		return fmt.Errorf("failed to create compose client: %w", err)
 	}
 
-	manager := pkg.NewManager(client, composeClient, stateDir)
+	manager := pkg.NewManager(client, engine, packageStateDir)
+
+	if opts.backup {
+		output := filepath.Join(packageStateDir, "backups", backupFileName(installedPkg, time.Now()))
+		if err := writeBackupFile(ctx, manager, packageName, output, pkg.BackupOptions{}); err != nil {
+			return fmt.Errorf("pre-upgrade backup failed (aborting upgrade): %w", err)
+		}
+		fmt.Printf("Backed up %s to %s\n", packageName, output)
+	}
 
-	return performUpgrade(manager, packageName, &installedPkg, latestPkg)
+	return performUpgrade(manager, packageName, &installedPkg, latestPkg, opts)
 }
 
-func fetchLatestPackage(ctx context.Context, packageName, targetVersion string) (pkg.Package, error) {
+func fetchLatestPackage(ctx context.Context, packageName, targetVersion string, requireSignatures bool) (pkg.Package, error) {
 	if targetVersion != "" {
 		if _, err := semver.NewVersion(targetVersion); err != nil && targetVersion != "latest" {
 			return pkg.Package{}, fmt.Errorf("invalid target version %q: %w", targetVersion, err)
 		}
 	}
 
-	indexClient := index.NewClient()
+	indexClient, err := newIndexClient(requireSignatures)
+	if err != nil {
+		return pkg.Package{}, err
+	}
 	lookupName := packageName
 	if targetVersion != "" {
 		lookupName = fmt.Sprintf("%s@%s", packageName, targetVersion)
@@ -119,20 +211,45 @@ func fetchLatestPackage(ctx context.Context, packageName, targetVersion string)
 		return pkg.Package{}, fmt.Errorf("failed to parse package: %w", err)
 	}
 
+	if err := pkg.ValidateParamDefinitions(latestPkg.Parameters); err != nil {
+		return pkg.Package{}, fmt.Errorf("invalid package %q in index: %w", lookupName, err)
+	}
+
 	return latestPkg, nil
 }
 
-func performUpgrade(manager *pkg.Manager, packageName string, installedPkg *pkg.InstalledPackage, latestPkg pkg.Package) error {
+func performUpgrade(manager *pkg.Manager, packageName string, installedPkg *pkg.InstalledPackage, latestPkg pkg.Package, upgradeOpts upgradeOptions) error {
 	oldPkg := installedPkg.Package
 	values := installedPkg.Values
+	if upgradeOpts.values != nil {
+		values = upgradeOpts.values
+	}
+
+	opts := pkg.DeployOptions{
+		SourcePath:    upgradeOpts.sourcePath,
+		Overlays:      installedPkg.Overlays,
+		QuietUnpinned: upgradeOpts.quietUnpinned,
+		Policy:        upgradeOpts.policy,
+		AcceptRisk:    upgradeOpts.acceptRisk,
+		Wait:          upgradeOpts.wait,
+		WaitTimeout:   upgradeOpts.waitTimeout,
+		Pak:           installedPkg.Pak,
+		IndexCommit:   upgradeOpts.indexCommit,
+		PinImages:     upgradeOpts.pinImages || installedPkg.ImagesPinned,
+		RemoteHost:    remoteHost(),
+	}
 
-	if err := manager.Stop(packageName); err != nil {
+	if err := manager.CheckPolicy(latestPkg, values, opts); err != nil {
+		return fmt.Errorf("upgrade aborted, %s is still running: %w", oldPkg.Version, err)
+	}
+
+	if err := manager.Down(context.Background(), packageName); err != nil {
 		return fmt.Errorf("failed to stop old version (aborting upgrade): %w", err)
 	}
 
-	if err := manager.Deploy(latestPkg, values); err != nil {
+	if err := manager.DeployWithOptions(latestPkg, values, opts); err != nil {
 		fmt.Printf("Deployment failed, attempting rollback to %s...\n", oldPkg.Version)
-		if rollbackErr := manager.Deploy(oldPkg, values); rollbackErr != nil {
+		if rollbackErr := manager.DeployWithOptions(oldPkg, installedPkg.Values, rollbackOptions(installedPkg, upgradeOpts)); rollbackErr != nil {
 			return fmt.Errorf("failed to deploy upgraded package: %w (rollback also failed: %v)", err, rollbackErr)
 		}
 		return fmt.Errorf("deployment failed, successfully rolled back to %s: %w", oldPkg.Version, err)
@@ -142,10 +259,28 @@ func performUpgrade(manager *pkg.Manager, packageName string, installedPkg *pkg.
 	return nil
 }
 
-func upgradeAll(ctx context.Context, targetVersion string) error {
-	stateDir, err := config.GetStateDir()
+func rollbackOptions(installedPkg *pkg.InstalledPackage, upgradeOpts upgradeOptions) pkg.DeployOptions {
+	opts := pkg.DeployOptions{
+		SourcePath:    upgradeOpts.sourcePath,
+		Overlays:      installedPkg.Overlays,
+		QuietUnpinned: true,
+		AcceptRisk:    true,
+		Pak:           installedPkg.Pak,
+		IndexCommit:   installedPkg.IndexCommit,
+		PinImages:     installedPkg.ImagesPinned,
+		RemoteHost:    remoteHost(),
+	}
+	if installedPkg.ImagesPinned && len(installedPkg.Images) > 0 {
+		lock := pkg.NewRollbackLockEntry(*installedPkg)
+		opts.Lock = &lock
+	}
+	return opts
+}
+
+func upgradeAll(ctx context.Context, opts upgradeOptions) error {
+	stateDir, err := contextStateDir()
 	if err != nil {
-		return fmt.Errorf("failed to get state directory: %w", err)
+		return err
 	}
 
 	client := pkg.NewClient(stateDir)
@@ -166,7 +301,7 @@ func upgradeAll(ctx context.Context, targetVersion string) error {
 
 	for i, installedPkg := range packages {
 		fmt.Printf("\n[%d/%d] Checking %s...\n", i+1, len(packages), installedPkg.Package.Name)
-		err := upgradePackage(ctx, installedPkg.Package.Name, targetVersion)
+		err := upgradePackage(ctx, installedPkg.Package.Name, opts)
 
 		switch {
 		case err == nil:
@@ -221,5 +356,11 @@ func compareVersions(installed, latest string) (shouldUpgrade bool, reason strin
 func init() {
 	upgradeCmd.Flags().String("version", "", "target version to upgrade to")
 	upgradeCmd.Flags().Bool("all", false, "upgrade all installed packages")
+	upgradeCmd.Flags().Bool("quiet-unpinned", false, "do not warn when the compose source has no sourceDigest (a digest mismatch still fails)")
+	upgradeCmd.Flags().Bool("require-signatures", false, "refuse index packages without a signature from a trusted key")
+	upgradeCmd.Flags().Bool("accept-risk", false, "deploy even if the security policy denies the compose configuration")
+	upgradeCmd.Flags().Bool("pin-images", false, "pin every service image to its digest (kept automatically if the package was installed with --pin-images)")
+	upgradeCmd.Flags().Bool("backup", false, "back up the package to backups/ in the context's state directory (~/.compak/backups/ for the default context) before upgrading")
+	addWaitFlags(upgradeCmd)
 	rootCmd.AddCommand(upgradeCmd)
 }
@@ -48,6 +48,18 @@ func TestUpgradeCmdFlags(t *testing.T) {
 	if upgradeCmd.Flags().Lookup("all") == nil {
 		t.Error("Expected --all flag to be defined")
 	}
+
+	if upgradeCmd.Flags().Lookup("quiet-unpinned") == nil {
+		t.Error("Expected --quiet-unpinned flag to be defined")
+	}
+
+	if upgradeCmd.Flags().Lookup("require-signatures") == nil {
+		t.Error("Expected --require-signatures flag to be defined")
+	}
+
+	if upgradeCmd.Flags().Lookup("accept-risk") == nil {
+		t.Error("Expected --accept-risk flag to be defined")
+	}
 }
 
 func TestUpgradeCmdRequiresArg(t *testing.T) {
@@ -180,7 +192,7 @@ func TestFetchLatestPackage_InvalidVersion(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			_, err := fetchLatestPackage(context.Background(), tt.packageName, tt.targetVersion)
+			_, err := fetchLatestPackage(context.Background(), tt.packageName, tt.targetVersion, false)
 
 			if tt.wantErr && err == nil {
 				t.Error("Expected error but got none")
@@ -0,0 +1,79 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+const clearScreen = "\033[H\033[2J"
+
+func addWatchFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolP("watch", "w", false, "refresh the output until interrupted")
+	cmd.Flags().Duration("interval", 2*time.Second, "refresh interval for --watch")
+}
+
+func readWatchFlags(cmd *cobra.Command) (time.Duration, error) {
+	watch, err := cmd.Flags().GetBool("watch")
+	if err != nil {
+		return 0, fmt.Errorf("failed to get watch flag: %w", err)
+	}
+	interval, err := cmd.Flags().GetDuration("interval")
+	if err != nil {
+		return 0, fmt.Errorf("failed to get interval flag: %w", err)
+	}
+	if !watch {
+		return 0, nil
+	}
+	if interval <= 0 {
+		return 0, fmt.Errorf("--interval must be positive")
+	}
+	return interval, nil
+}
+
+func render(ctx context.Context, interval time.Duration, draw func(ctx context.Context, w io.Writer) error) error {
+	if interval == 0 {
+		return draw(ctx, os.Stdout)
+	}
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+	defer stop()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		var frame bytes.Buffer
+		if err := draw(ctx, &frame); err != nil {
+			return err
+		}
+		fmt.Printf("%sEvery %s, updated %s\n\n%s", clearScreen, interval, time.Now().Format("15:04:05"), frame.String())
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func formatUptime(d time.Duration) string {
+	switch {
+	case d <= 0:
+		return "-"
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh%dm", int(d.Hours()), int(d.Minutes())%60)
+	default:
+		return fmt.Sprintf("%dd%dh", int(d.Hours())/24, int(d.Hours())%24)
+	}
+}
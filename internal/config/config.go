@@ -1,10 +1,24 @@
 package config
 
 import (
+	"bytes"
+	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/LoriKarikari/compak/internal/core/policy"
 )
 
+const configFile = "config.yaml"
+
+type Config struct {
+	Engine string        `yaml:"engine"`
+	Policy policy.Policy `yaml:"policy"`
+}
+
 func GetStateDir() (string, error) {
 	home, err := os.UserHomeDir()
 	if err != nil {
@@ -12,3 +26,28 @@ func GetStateDir() (string, error) {
 	}
 	return filepath.Join(home, ".compak"), nil
 }
+
+func Load(stateDir string) (Config, error) {
+	var cfg Config
+
+	path := filepath.Join(stateDir, configFile)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return cfg, nil
+		}
+		return cfg, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&cfg); err != nil && err != io.EOF {
+		return cfg, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	if err := cfg.Policy.Validate(); err != nil {
+		return cfg, fmt.Errorf("invalid policy in %s: %w", path, err)
+	}
+
+	return cfg, nil
+}
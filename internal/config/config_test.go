@@ -0,0 +1,73 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/LoriKarikari/compak/internal/core/policy"
+)
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name      string
+		content   string
+		expectErr bool
+		check     func(t *testing.T, cfg Config)
+	}{
+		{
+			name: "missing file",
+			check: func(t *testing.T, cfg Config) {
+				if len(cfg.Policy.Rules) != 0 {
+					t.Errorf("Expected empty policy, got %+v", cfg.Policy)
+				}
+			},
+		},
+		{
+			name:    "policy rules",
+			content: "policy:\n  rules:\n    docker-socket: warn\n  allowedBindRoots:\n    - /srv\n",
+			check: func(t *testing.T, cfg Config) {
+				if cfg.Policy.Rules[policy.RuleDockerSocket] != policy.ActionWarn {
+					t.Errorf("Expected docker-socket to warn, got %+v", cfg.Policy.Rules)
+				}
+				if len(cfg.Policy.AllowedBindRoots) != 1 || cfg.Policy.AllowedBindRoots[0] != "/srv" {
+					t.Errorf("Unexpected bind roots: %v", cfg.Policy.AllowedBindRoots)
+				}
+			},
+		},
+		{
+			name:    "require signatures",
+			content: "policy:\n  requireSignatures: true\n",
+			check: func(t *testing.T, cfg Config) {
+				if !cfg.Policy.RequireSignatures {
+					t.Error("Expected requireSignatures to be set")
+				}
+			},
+		},
+		{name: "invalid action", content: "policy:\n  rules:\n    privileged: maybe\n", expectErr: true},
+		{name: "unknown field", content: "polcy: {}\n", expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stateDir := t.TempDir()
+			if tt.content != "" {
+				if err := os.WriteFile(filepath.Join(stateDir, configFile), []byte(tt.content), 0o600); err != nil {
+					t.Fatalf("Failed to write config: %v", err)
+				}
+			}
+
+			cfg, err := Load(stateDir)
+			if tt.expectErr {
+				if err == nil {
+					t.Error("Expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error but got: %v", err)
+			}
+			tt.check(t, cfg)
+		})
+	}
+}
@@ -0,0 +1,189 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+const (
+	DefaultContext = "default"
+
+	contextsDir     = "contexts"
+	contextNameFile = "context-name"
+)
+
+var unsafeContextChars = regexp.MustCompile(`[^a-zA-Z0-9._-]+`)
+
+func ResolveContext(flag string) string {
+	if flag != "" {
+		return flag
+	}
+	if host := os.Getenv("DOCKER_HOST"); host != "" {
+		return "host-" + host
+	}
+	if name := os.Getenv("DOCKER_CONTEXT"); name != "" {
+		return name
+	}
+	if name := dockerCurrentContext(); name != "" {
+		return name
+	}
+	return DefaultContext
+}
+
+func StateContext(flag string) string {
+	name := ResolveContext(flag)
+	explicit := flag != "" || (os.Getenv("DOCKER_HOST") == "" && os.Getenv("DOCKER_CONTEXT") != "")
+	if explicit || !IsLocalContext(name) {
+		return name
+	}
+	return DefaultContext
+}
+
+func ContextStateDir(stateDir, context string) (string, error) {
+	dir, exists, err := lookupContextStateDir(stateDir, context)
+	if err != nil || exists {
+		return dir, err
+	}
+
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return "", fmt.Errorf("failed to create context state directory: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, contextNameFile), []byte(context), 0o600); err != nil {
+		return "", fmt.Errorf("failed to record context name: %w", err)
+	}
+	return dir, nil
+}
+
+func LookupContextStateDir(stateDir, context string) (string, error) {
+	dir, _, err := lookupContextStateDir(stateDir, context)
+	return dir, err
+}
+
+func lookupContextStateDir(stateDir, context string) (string, bool, error) {
+	if context == "" || context == DefaultContext {
+		return stateDir, true, nil
+	}
+
+	dir := filepath.Join(stateDir, contextsDir, unsafeContextChars.ReplaceAllString(context, "_"))
+	recorded, err := os.ReadFile(filepath.Join(dir, contextNameFile))
+	switch {
+	case err == nil && string(recorded) != context:
+		return "", false, fmt.Errorf("context %q would share the state directory %s with context %q", context, dir, recorded)
+	case err == nil:
+		return dir, true, nil
+	case !os.IsNotExist(err):
+		return "", false, fmt.Errorf("failed to read context name: %w", err)
+	}
+	return dir, false, nil
+}
+
+func ContextStateDirs(stateDir string) (map[string]string, error) {
+	dirs := map[string]string{DefaultContext: stateDir}
+
+	entries, err := os.ReadDir(filepath.Join(stateDir, contextsDir))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return dirs, nil
+		}
+		return nil, fmt.Errorf("failed to read contexts: %w", err)
+	}
+
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		dir := filepath.Join(stateDir, contextsDir, entry.Name())
+		name := entry.Name()
+		if recorded, err := os.ReadFile(filepath.Join(dir, contextNameFile)); err == nil {
+			name = string(recorded)
+		}
+		dirs[name] = dir
+	}
+	return dirs, nil
+}
+
+func IsLocalContext(name string) bool {
+	return isLocalHost(contextHost(name))
+}
+
+func contextHost(name string) string {
+	if host, ok := strings.CutPrefix(name, "host-"); ok {
+		return host
+	}
+	if name == "" || name == DefaultContext {
+		return os.Getenv("DOCKER_HOST")
+	}
+
+	sum := sha256.Sum256([]byte(name))
+	data, err := os.ReadFile(filepath.Join(dockerConfigDir(), "contexts", "meta", hex.EncodeToString(sum[:]), "meta.json"))
+	if err != nil {
+		return ""
+	}
+
+	var meta struct {
+		Endpoints map[string]struct {
+			Host string `json:"Host"`
+		} `json:"Endpoints"`
+	}
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return ""
+	}
+	return meta.Endpoints["docker"].Host
+}
+
+func isLocalHost(host string) bool {
+	if host == "" {
+		return true
+	}
+
+	parsed, err := url.Parse(host)
+	if err != nil {
+		return false
+	}
+	switch parsed.Scheme {
+	case "unix", "npipe":
+		return true
+	case "tcp", "http", "https":
+		hostname := parsed.Hostname()
+		if hostname == "localhost" {
+			return true
+		}
+		ip := net.ParseIP(hostname)
+		return ip != nil && ip.IsLoopback()
+	}
+	return false
+}
+
+func dockerConfigDir() string {
+	if dir := os.Getenv("DOCKER_CONFIG"); dir != "" {
+		return dir
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".docker")
+}
+
+func dockerCurrentContext() string {
+	data, err := os.ReadFile(filepath.Join(dockerConfigDir(), "config.json"))
+	if err != nil {
+		return ""
+	}
+
+	var dockerConfig struct {
+		CurrentContext string `json:"currentContext"`
+	}
+	if err := json.Unmarshal(data, &dockerConfig); err != nil {
+		return ""
+	}
+	return dockerConfig.CurrentContext
+}
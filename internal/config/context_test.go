@@ -0,0 +1,200 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResolveContext(t *testing.T) {
+	tests := []struct {
+		name          string
+		flag          string
+		dockerHost    string
+		dockerContext string
+		configJSON    string
+		expected      string
+	}{
+		{name: "default", expected: DefaultContext},
+		{name: "flag wins", flag: "prod-1", dockerHost: "ssh://prod-2", dockerContext: "prod-3", expected: "prod-1"},
+		{name: "docker host", dockerHost: "ssh://admin@prod-2", dockerContext: "prod-3", expected: "host-ssh://admin@prod-2"},
+		{name: "docker context env", dockerContext: "prod-3", configJSON: `{"currentContext":"prod-4"}`, expected: "prod-3"},
+		{name: "docker config", configJSON: `{"currentContext":"prod-4"}`, expected: "prod-4"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dockerConfig := t.TempDir()
+			if tt.configJSON != "" {
+				if err := os.WriteFile(filepath.Join(dockerConfig, "config.json"), []byte(tt.configJSON), 0o600); err != nil {
+					t.Fatalf("Failed to write docker config: %v", err)
+				}
+			}
+			t.Setenv("DOCKER_CONFIG", dockerConfig)
+			t.Setenv("DOCKER_HOST", tt.dockerHost)
+			t.Setenv("DOCKER_CONTEXT", tt.dockerContext)
+
+			if got := ResolveContext(tt.flag); got != tt.expected {
+				t.Errorf("Expected context %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestStateContext(t *testing.T) {
+	tests := []struct {
+		name          string
+		flag          string
+		dockerHost    string
+		dockerContext string
+		configJSON    string
+		expected      string
+	}{
+		{name: "default", expected: DefaultContext},
+		{name: "flag", flag: "desktop-linux", expected: "desktop-linux"},
+		{name: "docker context env", dockerContext: "rootless", expected: "rootless"},
+		{name: "implicit local context", configJSON: `{"currentContext":"desktop-linux"}`, expected: DefaultContext},
+		{name: "local docker host", dockerHost: "unix:///var/run/docker.sock", expected: DefaultContext},
+		{name: "remote docker host", dockerHost: "ssh://admin@prod-2", expected: "host-ssh://admin@prod-2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dockerConfig := t.TempDir()
+			if tt.configJSON != "" {
+				if err := os.WriteFile(filepath.Join(dockerConfig, "config.json"), []byte(tt.configJSON), 0o600); err != nil {
+					t.Fatalf("Failed to write docker config: %v", err)
+				}
+			}
+			t.Setenv("DOCKER_CONFIG", dockerConfig)
+			t.Setenv("DOCKER_HOST", tt.dockerHost)
+			t.Setenv("DOCKER_CONTEXT", tt.dockerContext)
+
+			if got := StateContext(tt.flag); got != tt.expected {
+				t.Errorf("Expected context %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestContextStateDir(t *testing.T) {
+	stateDir := t.TempDir()
+
+	tests := []struct {
+		context  string
+		expected string
+		wantErr  bool
+	}{
+		{context: "", expected: "."},
+		{context: DefaultContext, expected: "."},
+		{context: "prod-1", expected: "contexts/prod-1"},
+		{context: "host-ssh://admin@prod-2", expected: "contexts/host-ssh_admin_prod-2"},
+		{context: "host-ssh_admin_prod-2", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.context, func(t *testing.T) {
+			got, err := ContextStateDir(stateDir, tt.context)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Expected a collision error, got %s", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error but got: %v", err)
+			}
+			if expected := filepath.Join(stateDir, filepath.FromSlash(tt.expected)); got != expected {
+				t.Errorf("Expected %s, got %s", expected, got)
+			}
+		})
+	}
+}
+
+func TestContextStateDirs(t *testing.T) {
+	stateDir := t.TempDir()
+
+	dirs, err := ContextStateDirs(stateDir)
+	if err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+	if len(dirs) != 1 || dirs[DefaultContext] != stateDir {
+		t.Errorf("Expected only the default context, got %v", dirs)
+	}
+
+	prodDir, err := ContextStateDir(stateDir, "host-ssh://admin@prod-1")
+	if err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+
+	dirs, err = ContextStateDirs(stateDir)
+	if err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+	if len(dirs) != 2 || dirs["host-ssh://admin@prod-1"] != prodDir {
+		t.Errorf("Expected default and host-ssh://admin@prod-1 contexts, got %v", dirs)
+	}
+}
+
+func TestIsLocalContext(t *testing.T) {
+	dockerConfig := t.TempDir()
+	t.Setenv("DOCKER_CONFIG", dockerConfig)
+	t.Setenv("DOCKER_HOST", "")
+
+	for name, host := range map[string]string{"desktop-linux": "unix:///home/me/.docker/desktop/docker.sock", "prod-1": "ssh://admin@prod-1"} {
+		sum := sha256.Sum256([]byte(name))
+		dir := filepath.Join(dockerConfig, "contexts", "meta", hex.EncodeToString(sum[:]))
+		if err := os.MkdirAll(dir, 0o750); err != nil {
+			t.Fatalf("Failed to create context meta: %v", err)
+		}
+		meta := `{"Name":"` + name + `","Endpoints":{"docker":{"Host":"` + host + `"}}}`
+		if err := os.WriteFile(filepath.Join(dir, "meta.json"), []byte(meta), 0o600); err != nil {
+			t.Fatalf("Failed to write context meta: %v", err)
+		}
+	}
+
+	tests := []struct {
+		context  string
+		expected bool
+	}{
+		{context: DefaultContext, expected: true},
+		{context: "desktop-linux", expected: true},
+		{context: "prod-1", expected: false},
+		{context: "host-unix:///var/run/docker.sock", expected: true},
+		{context: "host-tcp://127.0.0.1:2375", expected: true},
+		{context: "host-tcp://10.0.0.5:2375", expected: false},
+		{context: "host-ssh://admin@prod-2", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.context, func(t *testing.T) {
+			if got := IsLocalContext(tt.context); got != tt.expected {
+				t.Errorf("Expected local=%v for %s, got %v", tt.expected, tt.context, got)
+			}
+		})
+	}
+}
+
+func TestLookupContextStateDir(t *testing.T) {
+	stateDir := t.TempDir()
+
+	dir, err := LookupContextStateDir(stateDir, "prod-1")
+	if err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+	if dir != filepath.Join(stateDir, "contexts", "prod-1") {
+		t.Errorf("Unexpected dir %s", dir)
+	}
+	if _, err := os.Stat(filepath.Join(stateDir, "contexts")); !os.IsNotExist(err) {
+		t.Errorf("Expected lookup not to create the context directory, got %v", err)
+	}
+
+	if _, err := ContextStateDir(stateDir, "host-ssh://admin@prod-2"); err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+	if _, err := LookupContextStateDir(stateDir, "host-ssh_admin_prod-2"); err == nil {
+		t.Error("Expected a collision error")
+	}
+}
@@ -1,7 +1,6 @@
 package compose
 
 import (
-	"bufio"
 	"context"
 	"fmt"
 	"os"
@@ -9,24 +8,29 @@ import (
 	"strings"
 
 	"github.com/compose-spec/compose-go/v2/cli"
+	"github.com/compose-spec/compose-go/v2/dotenv"
 	"github.com/compose-spec/compose-go/v2/types"
 	dockercli "github.com/docker/cli/cli/command"
 	"github.com/docker/cli/cli/flags"
 	"github.com/docker/compose/v2/pkg/api"
 	"github.com/docker/compose/v2/pkg/compose"
+	"github.com/docker/docker/client"
 )
 
 type Client struct {
 	service api.Service
+	docker  client.APIClient
 }
 
-func NewClient() (*Client, error) {
+func NewClient(dockerContext string) (*Client, error) {
 	dockerCli, err := dockercli.NewDockerCli()
 	if err != nil {
 		return nil, fmt.Errorf("failed to create docker client: %w", err)
 	}
 
-	if err := dockerCli.Initialize(flags.NewClientOptions()); err != nil {
+	options := flags.NewClientOptions()
+	options.Context = dockerContext
+	if err := dockerCli.Initialize(options); err != nil {
 		return nil, fmt.Errorf("failed to initialize docker client: %w", err)
 	}
 
@@ -34,24 +38,68 @@ func NewClient() (*Client, error) {
 
 	return &Client{
 		service: service,
+		docker:  dockerCli.Client(),
 	}, nil
 }
 
-func (c *Client) LoadProject(projectDir, projectName string) (*types.Project, error) {
-	composeFile := filepath.Join(projectDir, "docker-compose.yaml")
+func FindComposeFiles(projectDir string) ([]string, error) {
+	var files []string
+	for _, candidates := range [][]string{cli.DefaultFileNames, cli.DefaultOverrideFileNames} {
+		for _, name := range candidates {
+			if info, err := os.Stat(filepath.Join(projectDir, name)); err == nil && !info.IsDir() {
+				files = append(files, name)
+				break
+			}
+		}
+	}
+
+	if len(files) == 0 {
+		return nil, fmt.Errorf("%w in %s (looked for %s)", ErrNoComposeFile, projectDir, strings.Join(cli.DefaultFileNames, ", "))
+	}
+	return files, nil
+}
+
+func (c *Client) LoadProject(projectDir, projectName string, files ...string) (*types.Project, error) {
+	return LoadProject(projectDir, projectName, files...)
+}
+
+func LoadProject(projectDir, projectName string, files ...string) (*types.Project, error) {
+	return LoadProjectIn(projectDir, projectDir, projectName, files...)
+}
+
+func LoadProjectIn(projectDir, workingDir, projectName string, files ...string) (*types.Project, error) {
+	if len(files) == 0 {
+		found, err := FindComposeFiles(projectDir)
+		if err != nil {
+			return nil, err
+		}
+		files = found
+	}
+
+	composeFiles := make([]string, 0, len(files))
+	for _, file := range files {
+		composeFiles = append(composeFiles, filepath.Join(projectDir, file))
+	}
 	envFile := filepath.Join(projectDir, ".env")
 
-	if err := loadAndExportEnv(projectDir, ".env"); err != nil {
+	env, err := loadEnv(projectDir, ".env")
+	if err != nil {
 		return nil, fmt.Errorf("failed to load .env: %w", err)
 	}
 
-	options, err := cli.NewProjectOptions(
-		[]string{composeFile},
+	optionFns := []cli.ProjectOptionsFn{
 		cli.WithName(projectName),
-		cli.WithWorkingDirectory(projectDir),
+		cli.WithWorkingDirectory(workingDir),
 		cli.WithEnvFiles(envFile),
 		cli.WithOsEnv,
-	)
+		cli.WithEnv(env),
+	}
+	relocated := filepath.Clean(workingDir) != filepath.Clean(projectDir)
+	if relocated {
+		optionFns = append(optionFns, cli.WithoutEnvironmentResolution)
+	}
+
+	options, err := cli.NewProjectOptions(composeFiles, optionFns...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create project options: %w", err)
 	}
@@ -61,6 +109,12 @@ func (c *Client) LoadProject(projectDir, projectName string) (*types.Project, er
 		return nil, fmt.Errorf("failed to load project: %w", err)
 	}
 
+	if relocated {
+		if project, err = resolveEnvFilesIn(project, projectDir, workingDir); err != nil {
+			return nil, fmt.Errorf("failed to resolve env files: %w", err)
+		}
+	}
+
 	project.Name = projectName
 
 	for i, service := range project.Services {
@@ -79,6 +133,28 @@ func (c *Client) LoadProject(projectDir, projectName string) (*types.Project, er
 	return project, nil
 }
 
+func resolveEnvFilesIn(project *types.Project, projectDir, workingDir string) (*types.Project, error) {
+	relocate := func(from, to string) {
+		for name, service := range project.Services {
+			for i, envFile := range service.EnvFiles {
+				if rel, err := filepath.Rel(from, envFile.Path); err == nil && !strings.HasPrefix(rel, "..") {
+					service.EnvFiles[i].Path = filepath.Join(to, rel)
+				}
+			}
+			project.Services[name] = service
+		}
+	}
+
+	relocate(workingDir, projectDir)
+	resolved, err := project.WithServicesEnvironmentResolved(false)
+	if err != nil {
+		return nil, err
+	}
+	project = resolved
+	relocate(projectDir, workingDir)
+	return project, nil
+}
+
 func (c *Client) Up(ctx context.Context, project *types.Project, detach bool, consumer api.LogConsumer) error {
 	err := c.service.Up(ctx, project, api.UpOptions{
 		Create: api.CreateOptions{
@@ -104,14 +180,43 @@ func (c *Client) Up(ctx context.Context, project *types.Project, detach bool, co
 	return nil
 }
 
-func (c *Client) Down(ctx context.Context, projectName string) error {
-	return c.service.Down(ctx, projectName, api.DownOptions{
-		RemoveOrphans: true,
+func (c *Client) Down(ctx context.Context, project *types.Project, options api.DownOptions) error {
+	options.Project = project
+	options.RemoveOrphans = true
+	return c.service.Down(ctx, project.Name, options)
+}
+
+func (c *Client) Start(ctx context.Context, project *types.Project, services []string) error {
+	return c.service.Start(ctx, project.Name, api.StartOptions{
+		Project:  project,
+		Services: services,
+	})
+}
+
+func (c *Client) Stop(ctx context.Context, project *types.Project, services []string) error {
+	return c.service.Stop(ctx, project.Name, api.StopOptions{
+		Project:  project,
+		Services: services,
 	})
 }
 
-func (c *Client) PS(ctx context.Context, projectName string) ([]api.ContainerSummary, error) {
-	return c.service.Ps(ctx, projectName, api.PsOptions{
+func (c *Client) Restart(ctx context.Context, project *types.Project, services []string) error {
+	return c.service.Restart(ctx, project.Name, api.RestartOptions{
+		Project:  project,
+		Services: services,
+	})
+}
+
+func (c *Client) Pause(ctx context.Context, project *types.Project) error {
+	return c.service.Pause(ctx, project.Name, api.PauseOptions{Project: project})
+}
+
+func (c *Client) Unpause(ctx context.Context, project *types.Project) error {
+	return c.service.UnPause(ctx, project.Name, api.PauseOptions{Project: project})
+}
+
+func (c *Client) PS(ctx context.Context, project *types.Project) ([]api.ContainerSummary, error) {
+	return c.service.Ps(ctx, project.Name, api.PsOptions{
 		All: true,
 	})
 }
@@ -120,16 +225,14 @@ func (c *Client) Pull(ctx context.Context, project *types.Project) error {
 	return c.service.Pull(ctx, project, api.PullOptions{})
 }
 
-func (c *Client) Logs(ctx context.Context, projectName string, consumer api.LogConsumer, follow bool) error {
-	return c.service.Logs(ctx, projectName, consumer, api.LogOptions{
-		Follow: follow,
-	})
+func (c *Client) Logs(ctx context.Context, project *types.Project, consumer api.LogConsumer, options api.LogOptions) error {
+	return c.service.Logs(ctx, project.Name, consumer, options)
 }
 
-func loadAndExportEnv(rootDir, filename string) (err error) {
+func loadEnv(rootDir, filename string) (env []string, err error) {
 	root, err := os.OpenRoot(rootDir)
 	if err != nil {
-		return fmt.Errorf("failed to open root: %w", err)
+		return nil, fmt.Errorf("failed to open root: %w", err)
 	}
 	defer func() {
 		if closeErr := root.Close(); closeErr != nil && err == nil {
@@ -140,9 +243,9 @@ func loadAndExportEnv(rootDir, filename string) (err error) {
 	f, err := root.Open(filename)
 	if err != nil {
 		if os.IsNotExist(err) {
-			return nil
+			return nil, nil
 		}
-		return err
+		return nil, err
 	}
 	defer func() {
 		if closeErr := f.Close(); closeErr != nil && err == nil {
@@ -150,30 +253,24 @@ func loadAndExportEnv(rootDir, filename string) (err error) {
 		}
 	}()
 
-	return parseAndSetEnv(f)
+	values, err := dotenv.Parse(f)
+	if err != nil {
+		return nil, err
+	}
+	for key, value := range values {
+		env = append(env, key+"="+value)
+	}
+	return env, nil
 }
 
-func parseAndSetEnv(f *os.File) error {
-	scanner := bufio.NewScanner(f)
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-		if line == "" || strings.HasPrefix(line, "#") {
-			continue
-		}
-
-		parts := strings.SplitN(line, "=", 2)
-		if len(parts) != 2 {
-			continue
-		}
-
-		key := strings.TrimSpace(parts[0])
-		value := strings.Trim(strings.TrimSpace(parts[1]), `"'`)
-
-		if key != "" {
-			if err := os.Setenv(key, value); err != nil {
-				return fmt.Errorf("failed to set env var %s: %w", key, err)
-			}
-		}
+func ServiceHash(service types.ServiceConfig) (string, error) {
+	if service.Deploy != nil {
+		deploy := *service.Deploy
+		service.Deploy = &deploy
+	}
+	hash, err := compose.ServiceHash(service)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash service %s: %w", service.Name, err)
 	}
-	return scanner.Err()
+	return hash, nil
 }
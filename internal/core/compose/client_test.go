@@ -0,0 +1,44 @@
+package compose
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadProjectKeepsEnvPerProject(t *testing.T) {
+	compose := "services:\n  app:\n    image: nginx\n    environment:\n      COMPAK_TEST_TZ: ${COMPAK_TEST_TZ:-UTC}\n"
+
+	first := t.TempDir()
+	second := t.TempDir()
+	for _, dir := range []string{first, second} {
+		if err := os.WriteFile(filepath.Join(dir, "docker-compose.yaml"), []byte(compose), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(first, ".env"), []byte("COMPAK_TEST_TZ=Europe/Paris\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		dir      string
+		expected string
+	}{
+		{dir: first, expected: "Europe/Paris"},
+		{dir: second, expected: "UTC"},
+	}
+
+	for _, tt := range tests {
+		project, err := LoadProject(tt.dir, "demo")
+		if err != nil {
+			t.Fatalf("LoadProject failed: %v", err)
+		}
+		if value := project.Services["app"].Environment["COMPAK_TEST_TZ"]; value == nil || *value != tt.expected {
+			t.Errorf("Expected COMPAK_TEST_TZ=%s in %s, got %v", tt.expected, tt.dir, value)
+		}
+	}
+
+	if value, ok := os.LookupEnv("COMPAK_TEST_TZ"); ok {
+		t.Errorf("Expected .env values to stay out of the process environment, got COMPAK_TEST_TZ=%s", value)
+	}
+}
@@ -0,0 +1,561 @@
+package composetest
+
+import (
+	"archive/tar"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/docker/compose/v2/pkg/api"
+
+	"github.com/LoriKarikari/compak/internal/core/compose"
+)
+
+const (
+	MethodLoadProject = "LoadProject"
+	MethodUp          = "Up"
+	MethodDown        = "Down"
+	MethodStart       = "Start"
+	MethodStop        = "Stop"
+	MethodRestart     = "Restart"
+	MethodPS          = "PS"
+	MethodInspect     = "Inspect"
+	MethodPull        = "Pull"
+	MethodLogs        = "Logs"
+	MethodPause       = "Pause"
+	MethodUnpause     = "Unpause"
+	MethodExport      = "ExportVolume"
+	MethodImport      = "ImportVolume"
+	MethodImages      = "ImageDigests"
+)
+
+type Call struct {
+	Method  string
+	Project string
+}
+
+type Engine struct {
+	mu         sync.Mutex
+	calls      []Call
+	projects   map[string]*types.Project
+	containers map[string]map[string]api.ContainerSummary
+	failures   map[string]error
+	failNext   map[string][]error
+	logs       map[string][]logLine
+	downs      map[string]api.DownOptions
+	volumes    map[string]map[string][]byte
+	healthNext []string
+	details    map[string]compose.ContainerDetails
+	digests    map[string]string
+}
+
+type logLine struct {
+	service string
+	message string
+}
+
+var _ compose.Engine = (*Engine)(nil)
+
+func NewEngine() *Engine {
+	return &Engine{
+		projects:   make(map[string]*types.Project),
+		containers: make(map[string]map[string]api.ContainerSummary),
+		failures:   make(map[string]error),
+		failNext:   make(map[string][]error),
+		logs:       make(map[string][]logLine),
+		downs:      make(map[string]api.DownOptions),
+		volumes:    make(map[string]map[string][]byte),
+		details:    make(map[string]compose.ContainerDetails),
+		digests:    make(map[string]string),
+	}
+}
+
+func (e *Engine) Fail(method string, err error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if err == nil {
+		delete(e.failures, method)
+		return
+	}
+	e.failures[method] = err
+}
+
+func (e *Engine) FailNext(method string, err error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.failNext[method] = append(e.failNext[method], err)
+}
+
+func (e *Engine) SetDigest(image, digest string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.digests[image] = digest
+}
+
+func (e *Engine) SetImage(projectName, service, image string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	container, ok := e.containers[projectName][service]
+	if !ok {
+		return fmt.Errorf("no container for service %s in project %s", service, projectName)
+	}
+	container.Image = image
+	e.containers[projectName][service] = container
+	return nil
+}
+
+func (e *Engine) SetLabel(projectName, service, key, value string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	container, ok := e.containers[projectName][service]
+	if !ok {
+		return fmt.Errorf("no container for service %s in project %s", service, projectName)
+	}
+	container.Labels = maps.Clone(container.Labels)
+	if container.Labels == nil {
+		container.Labels = make(map[string]string)
+	}
+	container.Labels[key] = value
+	e.containers[projectName][service] = container
+	return nil
+}
+
+func (e *Engine) RemoveContainer(projectName, service string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	delete(e.containers[projectName], service)
+}
+
+func (e *Engine) SetState(projectName, service, state string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	container, ok := e.containers[projectName][service]
+	if !ok {
+		return fmt.Errorf("no container for service %s in project %s", service, projectName)
+	}
+	container.State = state
+	container.Status = state
+	e.containers[projectName][service] = container
+	return nil
+}
+
+func (e *Engine) SetHealth(projectName, service, health string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	container, ok := e.containers[projectName][service]
+	if !ok {
+		return fmt.Errorf("no container for service %s in project %s", service, projectName)
+	}
+	container.Health = health
+	e.containers[projectName][service] = container
+	return nil
+}
+
+func (e *Engine) SetRestartCount(projectName, service string, count int) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	container, ok := e.containers[projectName][service]
+	if !ok {
+		return fmt.Errorf("no container for service %s in project %s", service, projectName)
+	}
+	details := e.details[container.ID]
+	details.RestartCount = count
+	e.details[container.ID] = details
+	return nil
+}
+
+func (e *Engine) HealthNext(health string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.healthNext = append(e.healthNext, health)
+}
+
+func (e *Engine) AddLogs(projectName, service string, lines ...string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, line := range lines {
+		e.logs[projectName] = append(e.logs[projectName], logLine{service: service, message: line})
+	}
+}
+
+func (e *Engine) Calls() []Call {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return slices.Clone(e.calls)
+}
+
+func (e *Engine) Count(method string) int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	count := 0
+	for _, call := range e.calls {
+		if call.Method == method {
+			count++
+		}
+	}
+	return count
+}
+
+func (e *Engine) Project(projectName string) *types.Project {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.projects[projectName]
+}
+
+func (e *Engine) Running(projectName string) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return len(e.containers[projectName]) > 0
+}
+
+func (e *Engine) State(projectName, service string) string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.containers[projectName][service].State
+}
+
+func (e *Engine) DownOptions(projectName string) api.DownOptions {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.downs[projectName]
+}
+
+func (e *Engine) SetVolumeFile(volumeName, name string, data []byte) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.volumes[volumeName] == nil {
+		e.volumes[volumeName] = make(map[string][]byte)
+	}
+	e.volumes[volumeName][name] = slices.Clone(data)
+}
+
+func (e *Engine) VolumeFile(volumeName, name string) ([]byte, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	data, ok := e.volumes[volumeName][name]
+	return data, ok
+}
+
+func (e *Engine) HasVolume(volumeName string) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	_, ok := e.volumes[volumeName]
+	return ok
+}
+
+func (e *Engine) LoadProject(projectDir, projectName string, files ...string) (*types.Project, error) {
+	if err := e.record(MethodLoadProject, projectName); err != nil {
+		return nil, err
+	}
+	return compose.LoadProject(projectDir, projectName, files...)
+}
+
+func (e *Engine) Up(_ context.Context, project *types.Project, _ bool, _ api.LogConsumer) error {
+	if err := e.record(MethodUp, project.Name); err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	var health string
+	if len(e.healthNext) > 0 {
+		health, e.healthNext = e.healthNext[0], e.healthNext[1:]
+	}
+
+	containers := make(map[string]api.ContainerSummary, len(project.Services))
+	for _, name := range project.ServiceNames() {
+		service := project.Services[name]
+		labels := maps.Clone(service.Labels)
+		if labels == nil {
+			labels = make(map[string]string)
+		}
+		if hash, err := compose.ServiceHash(service); err == nil {
+			labels[api.ConfigHashLabel] = hash
+		}
+		containers[name] = api.ContainerSummary{
+			ID:         fmt.Sprintf("%s-%s", project.Name, name),
+			Name:       fmt.Sprintf("%s-%s-1", project.Name, name),
+			Image:      service.Image,
+			Project:    project.Name,
+			Service:    name,
+			State:      "running",
+			Status:     "Up",
+			Health:     health,
+			Publishers: publishers(service.Ports),
+			Labels:     labels,
+		}
+		e.details[containers[name].ID] = compose.ContainerDetails{StartedAt: time.Now()}
+	}
+	e.containers[project.Name] = containers
+	e.projects[project.Name] = project
+	return nil
+}
+
+func (e *Engine) Down(_ context.Context, project *types.Project, options api.DownOptions) error {
+	if err := e.record(MethodDown, project.Name); err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.downs[project.Name] = options
+	if options.Volumes {
+		for key := range project.Volumes {
+			if name, err := compose.VolumeName(project, key); err == nil {
+				delete(e.volumes, name)
+			}
+		}
+	}
+	delete(e.containers, project.Name)
+	delete(e.projects, project.Name)
+	return nil
+}
+
+func (e *Engine) Start(_ context.Context, project *types.Project, services []string) error {
+	if err := e.record(MethodStart, project.Name); err != nil {
+		return err
+	}
+	return e.setStates(project.Name, services, "running", "Up")
+}
+
+func (e *Engine) Stop(_ context.Context, project *types.Project, services []string) error {
+	if err := e.record(MethodStop, project.Name); err != nil {
+		return err
+	}
+	return e.setStates(project.Name, services, "exited", "Exited (0)")
+}
+
+func (e *Engine) Restart(_ context.Context, project *types.Project, services []string) error {
+	if err := e.record(MethodRestart, project.Name); err != nil {
+		return err
+	}
+	return e.setStates(project.Name, services, "running", "Up")
+}
+
+func (e *Engine) Pause(_ context.Context, project *types.Project) error {
+	if err := e.record(MethodPause, project.Name); err != nil {
+		return err
+	}
+	return e.setStates(project.Name, nil, "paused", "Up (Paused)")
+}
+
+func (e *Engine) Unpause(_ context.Context, project *types.Project) error {
+	if err := e.record(MethodUnpause, project.Name); err != nil {
+		return err
+	}
+	return e.setStates(project.Name, nil, "running", "Up")
+}
+
+func (e *Engine) setStates(projectName string, services []string, state, status string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	containers := e.containers[projectName]
+	if len(containers) == 0 {
+		return fmt.Errorf("no containers found for project %s", projectName)
+	}
+
+	if len(services) == 0 {
+		services = slices.Sorted(maps.Keys(containers))
+	}
+	for _, service := range services {
+		container, ok := containers[service]
+		if !ok {
+			return fmt.Errorf("no such service: %s", service)
+		}
+		container.State = state
+		container.Status = status
+		containers[service] = container
+		if state == "running" {
+			details := e.details[container.ID]
+			details.StartedAt = time.Now()
+			e.details[container.ID] = details
+		}
+	}
+	return nil
+}
+
+func publishers(ports []types.ServicePortConfig) api.PortPublishers {
+	var result api.PortPublishers
+	for _, port := range ports {
+		published, _ := strconv.Atoi(port.Published)
+		result = append(result, api.PortPublisher{
+			URL:           port.HostIP,
+			TargetPort:    int(port.Target),
+			PublishedPort: published,
+			Protocol:      port.Protocol,
+		})
+	}
+	return result
+}
+
+func (e *Engine) PS(_ context.Context, project *types.Project) ([]api.ContainerSummary, error) {
+	if err := e.record(MethodPS, project.Name); err != nil {
+		return nil, err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	containers := e.containers[project.Name]
+	summaries := make([]api.ContainerSummary, 0, len(containers))
+	for _, service := range slices.Sorted(maps.Keys(containers)) {
+		summaries = append(summaries, containers[service])
+	}
+	return summaries, nil
+}
+
+func (e *Engine) Inspect(_ context.Context, containerIDs ...string) (map[string]compose.ContainerDetails, error) {
+	if err := e.record(MethodInspect, ""); err != nil {
+		return nil, err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	details := make(map[string]compose.ContainerDetails, len(containerIDs))
+	for _, id := range containerIDs {
+		details[id] = e.details[id]
+	}
+	return details, nil
+}
+
+func (e *Engine) Pull(_ context.Context, project *types.Project) error {
+	return e.record(MethodPull, project.Name)
+}
+
+func (e *Engine) ImageDigests(_ context.Context, images ...string) (map[string]string, error) {
+	if err := e.record(MethodImages, ""); err != nil {
+		return nil, err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	digests := make(map[string]string, len(images))
+	for _, image := range images {
+		digest, ok := e.digests[image]
+		if _, pinned, found := strings.Cut(image, "@"); found && !ok {
+			digest = pinned
+		} else if !ok {
+			sum := sha256.Sum256([]byte(image))
+			digest = "sha256:" + hex.EncodeToString(sum[:])
+		}
+		digests[image] = digest
+	}
+	return digests, nil
+}
+
+func (e *Engine) Logs(_ context.Context, project *types.Project, consumer api.LogConsumer, options api.LogOptions) error {
+	if err := e.record(MethodLogs, project.Name); err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	var lines []logLine
+	for _, line := range e.logs[project.Name] {
+		if len(options.Services) == 0 || slices.Contains(options.Services, line.service) {
+			lines = append(lines, line)
+		}
+	}
+	e.mu.Unlock()
+
+	if tail, err := strconv.Atoi(options.Tail); err == nil && tail < len(lines) {
+		lines = lines[len(lines)-tail:]
+	}
+
+	for _, line := range lines {
+		consumer.Log(line.service+"-1", line.message)
+	}
+	return nil
+}
+
+func (e *Engine) ExportVolume(_ context.Context, project *types.Project, key string, w io.Writer) error {
+	if err := e.record(MethodExport, project.Name); err != nil {
+		return err
+	}
+	name, err := compose.VolumeName(project, key)
+	if err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	files := maps.Clone(e.volumes[name])
+	e.mu.Unlock()
+
+	writer := tar.NewWriter(w)
+	for _, file := range slices.Sorted(maps.Keys(files)) {
+		header := &tar.Header{Name: file, Mode: 0o644, Size: int64(len(files[file])), Typeflag: tar.TypeReg}
+		if err := writer.WriteHeader(header); err != nil {
+			return err
+		}
+		if _, err := writer.Write(files[file]); err != nil {
+			return err
+		}
+	}
+	return writer.Close()
+}
+
+func (e *Engine) ImportVolume(_ context.Context, project *types.Project, key string, r io.Reader) error {
+	if err := e.record(MethodImport, project.Name); err != nil {
+		return err
+	}
+	name, err := compose.VolumeName(project, key)
+	if err != nil {
+		return err
+	}
+
+	files := make(map[string][]byte)
+	reader := tar.NewReader(r)
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		data, err := io.ReadAll(reader)
+		if err != nil {
+			return err
+		}
+		files[header.Name] = data
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.volumes[name] = files
+	return nil
+}
+
+func (e *Engine) record(method, projectName string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.calls = append(e.calls, Call{Method: method, Project: projectName})
+
+	if queued := e.failNext[method]; len(queued) > 0 {
+		e.failNext[method] = queued[1:]
+		return queued[0]
+	}
+	return e.failures[method]
+}
@@ -0,0 +1,101 @@
+package compose
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"time"
+)
+
+const (
+	EngineAuto          = "auto"
+	EngineDocker        = "docker"
+	EnginePodman        = "podman"
+	EngineDockerCompose = "docker-compose"
+)
+
+const detectTimeout = 5 * time.Second
+
+var (
+	lookPath   = exec.LookPath
+	pingDocker = dockerPing
+)
+
+func Engines() []string {
+	return []string{EngineAuto, EngineDocker, EnginePodman, EngineDockerCompose}
+}
+
+func NewEngine(name, dockerContext string) (Engine, error) {
+	if name == "" || name == EngineAuto {
+		detected, err := DetectEngine(dockerContext)
+		if err != nil {
+			return nil, err
+		}
+		name = detected
+	}
+
+	switch name {
+	case EngineDocker:
+		client, err := NewClient(dockerContext)
+		if err != nil {
+			return nil, err
+		}
+		return client, nil
+	case EnginePodman:
+		engine, err := newPodmanEngine()
+		if err != nil {
+			return nil, err
+		}
+		return engine.WithContext(dockerContext), nil
+	case EngineDockerCompose:
+		if _, err := lookPath("docker-compose"); err != nil {
+			return nil, fmt.Errorf("engine docker-compose: %w", ErrNoComposeFound)
+		}
+		return NewExecEngine("docker", "docker-compose").WithContext(dockerContext), nil
+	default:
+		return nil, fmt.Errorf("unknown engine %q: must be one of auto, docker, podman, docker-compose", name)
+	}
+}
+
+func DetectEngine(dockerContext string) (string, error) {
+	if err := pingDocker(dockerContext); err == nil {
+		return EngineDocker, nil
+	}
+	if _, err := newPodmanEngine(); err == nil {
+		return EnginePodman, nil
+	}
+	if _, err := lookPath("docker-compose"); err == nil {
+		return EngineDockerCompose, nil
+	}
+	return "", ErrNoComposeFound
+}
+
+func dockerPing(dockerContext string) error {
+	client, err := NewClient(dockerContext)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), detectTimeout)
+	defer cancel()
+	if _, err := client.docker.Ping(ctx); err != nil {
+		return fmt.Errorf("docker daemon is not reachable: %w", err)
+	}
+	return nil
+}
+
+func newPodmanEngine() (*ExecEngine, error) {
+	if _, err := lookPath("podman"); err != nil {
+		return nil, fmt.Errorf("engine podman: %w", ErrNoComposeFound)
+	}
+	if _, err := lookPath("podman-compose"); err == nil {
+		return NewExecEngine("podman", "podman-compose"), nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), detectTimeout)
+	defer cancel()
+	if err := exec.CommandContext(ctx, "podman", "compose", "version").Run(); err != nil {
+		return nil, fmt.Errorf("engine podman: no compose provider (install podman-compose or docker-compose): %w", ErrNoComposeFound)
+	}
+	return NewExecEngine("podman", "podman", "compose"), nil
+}
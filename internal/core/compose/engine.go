@@ -0,0 +1,29 @@
+package compose
+
+import (
+	"context"
+	"io"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/docker/compose/v2/pkg/api"
+)
+
+type Engine interface {
+	LoadProject(projectDir, projectName string, files ...string) (*types.Project, error)
+	Up(ctx context.Context, project *types.Project, detach bool, consumer api.LogConsumer) error
+	Down(ctx context.Context, project *types.Project, options api.DownOptions) error
+	Start(ctx context.Context, project *types.Project, services []string) error
+	Stop(ctx context.Context, project *types.Project, services []string) error
+	Restart(ctx context.Context, project *types.Project, services []string) error
+	Pause(ctx context.Context, project *types.Project) error
+	Unpause(ctx context.Context, project *types.Project) error
+	PS(ctx context.Context, project *types.Project) ([]api.ContainerSummary, error)
+	Inspect(ctx context.Context, containerIDs ...string) (map[string]ContainerDetails, error)
+	Pull(ctx context.Context, project *types.Project) error
+	ImageDigests(ctx context.Context, images ...string) (map[string]string, error)
+	Logs(ctx context.Context, project *types.Project, consumer api.LogConsumer, options api.LogOptions) error
+	ExportVolume(ctx context.Context, project *types.Project, volume string, w io.Writer) error
+	ImportVolume(ctx context.Context, project *types.Project, volume string, r io.Reader) error
+}
+
+var _ Engine = (*Client)(nil)
@@ -4,4 +4,5 @@ import "errors"
 
 var (
 	ErrNoComposeFound = errors.New("no compose command found: please install Docker Compose, docker-compose, or podman-compose")
+	ErrNoComposeFile  = errors.New("no compose file found")
 )
@@ -0,0 +1,382 @@
+package compose
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/docker/compose/v2/pkg/api"
+)
+
+type ExecEngine struct {
+	command []string
+	runtime string
+	env     []string
+}
+
+var _ Engine = (*ExecEngine)(nil)
+
+func NewExecEngine(runtime string, command ...string) *ExecEngine {
+	return &ExecEngine{command: command, runtime: runtime}
+}
+
+func (e *ExecEngine) WithContext(dockerContext string) *ExecEngine {
+	if dockerContext == "" {
+		return e
+	}
+
+	variable := "DOCKER_CONTEXT"
+	if e.runtime == "podman" {
+		variable = "CONTAINER_CONNECTION"
+	}
+	e.env = append(e.env, variable+"="+dockerContext)
+	return e
+}
+
+func (e *ExecEngine) Command() string {
+	return strings.Join(e.command, " ")
+}
+
+func (e *ExecEngine) LoadProject(projectDir, projectName string, files ...string) (*types.Project, error) {
+	return LoadProject(projectDir, projectName, files...)
+}
+
+func (e *ExecEngine) Up(ctx context.Context, project *types.Project, detach bool, consumer api.LogConsumer) error {
+	if err := e.run(ctx, project, "up", "-d", "--remove-orphans"); err != nil {
+		return err
+	}
+
+	if !detach {
+		if consumer == nil {
+			return fmt.Errorf("log consumer required when not running detached")
+		}
+		return e.Logs(ctx, project, consumer, api.LogOptions{Follow: true})
+	}
+
+	return nil
+}
+
+func (e *ExecEngine) Down(ctx context.Context, project *types.Project, options api.DownOptions) error {
+	args := []string{"down", "--remove-orphans"}
+	if options.Volumes {
+		args = append(args, "--volumes")
+	}
+	if options.Images != "" {
+		args = append(args, "--rmi", options.Images)
+	}
+	return e.run(ctx, project, args...)
+}
+
+func (e *ExecEngine) Start(ctx context.Context, project *types.Project, services []string) error {
+	return e.run(ctx, project, append([]string{"start"}, services...)...)
+}
+
+func (e *ExecEngine) Stop(ctx context.Context, project *types.Project, services []string) error {
+	return e.run(ctx, project, append([]string{"stop"}, services...)...)
+}
+
+func (e *ExecEngine) Restart(ctx context.Context, project *types.Project, services []string) error {
+	return e.run(ctx, project, append([]string{"restart"}, services...)...)
+}
+
+func (e *ExecEngine) Pause(ctx context.Context, project *types.Project) error {
+	return e.run(ctx, project, "pause")
+}
+
+func (e *ExecEngine) Unpause(ctx context.Context, project *types.Project) error {
+	return e.run(ctx, project, "unpause")
+}
+
+func (e *ExecEngine) Pull(ctx context.Context, project *types.Project) error {
+	return e.run(ctx, project, "pull")
+}
+
+func (e *ExecEngine) Logs(ctx context.Context, project *types.Project, consumer api.LogConsumer, options api.LogOptions) error {
+	args := []string{"logs", "--no-color"}
+	if options.Follow {
+		args = append(args, "-f")
+	}
+	if options.Timestamps {
+		args = append(args, "-t")
+	}
+	if options.Tail != "" {
+		args = append(args, "--tail", options.Tail)
+	}
+	if options.Since != "" {
+		args = append(args, "--since", options.Since)
+	}
+	args = append(args, options.Services...)
+
+	cmd := e.composeCommand(ctx, project, args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("failed to read logs: %w", err)
+	}
+
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to run %s: %w", e.Command(), err)
+	}
+
+	scanner := bufio.NewScanner(stdout)
+	for scanner.Scan() {
+		container, message, found := strings.Cut(scanner.Text(), "|")
+		if !found {
+			consumer.Log(project.Name, scanner.Text())
+			continue
+		}
+		consumer.Log(strings.TrimSpace(container), strings.TrimPrefix(message, " "))
+	}
+
+	if err := cmd.Wait(); err != nil {
+		return commandError(e.Command()+" logs", err, stderr.String())
+	}
+	return scanner.Err()
+}
+
+func (e *ExecEngine) PS(ctx context.Context, project *types.Project) ([]api.ContainerSummary, error) {
+	cmd := e.runtimeCommand(ctx, "ps", "-a",
+		"--filter", "label="+api.ProjectLabel+"="+project.Name,
+		"--format", "json",
+	)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, commandError(e.runtime+" ps", err, stderr.String())
+	}
+
+	return parseContainers(output)
+}
+
+func (e *ExecEngine) run(ctx context.Context, project *types.Project, args ...string) error {
+	cmd := e.composeCommand(ctx, project, args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return commandError(e.Command()+" "+args[0], err, stderr.String())
+	}
+	return nil
+}
+
+func (e *ExecEngine) composeCommand(ctx context.Context, project *types.Project, args ...string) *exec.Cmd {
+	fullArgs := append([]string{}, e.command[1:]...)
+	fullArgs = append(fullArgs, "-p", project.Name)
+	for _, file := range project.ComposeFiles {
+		fullArgs = append(fullArgs, "-f", file)
+	}
+	fullArgs = append(fullArgs, args...)
+
+	cmd := exec.CommandContext(ctx, e.command[0], fullArgs...)
+	cmd.Dir = project.WorkingDir
+	cmd.Env = e.environ()
+	return cmd
+}
+
+func (e *ExecEngine) runtimeCommand(ctx context.Context, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, e.runtime, args...)
+	cmd.Env = e.environ()
+	return cmd
+}
+
+func (e *ExecEngine) environ() []string {
+	if len(e.env) == 0 {
+		return nil
+	}
+	return append(os.Environ(), e.env...)
+}
+
+func commandError(command string, err error, stderr string) error {
+	if stderr = strings.TrimSpace(stderr); stderr != "" {
+		return fmt.Errorf("%s failed: %w: %s", command, err, stderr)
+	}
+	return fmt.Errorf("%s failed: %w", command, err)
+}
+
+type runtimeContainer struct {
+	ID       string          `json:"Id"`
+	ShortID  string          `json:"ID"`
+	Names    json.RawMessage `json:"Names"`
+	Image    string          `json:"Image"`
+	State    string          `json:"State"`
+	Status   string          `json:"Status"`
+	Labels   json.RawMessage `json:"Labels"`
+	Ports    json.RawMessage `json:"Ports"`
+	ExitCode int             `json:"ExitCode"`
+}
+
+type podmanPort struct {
+	HostIP        string `json:"host_ip"`
+	ContainerPort int    `json:"container_port"`
+	HostPort      int    `json:"host_port"`
+	Protocol      string `json:"protocol"`
+}
+
+func parseContainers(output []byte) ([]api.ContainerSummary, error) {
+	output = bytes.TrimSpace(output)
+	if len(output) == 0 {
+		return nil, nil
+	}
+
+	var raw []runtimeContainer
+	if output[0] == '[' {
+		if err := json.Unmarshal(output, &raw); err != nil {
+			return nil, fmt.Errorf("failed to parse container list: %w", err)
+		}
+	} else {
+		decoder := json.NewDecoder(bytes.NewReader(output))
+		for {
+			var container runtimeContainer
+			if err := decoder.Decode(&container); err == io.EOF {
+				break
+			} else if err != nil {
+				return nil, fmt.Errorf("failed to parse container list: %w", err)
+			}
+			raw = append(raw, container)
+		}
+	}
+
+	containers := make([]api.ContainerSummary, 0, len(raw))
+	for _, container := range raw {
+		containers = append(containers, container.summary())
+	}
+	return containers, nil
+}
+
+func (c runtimeContainer) summary() api.ContainerSummary {
+	names := parseNames(c.Names)
+	labels := parseLabels(c.Labels)
+
+	id := c.ID
+	if id == "" {
+		id = c.ShortID
+	}
+
+	summary := api.ContainerSummary{
+		ID:         id,
+		Names:      names,
+		Image:      c.Image,
+		Project:    labels[api.ProjectLabel],
+		Service:    labels[api.ServiceLabel],
+		State:      strings.ToLower(c.State),
+		Status:     c.Status,
+		Health:     parseHealth(c.Status),
+		ExitCode:   c.ExitCode,
+		Labels:     labels,
+		Publishers: parsePorts(c.Ports),
+	}
+	if len(names) > 0 {
+		summary.Name = names[0]
+	}
+	return summary
+}
+
+func parseNames(raw json.RawMessage) []string {
+	var names []string
+	if err := json.Unmarshal(raw, &names); err == nil {
+		return names
+	}
+
+	var joined string
+	if err := json.Unmarshal(raw, &joined); err != nil || joined == "" {
+		return nil
+	}
+	return strings.Split(joined, ",")
+}
+
+func parseHealth(status string) string {
+	switch {
+	case strings.Contains(status, "(healthy)"):
+		return "healthy"
+	case strings.Contains(status, "(unhealthy)"):
+		return "unhealthy"
+	case strings.Contains(status, "(health: starting)"), strings.Contains(status, "(starting)"):
+		return "starting"
+	default:
+		return ""
+	}
+}
+
+func parsePorts(raw json.RawMessage) api.PortPublishers {
+	var publishers api.PortPublishers
+
+	var ports []podmanPort
+	if err := json.Unmarshal(raw, &ports); err == nil {
+		for _, port := range ports {
+			publishers = append(publishers, api.PortPublisher{
+				URL:           port.HostIP,
+				TargetPort:    port.ContainerPort,
+				PublishedPort: port.HostPort,
+				Protocol:      port.Protocol,
+			})
+		}
+		return publishers
+	}
+
+	var joined string
+	if err := json.Unmarshal(raw, &joined); err != nil {
+		return nil
+	}
+	for _, entry := range strings.Split(joined, ",") {
+		if publisher, ok := parsePort(strings.TrimSpace(entry)); ok {
+			publishers = append(publishers, publisher)
+		}
+	}
+	return publishers
+}
+
+func parsePort(entry string) (api.PortPublisher, bool) {
+	host, target, published := strings.Cut(entry, "->")
+	if !published {
+		host, target = "", entry
+	}
+
+	port, protocol, _ := strings.Cut(target, "/")
+	targetPort, err := strconv.Atoi(port)
+	if err != nil {
+		return api.PortPublisher{}, false
+	}
+	publisher := api.PortPublisher{TargetPort: targetPort, Protocol: protocol}
+
+	if published {
+		separator := strings.LastIndex(host, ":")
+		publishedPort, err := strconv.Atoi(host[separator+1:])
+		if err != nil {
+			return api.PortPublisher{}, false
+		}
+		publisher.PublishedPort = publishedPort
+		if separator > 0 {
+			publisher.URL = strings.Trim(host[:separator], "[]")
+		}
+	}
+	return publisher, true
+}
+
+func parseLabels(raw json.RawMessage) map[string]string {
+	labels := map[string]string{}
+	if err := json.Unmarshal(raw, &labels); err == nil {
+		return labels
+	}
+
+	var joined string
+	if err := json.Unmarshal(raw, &joined); err != nil {
+		return labels
+	}
+	for _, pair := range strings.Split(joined, ",") {
+		if key, value, found := strings.Cut(pair, "="); found {
+			labels[key] = value
+		}
+	}
+	return labels
+}
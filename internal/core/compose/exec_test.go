@@ -0,0 +1,480 @@
+package compose
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/docker/compose/v2/pkg/api"
+)
+
+type recordingConsumer struct {
+	lines []string
+}
+
+func (c *recordingConsumer) Log(container, message string) {
+	c.lines = append(c.lines, container+": "+message)
+}
+
+func (c *recordingConsumer) Err(container, message string) {}
+
+func (c *recordingConsumer) Status(container, message string) {}
+
+func fakeBinaries(t *testing.T, scripts map[string]string) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("fake binaries require a POSIX shell")
+	}
+
+	binDir := t.TempDir()
+	logFile := filepath.Join(t.TempDir(), "calls.log")
+	for name, body := range scripts {
+		script := "#!/bin/sh\necho \"" + name + " $@\" >> \"" + logFile + "\"\n" + body + "\n"
+		if err := os.WriteFile(filepath.Join(binDir, name), []byte(script), 0o755); err != nil {
+			t.Fatalf("Failed to write fake %s: %v", name, err)
+		}
+	}
+	t.Setenv("PATH", binDir)
+	t.Setenv("DOCKER_HOST", "")
+
+	original := pingDocker
+	pingDocker = func(string) error { return errors.New("docker daemon is not reachable") }
+	t.Cleanup(func() { pingDocker = original })
+
+	return logFile
+}
+
+func readCalls(t *testing.T, logFile string) []string {
+	t.Helper()
+	data, err := os.ReadFile(logFile)
+	if err != nil {
+		t.Fatalf("Failed to read call log: %v", err)
+	}
+	return strings.Split(strings.TrimSpace(string(data)), "\n")
+}
+
+func testProject(t *testing.T) *types.Project {
+	dir := t.TempDir()
+	return &types.Project{
+		Name:         "compak-web",
+		WorkingDir:   dir,
+		ComposeFiles: []string{filepath.Join(dir, "docker-compose.yaml")},
+	}
+}
+
+func TestDetectEngine(t *testing.T) {
+	tests := []struct {
+		name     string
+		daemon   bool
+		binaries map[string]string
+		expected string
+		wantErr  error
+	}{
+		{
+			name:     "docker preferred",
+			daemon:   true,
+			binaries: map[string]string{"docker": "exit 0", "podman": "exit 0", "docker-compose": "exit 0"},
+			expected: EngineDocker,
+		},
+		{
+			name:     "docker without a daemon",
+			binaries: map[string]string{"docker": "exit 0", "podman": "exit 0"},
+			expected: EnginePodman,
+		},
+		{
+			name:     "podman before docker-compose",
+			binaries: map[string]string{"podman": "exit 0", "docker-compose": "exit 0"},
+			expected: EnginePodman,
+		},
+		{
+			name:     "podman without a compose provider",
+			binaries: map[string]string{"podman": `[ "$1" = compose ] && exit 125; exit 0`, "docker-compose": "exit 0"},
+			expected: EngineDockerCompose,
+		},
+		{
+			name:     "docker-compose only",
+			binaries: map[string]string{"docker-compose": "exit 0"},
+			expected: EngineDockerCompose,
+		},
+		{
+			name:    "nothing installed",
+			wantErr: ErrNoComposeFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeBinaries(t, tt.binaries)
+			if tt.daemon {
+				pingDocker = func(string) error { return nil }
+			}
+
+			engine, err := DetectEngine("")
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("Expected %v but got: %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error but got: %v", err)
+			}
+			if engine != tt.expected {
+				t.Errorf("Expected engine %s, got %s", tt.expected, engine)
+			}
+		})
+	}
+}
+
+func TestNewEnginePodmanCommand(t *testing.T) {
+	tests := []struct {
+		name     string
+		binaries []string
+		expected string
+	}{
+		{
+			name:     "podman-compose",
+			binaries: []string{"podman", "podman-compose"},
+			expected: "podman-compose",
+		},
+		{
+			name:     "podman compose",
+			binaries: []string{"podman"},
+			expected: "podman compose",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scripts := map[string]string{}
+			for _, binary := range tt.binaries {
+				scripts[binary] = "exit 0"
+			}
+			fakeBinaries(t, scripts)
+
+			engine, err := NewEngine(EnginePodman, "")
+			if err != nil {
+				t.Fatalf("Expected no error but got: %v", err)
+			}
+			execEngine, ok := engine.(*ExecEngine)
+			if !ok {
+				t.Fatalf("Expected exec engine, got %T", engine)
+			}
+			if execEngine.Command() != tt.expected {
+				t.Errorf("Expected command %q, got %q", tt.expected, execEngine.Command())
+			}
+		})
+	}
+}
+
+func TestNewEngineErrors(t *testing.T) {
+	fakeBinaries(t, nil)
+
+	if _, err := NewEngine("kubernetes", ""); err == nil || !strings.Contains(err.Error(), "unknown engine") {
+		t.Errorf("Expected unknown engine error, got: %v", err)
+	}
+	if _, err := NewEngine(EngineDockerCompose, ""); !errors.Is(err, ErrNoComposeFound) {
+		t.Errorf("Expected ErrNoComposeFound, got: %v", err)
+	}
+	if _, err := NewEngine(EnginePodman, ""); !errors.Is(err, ErrNoComposeFound) {
+		t.Errorf("Expected ErrNoComposeFound, got: %v", err)
+	}
+}
+
+func TestExecEngineCommands(t *testing.T) {
+	logFile := fakeBinaries(t, map[string]string{
+		"docker-compose": "exit 0",
+		"docker":         "exit 0",
+	})
+
+	engine, err := NewEngine(EngineDockerCompose, "")
+	if err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+
+	project := testProject(t)
+	ctx := context.Background()
+	if err := engine.Pull(ctx, project); err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+	if err := engine.Up(ctx, project, true, nil); err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+	if err := engine.Stop(ctx, project, []string{"web"}); err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+	if err := engine.Start(ctx, project, nil); err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+	if err := engine.Restart(ctx, project, []string{"web", "db"}); err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+	if err := engine.Down(ctx, project, api.DownOptions{Volumes: true, Images: "all"}); err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+
+	file := project.ComposeFiles[0]
+	expected := []string{
+		"docker-compose -p compak-web -f " + file + " pull",
+		"docker-compose -p compak-web -f " + file + " up -d --remove-orphans",
+		"docker-compose -p compak-web -f " + file + " stop web",
+		"docker-compose -p compak-web -f " + file + " start",
+		"docker-compose -p compak-web -f " + file + " restart web db",
+		"docker-compose -p compak-web -f " + file + " down --remove-orphans --volumes --rmi all",
+	}
+	calls := readCalls(t, logFile)
+	if strings.Join(calls, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Expected calls:\n%s\ngot:\n%s", strings.Join(expected, "\n"), strings.Join(calls, "\n"))
+	}
+}
+
+func TestExecEngineCommandFailure(t *testing.T) {
+	fakeBinaries(t, map[string]string{
+		"podman":         "exit 0",
+		"podman-compose": "echo 'image not found' >&2; exit 3",
+	})
+
+	engine, err := NewEngine(EnginePodman, "")
+	if err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+
+	err = engine.Up(context.Background(), testProject(t), true, nil)
+	if err == nil {
+		t.Fatal("Expected error but got none")
+	}
+	if !strings.Contains(err.Error(), "podman-compose up failed") || !strings.Contains(err.Error(), "image not found") {
+		t.Errorf("Expected error to include command and stderr, got: %v", err)
+	}
+}
+
+func TestExecEngineLogs(t *testing.T) {
+	fakeBinaries(t, map[string]string{
+		"docker-compose": "printf 'web_1  | hello\\nweb_1  | a | b\\nno separator\\n'",
+	})
+
+	consumer := &recordingConsumer{}
+	engine := NewExecEngine("docker", "docker-compose")
+	if err := engine.Logs(context.Background(), testProject(t), consumer, api.LogOptions{}); err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+
+	expected := []string{"web_1: hello", "web_1: a | b", "compak-web: no separator"}
+	if strings.Join(consumer.lines, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Expected lines %v, got %v", expected, consumer.lines)
+	}
+}
+
+func TestExecEnginePS(t *testing.T) {
+	tests := []struct {
+		name    string
+		runtime string
+		output  string
+	}{
+		{
+			name:    "docker line json",
+			runtime: "docker",
+			output:  `{"ID":"abc123","Names":"compak-web-web-1","Image":"nginx:1.27","State":"running","Status":"Up 2 minutes","Labels":"com.docker.compose.project=compak-web,com.docker.compose.service=web"}`,
+		},
+		{
+			name:    "podman json array",
+			runtime: "podman",
+			output:  `[{"Id":"abc123","Names":["compak-web-web-1"],"Image":"nginx:1.27","State":"running","Status":"Up 2 minutes","Labels":{"com.docker.compose.project":"compak-web","com.docker.compose.service":"web"}}]`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logFile := fakeBinaries(t, map[string]string{
+				tt.runtime: "while IFS= read -r line; do echo \"$line\"; done <<'EOF'\n" + tt.output + "\nEOF",
+			})
+
+			engine := NewExecEngine(tt.runtime, "unused")
+			containers, err := engine.PS(context.Background(), testProject(t))
+			if err != nil {
+				t.Fatalf("Expected no error but got: %v", err)
+			}
+			if len(containers) != 1 {
+				t.Fatalf("Expected 1 container, got %d", len(containers))
+			}
+
+			container := containers[0]
+			if container.ID != "abc123" || container.Name != "compak-web-web-1" || container.Service != "web" ||
+				container.Project != "compak-web" || container.State != "running" || container.Status != "Up 2 minutes" {
+				t.Errorf("Unexpected container summary: %+v", container)
+			}
+
+			calls := readCalls(t, logFile)
+			expected := tt.runtime + " ps -a --filter label=com.docker.compose.project=compak-web --format json"
+			if len(calls) != 1 || calls[0] != expected {
+				t.Errorf("Expected call %q, got %v", expected, calls)
+			}
+		})
+	}
+}
+
+func TestParseHealth(t *testing.T) {
+	tests := []struct {
+		status   string
+		expected string
+	}{
+		{status: "Up 2 minutes", expected: ""},
+		{status: "Up 2 minutes (healthy)", expected: "healthy"},
+		{status: "Up 10 seconds (health: starting)", expected: "starting"},
+		{status: "Up 10 seconds (starting)", expected: "starting"},
+		{status: "Up 3 minutes (unhealthy)", expected: "unhealthy"},
+		{status: "Exited (1) 5 seconds ago", expected: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.status, func(t *testing.T) {
+			if got := parseHealth(tt.status); got != tt.expected {
+				t.Errorf("Expected health %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestParsePorts(t *testing.T) {
+	tests := []struct {
+		name     string
+		raw      string
+		expected api.PortPublishers
+	}{
+		{
+			name: "docker string",
+			raw:  `"0.0.0.0:8080->80/tcp, [::]:8080->80/tcp, 443/tcp"`,
+			expected: api.PortPublishers{
+				{URL: "0.0.0.0", PublishedPort: 8080, TargetPort: 80, Protocol: "tcp"},
+				{URL: "::", PublishedPort: 8080, TargetPort: 80, Protocol: "tcp"},
+				{TargetPort: 443, Protocol: "tcp"},
+			},
+		},
+		{
+			name:     "podman array",
+			raw:      `[{"host_ip":"127.0.0.1","container_port":5432,"host_port":15432,"range":1,"protocol":"tcp"}]`,
+			expected: api.PortPublishers{{URL: "127.0.0.1", PublishedPort: 15432, TargetPort: 5432, Protocol: "tcp"}},
+		},
+		{name: "empty", raw: `""`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parsePorts(json.RawMessage(tt.raw)); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("Expected %+v, got %+v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestExecEngineInspect(t *testing.T) {
+	output := `[{"Id":"abc123","RestartCount":3,"State":{"StartedAt":"2025-01-02T13:23:37.123456789Z"}},{"Id":"def456","RestartCount":0,"State":{"StartedAt":"0001-01-01T00:00:00Z"}}]`
+	logFile := fakeBinaries(t, map[string]string{
+		"docker": "echo '" + output + "'",
+	})
+
+	engine := NewExecEngine("docker", "unused")
+	details, err := engine.Inspect(context.Background(), "abc", "def")
+	if err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+
+	started := time.Date(2025, 1, 2, 13, 23, 37, 123456789, time.UTC)
+	if details["abc"].RestartCount != 3 || !details["abc"].StartedAt.Equal(started) {
+		t.Errorf("Unexpected details for abc: %+v", details["abc"])
+	}
+	if details["def"].RestartCount != 0 || !details["def"].StartedAt.IsZero() {
+		t.Errorf("Unexpected details for def: %+v", details["def"])
+	}
+
+	calls := readCalls(t, logFile)
+	if len(calls) != 1 || calls[0] != "docker inspect --type container abc def" {
+		t.Errorf("Unexpected calls: %v", calls)
+	}
+}
+
+func TestExecEngineImageDigests(t *testing.T) {
+	digest := "sha256:" + strings.Repeat("a", 64)
+	output := `[{"RepoDigests":["nginx@` + digest + `"]},{"RepoDigests":[]}]`
+	logFile := fakeBinaries(t, map[string]string{
+		"docker": "echo '" + output + "'",
+	})
+
+	engine := NewExecEngine("docker", "unused")
+	_, err := engine.ImageDigests(context.Background(), "nginx:1.27", "local/app")
+	if err == nil || !strings.Contains(err.Error(), "local/app has no registry digest") {
+		t.Errorf("Expected error for an image without a registry digest, got %v", err)
+	}
+
+	calls := readCalls(t, logFile)
+	if len(calls) != 1 || calls[0] != "docker image inspect nginx:1.27 local/app" {
+		t.Errorf("Unexpected calls: %v", calls)
+	}
+}
+
+func TestRepoDigest(t *testing.T) {
+	digest := "sha256:" + strings.Repeat("b", 64)
+	tests := []struct {
+		name        string
+		image       string
+		repoDigests []string
+		wantErr     bool
+	}{
+		{name: "docker hub", image: "nginx:1.27", repoDigests: []string{"docker.io/library/nginx@" + digest}},
+		{name: "familiar", image: "ghcr.io/immich-app/immich-server:release", repoDigests: []string{"ghcr.io/immich-app/immich-server@" + digest}},
+		{name: "other repository", image: "nginx", repoDigests: []string{"mirror.local/nginx@" + digest}, wantErr: true},
+		{name: "none", image: "nginx", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := repoDigest(tt.image, tt.repoDigests)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Expected error=%v, got %v", tt.wantErr, err)
+			}
+			if !tt.wantErr && got != digest {
+				t.Errorf("Expected %s, got %s", digest, got)
+			}
+		})
+	}
+
+	if pinned := PinImage("nginx:1.27@sha256:old", digest); pinned != "nginx:1.27@"+digest {
+		t.Errorf("Expected the existing digest to be replaced, got %s", pinned)
+	}
+}
+
+func TestExecEngineContext(t *testing.T) {
+	tests := []struct {
+		runtime  string
+		binary   string
+		variable string
+	}{
+		{runtime: "docker", binary: "docker-compose", variable: "DOCKER_CONTEXT"},
+		{runtime: "podman", binary: "podman-compose", variable: "CONTAINER_CONNECTION"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.runtime, func(t *testing.T) {
+			logFile := fakeBinaries(t, map[string]string{
+				tt.binary: "echo \"context=$" + tt.variable + "\" >&2; exit 1",
+			})
+
+			engine := NewExecEngine(tt.runtime, tt.binary).WithContext("prod-1")
+			err := engine.Pull(context.Background(), testProject(t))
+			if err == nil || !strings.Contains(err.Error(), "context=prod-1") {
+				t.Errorf("Expected %s=prod-1 in the command environment, got: %v", tt.variable, err)
+			}
+			if calls := readCalls(t, logFile); len(calls) != 1 {
+				t.Errorf("Expected 1 call, got %v", calls)
+			}
+		})
+	}
+}
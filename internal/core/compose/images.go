@@ -0,0 +1,90 @@
+package compose
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/distribution/reference"
+)
+
+type inspectedImage struct {
+	RepoDigests []string `json:"RepoDigests"`
+}
+
+func PinImage(image, digest string) string {
+	if name, _, found := strings.Cut(image, "@"); found {
+		image = name
+	}
+	return image + "@" + digest
+}
+
+func repoDigest(image string, repoDigests []string) (string, error) {
+	named, err := reference.ParseNormalizedNamed(image)
+	if err != nil {
+		return "", fmt.Errorf("invalid image reference %s: %w", image, err)
+	}
+
+	for _, candidate := range repoDigests {
+		parsed, err := reference.ParseNormalizedNamed(candidate)
+		if err != nil {
+			continue
+		}
+		if canonical, ok := parsed.(reference.Canonical); ok && parsed.Name() == named.Name() {
+			return canonical.Digest().String(), nil
+		}
+	}
+	return "", fmt.Errorf("image %s has no registry digest", image)
+}
+
+func (c *Client) ImageDigests(ctx context.Context, images ...string) (map[string]string, error) {
+	digests := make(map[string]string, len(images))
+	for _, image := range images {
+		response, err := c.docker.ImageInspect(ctx, image)
+		if err != nil {
+			return nil, fmt.Errorf("failed to inspect image %s: %w", image, err)
+		}
+
+		digest, err := repoDigest(image, response.RepoDigests)
+		if err != nil {
+			return nil, err
+		}
+		digests[image] = digest
+	}
+	return digests, nil
+}
+
+func (e *ExecEngine) ImageDigests(ctx context.Context, images ...string) (map[string]string, error) {
+	if len(images) == 0 {
+		return map[string]string{}, nil
+	}
+
+	cmd := e.runtimeCommand(ctx, append([]string{"image", "inspect"}, images...)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, commandError(e.runtime+" image inspect", err, stderr.String())
+	}
+
+	var inspected []inspectedImage
+	if err := json.Unmarshal(output, &inspected); err != nil {
+		return nil, fmt.Errorf("failed to parse image details: %w", err)
+	}
+	if len(inspected) != len(images) {
+		return nil, fmt.Errorf("expected details for %d images, got %d", len(images), len(inspected))
+	}
+
+	digests := make(map[string]string, len(images))
+	for i, image := range images {
+		digest, err := repoDigest(image, inspected[i].RepoDigests)
+		if err != nil {
+			return nil, err
+		}
+		digests[image] = digest
+	}
+	return digests, nil
+}
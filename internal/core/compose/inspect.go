@@ -0,0 +1,76 @@
+package compose
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+type ContainerDetails struct {
+	RestartCount int
+	StartedAt    time.Time
+}
+
+type inspectedContainer struct {
+	ID           string `json:"Id"`
+	RestartCount int    `json:"RestartCount"`
+	State        struct {
+		StartedAt string `json:"StartedAt"`
+	} `json:"State"`
+}
+
+func (i inspectedContainer) details() ContainerDetails {
+	startedAt, _ := time.Parse(time.RFC3339Nano, i.State.StartedAt)
+	return ContainerDetails{RestartCount: i.RestartCount, StartedAt: startedAt}
+}
+
+func (c *Client) Inspect(ctx context.Context, containerIDs ...string) (map[string]ContainerDetails, error) {
+	details := make(map[string]ContainerDetails, len(containerIDs))
+	for _, id := range containerIDs {
+		response, err := c.docker.ContainerInspect(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to inspect container %s: %w", id, err)
+		}
+
+		container := inspectedContainer{ID: id}
+		if response.ContainerJSONBase != nil {
+			container.RestartCount = response.RestartCount
+			if response.State != nil {
+				container.State.StartedAt = response.State.StartedAt
+			}
+		}
+		details[id] = container.details()
+	}
+	return details, nil
+}
+
+func (e *ExecEngine) Inspect(ctx context.Context, containerIDs ...string) (map[string]ContainerDetails, error) {
+	if len(containerIDs) == 0 {
+		return map[string]ContainerDetails{}, nil
+	}
+
+	cmd := e.runtimeCommand(ctx, append([]string{"inspect", "--type", "container"}, containerIDs...)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, commandError(e.runtime+" inspect", err, stderr.String())
+	}
+
+	var containers []inspectedContainer
+	if err := json.Unmarshal(output, &containers); err != nil {
+		return nil, fmt.Errorf("failed to parse container details: %w", err)
+	}
+	if len(containers) != len(containerIDs) {
+		return nil, fmt.Errorf("expected details for %d containers, got %d", len(containerIDs), len(containers))
+	}
+
+	details := make(map[string]ContainerDetails, len(containerIDs))
+	for i, container := range containers {
+		details[containerIDs[i]] = container.details()
+	}
+	return details, nil
+}
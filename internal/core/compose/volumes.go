@@ -0,0 +1,209 @@
+package compose
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/docker/compose/v2/pkg/api"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/api/types/volume"
+)
+
+const (
+	volumeHelperImage = "busybox:1.37"
+	volumeMountPoint  = "/data"
+)
+
+func VolumeName(project *types.Project, key string) (string, error) {
+	config, ok := project.Volumes[key]
+	if !ok {
+		return "", fmt.Errorf("project %s has no volume %q", project.Name, key)
+	}
+	if config.Name != "" {
+		return config.Name, nil
+	}
+	return project.Name + "_" + key, nil
+}
+
+func volumeLabels(project *types.Project, key string) map[string]string {
+	return map[string]string{
+		api.ProjectLabel: project.Name,
+		api.VolumeLabel:  key,
+		api.VersionLabel: api.ComposeVersion,
+	}
+}
+
+func (c *Client) ExportVolume(ctx context.Context, project *types.Project, key string, w io.Writer) error {
+	name, err := VolumeName(project, key)
+	if err != nil {
+		return err
+	}
+
+	id, err := c.createVolumeHelper(ctx, name, true)
+	if err != nil {
+		return err
+	}
+	defer c.removeVolumeHelper(id)
+
+	content, _, err := c.docker.CopyFromContainer(ctx, id, volumeMountPoint)
+	if err != nil {
+		return fmt.Errorf("failed to read volume %s: %w", name, err)
+	}
+	defer func() { _ = content.Close() }()
+
+	return rewriteTar(content, w, func(name string) string {
+		_, rest, _ := strings.Cut(name, "/")
+		return rest
+	})
+}
+
+func (c *Client) ImportVolume(ctx context.Context, project *types.Project, key string, r io.Reader) error {
+	name, err := VolumeName(project, key)
+	if err != nil {
+		return err
+	}
+
+	if _, err := c.docker.VolumeCreate(ctx, volume.CreateOptions{Name: name, Labels: volumeLabels(project, key)}); err != nil {
+		return fmt.Errorf("failed to create volume %s: %w", name, err)
+	}
+
+	id, err := c.createVolumeHelper(ctx, name, false)
+	if err != nil {
+		return err
+	}
+	defer c.removeVolumeHelper(id)
+
+	reader, writer := io.Pipe()
+	go func() {
+		_ = writer.CloseWithError(rewriteTar(r, writer, func(name string) string {
+			return path.Join(strings.TrimPrefix(volumeMountPoint, "/"), name)
+		}))
+	}()
+
+	if err := c.docker.CopyToContainer(ctx, id, "/", reader, container.CopyToContainerOptions{}); err != nil {
+		_ = reader.CloseWithError(err)
+		return fmt.Errorf("failed to write volume %s: %w", name, err)
+	}
+	return nil
+}
+
+func (c *Client) createVolumeHelper(ctx context.Context, volumeName string, readOnly bool) (string, error) {
+	bind := volumeName + ":" + volumeMountPoint
+	if readOnly {
+		bind += ":ro"
+	}
+
+	var pullErr error
+	if progress, err := c.docker.ImagePull(ctx, volumeHelperImage, image.PullOptions{}); err != nil {
+		pullErr = err
+	} else {
+		_, _ = io.Copy(io.Discard, progress)
+		_ = progress.Close()
+	}
+
+	created, err := c.docker.ContainerCreate(ctx,
+		&container.Config{Image: volumeHelperImage, Cmd: []string{"true"}},
+		&container.HostConfig{Binds: []string{bind}},
+		nil, nil, "")
+	if err != nil {
+		return "", fmt.Errorf("failed to create helper container for volume %s: %w", volumeName, errors.Join(err, pullErr))
+	}
+	return created.ID, nil
+}
+
+func (c *Client) removeVolumeHelper(id string) {
+	_ = c.docker.ContainerRemove(context.Background(), id, container.RemoveOptions{Force: true})
+}
+
+func (e *ExecEngine) ExportVolume(ctx context.Context, project *types.Project, key string, w io.Writer) error {
+	name, err := VolumeName(project, key)
+	if err != nil {
+		return err
+	}
+
+	cmd := e.runtimeCommand(ctx, "run", "--rm", "-v", name+":"+volumeMountPoint+":ro",
+		volumeHelperImage, "tar", "-C", volumeMountPoint, "-cf", "-", ".")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("failed to read volume %s: %w", name, err)
+	}
+
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to run %s: %w", e.runtime, err)
+	}
+
+	copyErr := rewriteTar(stdout, w, func(name string) string {
+		return strings.TrimPrefix(name, "./")
+	})
+	if err := cmd.Wait(); err != nil {
+		return commandError(e.runtime+" run", err, stderr.String())
+	}
+	return copyErr
+}
+
+func (e *ExecEngine) ImportVolume(ctx context.Context, project *types.Project, key string, r io.Reader) error {
+	name, err := VolumeName(project, key)
+	if err != nil {
+		return err
+	}
+
+	args := []string{"volume", "create"}
+	labels := volumeLabels(project, key)
+	for _, label := range slices.Sorted(maps.Keys(labels)) {
+		args = append(args, "--label", label+"="+labels[label])
+	}
+	create := e.runtimeCommand(ctx, append(args, name)...)
+	var stderr bytes.Buffer
+	create.Stderr = &stderr
+	if err := create.Run(); err != nil {
+		return commandError(e.runtime+" volume create", err, stderr.String())
+	}
+
+	cmd := e.runtimeCommand(ctx, "run", "--rm", "-i", "-v", name+":"+volumeMountPoint,
+		volumeHelperImage, "tar", "-C", volumeMountPoint, "-xf", "-")
+	stderr.Reset()
+	cmd.Stderr = &stderr
+	cmd.Stdin = r
+	if err := cmd.Run(); err != nil {
+		return commandError(e.runtime+" run", err, stderr.String())
+	}
+	return nil
+}
+
+func rewriteTar(r io.Reader, w io.Writer, rename func(string) string) error {
+	reader := tar.NewReader(r)
+	writer := tar.NewWriter(w)
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read archive: %w", err)
+		}
+
+		header.Name = rename(header.Name)
+		if header.Name == "" || header.Name == "." {
+			continue
+		}
+		if err := writer.WriteHeader(header); err != nil {
+			return fmt.Errorf("failed to write archive: %w", err)
+		}
+		if _, err := io.Copy(writer, reader); err != nil {
+			return fmt.Errorf("failed to write archive: %w", err)
+		}
+	}
+	return writer.Close()
+}
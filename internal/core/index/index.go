@@ -7,6 +7,8 @@ import (
 	"io/fs"
 	"os"
 	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
@@ -18,6 +20,7 @@ import (
 	"gopkg.in/yaml.v3"
 
 	pkg "github.com/LoriKarikari/compak/internal/core/package"
+	"github.com/LoriKarikari/compak/internal/core/trust"
 )
 
 const (
@@ -31,13 +34,13 @@ type Index struct {
 }
 
 type PakMetadata struct {
-	Name        string `yaml:"name" validate:"required,alphanum|contains=-|contains=_"`
-	Version     string `yaml:"version" validate:"required"`
-	Description string `yaml:"description" validate:"required,max=500"`
-	Author      string `yaml:"author" validate:"required"`
-	Homepage    string `yaml:"homepage" validate:"omitempty,url"`
-	Repository  string `yaml:"repository" validate:"omitempty,url"`
-	Source      string `yaml:"source" validate:"required,url"`
+	Name        string      `yaml:"name" validate:"required,alphanum|contains=-|contains=_"`
+	Version     string      `yaml:"version" validate:"required"`
+	Description string      `yaml:"description" validate:"required,max=500"`
+	Author      string      `yaml:"author" validate:"required"`
+	Homepage    string      `yaml:"homepage" validate:"omitempty,url"`
+	Repository  string      `yaml:"repository" validate:"omitempty,url"`
+	Source      pkg.Sources `yaml:"source" validate:"required,dive,url"`
 }
 
 type PakVersion struct {
@@ -58,14 +61,16 @@ type SearchResult struct {
 }
 
 type Client struct {
-	repoURL    string `validate:"required,url,startswith=https://"`
-	repoPath   string `validate:"required,dirpath"`
-	paksSubdir string `validate:"required"`
-	cache      *Index
-	validator  *validator.Validate
+	repoURL           string `validate:"required,url,startswith=https://"`
+	repoPath          string `validate:"required,dirpath"`
+	paksSubdir        string `validate:"required"`
+	cache             *Index
+	validator         *validator.Validate
+	trust             *trust.Store
+	requireSignatures bool
 }
 
-func NewClient() *Client {
+func NewClient(trustStore *trust.Store) *Client {
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
 		homeDir = "."
@@ -82,14 +87,22 @@ func NewClient() *Client {
 		paksSubdir = defaultPaksSubdir
 	}
 
+	requireSignatures, _ := strconv.ParseBool(os.Getenv("COMPAK_REQUIRE_SIGNATURES"))
+
 	return &Client{
-		repoURL:    repoURL,
-		repoPath:   repoPath,
-		paksSubdir: paksSubdir,
-		validator:  validator.New(),
+		repoURL:           repoURL,
+		repoPath:          repoPath,
+		paksSubdir:        paksSubdir,
+		validator:         validator.New(),
+		trust:             trustStore,
+		requireSignatures: requireSignatures,
 	}
 }
 
+func (c *Client) RequireSignatures(require bool) {
+	c.requireSignatures = c.requireSignatures || require
+}
+
 func (c *Client) Search(ctx context.Context, query string, limit int) ([]SearchResult, error) {
 	if err := c.updateIndex(ctx); err != nil {
 		return nil, fmt.Errorf("failed to update index: %w", err)
@@ -113,7 +126,7 @@ func (c *Client) Search(ctx context.Context, query string, limit int) ([]SearchR
 			Description: pak.Description,
 			Author:      pak.Author,
 			Homepage:    pak.Homepage,
-			Source:      pak.Source,
+			Source:      pak.Source.String(),
 		}, true
 	})
 
@@ -309,10 +322,13 @@ func (c *Client) LoadPackageFromIndex(ctx context.Context, name string) (data []
 		return nil, fmt.Errorf("failed to get relative path: %w", err)
 	}
 
-	file, err := root.Open(relPath)
+	data, err = root.ReadFile(relPath)
 	if err != nil {
 		if strings.Contains(name, "@") {
-			if extracted, extractErr := c.extractVersionFromHistory(name); extractErr == nil {
+			if extracted, signature, extractErr := c.extractVersionFromHistory(name); extractErr == nil {
+				if err := c.verifyPackage(name, extracted, signature); err != nil {
+					return nil, err
+				}
 				return extracted, nil
 			}
 			baseName := strings.Split(name, "@")[0]
@@ -320,27 +336,60 @@ func (c *Client) LoadPackageFromIndex(ctx context.Context, name string) (data []
 		}
 		return nil, fmt.Errorf("package %s not found in index", name)
 	}
-	defer func() {
-		if closeErr := file.Close(); closeErr != nil && err == nil {
-			err = closeErr
-		}
-	}()
 
-	data, err = io.ReadAll(file)
+	signature, err := root.ReadFile(relPath + signatureExt)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read signature: %w", err)
+	}
+
+	if err := c.verifyPackage(name, data, signature); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+func (c *Client) Revision() (string, error) {
+	repo, err := git.PlainOpen(c.repoPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to open git repo: %w", err)
+	}
+
+	ref, err := repo.Head()
+	if err != nil {
+		return "", fmt.Errorf("failed to get HEAD: %w", err)
+	}
+	return ref.Hash().String(), nil
+}
+
+func (c *Client) LoadPackageAtRevision(ctx context.Context, name, version, revision string) ([]byte, error) {
+	if err := c.ensureRepo(ctx); err != nil {
+		return nil, fmt.Errorf("failed to ensure repo: %w", err)
+	}
+
+	repo, err := git.PlainOpen(c.repoPath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read package: %w", err)
+		return nil, fmt.Errorf("failed to open git repo: %w", err)
+	}
+
+	if _, err := repo.CommitObject(plumbing.NewHash(revision)); err != nil {
+		return nil, fmt.Errorf("index revision %s not found (run 'compak update'): %w", revision, err)
 	}
 
+	data, signature, err := c.searchHistory(repo, plumbing.NewHash(revision), name, version)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.verifyPackage(name+"@"+version, data, signature); err != nil {
+		return nil, err
+	}
 	return data, nil
 }
 
-func (c *Client) extractVersionFromHistory(nameWithVersion string) ([]byte, error) {
-	parts := strings.Split(nameWithVersion, "@")
-	if len(parts) != 2 {
-		return nil, fmt.Errorf("invalid versioned package name: %s", nameWithVersion)
+func (c *Client) Versions(ctx context.Context, name string) ([]string, error) {
+	if err := c.ensureRepo(ctx); err != nil {
+		return nil, fmt.Errorf("failed to ensure repo: %w", err)
 	}
-	packageName := parts[0]
-	targetVersion := parts[1]
 
 	repo, err := git.PlainOpen(c.repoPath)
 	if err != nil {
@@ -357,6 +406,64 @@ func (c *Client) extractVersionFromHistory(nameWithVersion string) ([]byte, erro
 		return nil, fmt.Errorf("failed to get commit log: %w", err)
 	}
 
+	pakFile := fmt.Sprintf("%s/%s.yaml", c.paksSubdir, name)
+	var versions []string
+	err = commits.ForEach(func(commit *object.Commit) error {
+		file, err := commit.File(pakFile)
+		if err != nil {
+			return nil
+		}
+
+		contents, err := file.Contents()
+		if err != nil {
+			return nil
+		}
+
+		var p pkg.Package
+		if err := yaml.Unmarshal([]byte(contents), &p); err != nil || p.Version == "" {
+			return nil
+		}
+
+		if !slices.Contains(versions, p.Version) {
+			versions = append(versions, p.Version)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to search git history: %w", err)
+	}
+
+	if len(versions) == 0 {
+		return nil, fmt.Errorf("package %s not found in index", name)
+	}
+	return versions, nil
+}
+
+func (c *Client) extractVersionFromHistory(nameWithVersion string) ([]byte, []byte, error) {
+	parts := strings.Split(nameWithVersion, "@")
+	if len(parts) != 2 {
+		return nil, nil, fmt.Errorf("invalid versioned package name: %s", nameWithVersion)
+	}
+
+	repo, err := git.PlainOpen(c.repoPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open git repo: %w", err)
+	}
+
+	ref, err := repo.Head()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get HEAD: %w", err)
+	}
+
+	return c.searchHistory(repo, ref.Hash(), parts[0], parts[1])
+}
+
+func (c *Client) searchHistory(repo *git.Repository, from plumbing.Hash, packageName, targetVersion string) ([]byte, []byte, error) {
+	commits, err := repo.Log(&git.LogOptions{From: from})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get commit log: %w", err)
+	}
+
 	pakFile := fmt.Sprintf("%s/%s.yaml", c.paksSubdir, packageName)
 	var foundCommit *object.Commit
 
@@ -376,7 +483,7 @@ func (c *Client) extractVersionFromHistory(nameWithVersion string) ([]byte, erro
 			return nil
 		}
 
-		if p.Version == targetVersion {
+		if strings.TrimPrefix(p.Version, "v") == strings.TrimPrefix(targetVersion, "v") {
 			foundCommit = commit
 			return fmt.Errorf("found")
 		}
@@ -384,22 +491,31 @@ func (c *Client) extractVersionFromHistory(nameWithVersion string) ([]byte, erro
 		return nil
 	})
 	if searchErr != nil && searchErr.Error() != "found" {
-		return nil, fmt.Errorf("failed to search git history: %w", searchErr)
+		return nil, nil, fmt.Errorf("failed to search git history: %w", searchErr)
 	}
 
 	if foundCommit == nil {
-		return nil, fmt.Errorf("version %s not found in git history", targetVersion)
+		return nil, nil, fmt.Errorf("version %s not found in git history", targetVersion)
 	}
 
 	file, err := foundCommit.File(pakFile)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get file from commit: %w", err)
+		return nil, nil, fmt.Errorf("failed to get file from commit: %w", err)
 	}
 
 	contents, err := file.Contents()
 	if err != nil {
-		return nil, fmt.Errorf("failed to read file contents: %w", err)
+		return nil, nil, fmt.Errorf("failed to read file contents: %w", err)
+	}
+
+	var signature []byte
+	if sigFile, err := foundCommit.File(pakFile + signatureExt); err == nil {
+		sigContents, err := sigFile.Contents()
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read signature contents: %w", err)
+		}
+		signature = []byte(sigContents)
 	}
 
-	return []byte(contents), nil
+	return []byte(contents), signature, nil
 }
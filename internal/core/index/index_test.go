@@ -2,7 +2,17 @@ package index
 
 import (
 	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+
+	"github.com/LoriKarikari/compak/internal/core/trust"
 )
 
 const (
@@ -15,7 +25,7 @@ func TestLoadPackageFromIndexRepo(t *testing.T) {
 		t.Skip(skipIntegrationMsg)
 	}
 
-	client := NewClient()
+	client := NewClient(trust.NewStore(filepath.Join(t.TempDir(), "trust")))
 	ctx := context.Background()
 
 	data, err := client.LoadPackageFromIndex(ctx, "immich")
@@ -33,7 +43,7 @@ func TestLoadPackageFromIndexNotFound(t *testing.T) {
 		t.Skip(skipIntegrationMsg)
 	}
 
-	client := NewClient()
+	client := NewClient(trust.NewStore(filepath.Join(t.TempDir(), "trust")))
 	ctx := context.Background()
 
 	_, err := client.LoadPackageFromIndex(ctx, "nonexistent-package-xyz")
@@ -47,7 +57,7 @@ func TestSearchRealRepo(t *testing.T) {
 		t.Skip(skipIntegrationMsg)
 	}
 
-	client := NewClient()
+	client := NewClient(trust.NewStore(filepath.Join(t.TempDir(), "trust")))
 	ctx := context.Background()
 
 	testSearchAllPackages(ctx, t, client)
@@ -137,3 +147,96 @@ func testSearchWithLimit(ctx context.Context, t *testing.T, client *Client) {
 		t.Errorf("Expected max 1 result due to limit, got %d", len(results))
 	}
 }
+
+func commitPak(t *testing.T, worktree *git.Worktree, repoPath, content string) string {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(repoPath, defaultPaksSubdir, "demo.yaml"), []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write pak: %v", err)
+	}
+	if _, err := worktree.Add(defaultPaksSubdir + "/demo.yaml"); err != nil {
+		t.Fatalf("Failed to stage pak: %v", err)
+	}
+	hash, err := worktree.Commit("update demo", &git.CommitOptions{
+		Author: &object.Signature{Name: "test", Email: "test@example.com", When: time.Now()},
+	})
+	if err != nil {
+		t.Fatalf("Failed to commit: %v", err)
+	}
+	return hash.String()
+}
+
+func TestLoadPackageAtRevision(t *testing.T) {
+	client := newLocalIndex(t, nil)
+	if err := os.RemoveAll(filepath.Join(client.repoPath, ".git")); err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+	repo, err := git.PlainInit(client.repoPath, false)
+	if err != nil {
+		t.Fatalf("Failed to init repo: %v", err)
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		t.Fatalf("Failed to get worktree: %v", err)
+	}
+
+	v1 := "name: demo\nversion: 1.0.0\n"
+	first := commitPak(t, worktree, client.repoPath, v1)
+	second := commitPak(t, worktree, client.repoPath, "name: demo\nversion: 2.0.0\n")
+
+	revision, err := client.Revision()
+	if err != nil || revision != second {
+		t.Errorf("Expected revision %s, got %s (%v)", second, revision, err)
+	}
+
+	ctx := context.Background()
+	for _, rev := range []string{first, second} {
+		data, err := client.LoadPackageAtRevision(ctx, "demo", "1.0.0", rev)
+		if err != nil {
+			t.Fatalf("Expected no error but got: %v", err)
+		}
+		if string(data) != v1 {
+			t.Errorf("Expected version 1.0.0 at %s, got %s", rev, data)
+		}
+	}
+
+	if data, err := client.LoadPackageAtRevision(ctx, "demo", "v1.0.0", second); err != nil || string(data) != v1 {
+		t.Errorf("Expected v1.0.0 to find version 1.0.0, got %s (%v)", data, err)
+	}
+	if _, err := client.LoadPackageAtRevision(ctx, "demo", "2.0.0", first); err == nil {
+		t.Error("Expected error for a version newer than the revision")
+	}
+	if _, err := client.LoadPackageAtRevision(ctx, "demo", "1.0.0", strings.Repeat("0", 40)); err == nil || !strings.Contains(err.Error(), "compak update") {
+		t.Errorf("Expected error for an unknown revision, got %v", err)
+	}
+}
+
+func TestVersions(t *testing.T) {
+	client := newLocalIndex(t, nil)
+	if err := os.RemoveAll(filepath.Join(client.repoPath, ".git")); err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+	repo, err := git.PlainInit(client.repoPath, false)
+	if err != nil {
+		t.Fatalf("Failed to init repo: %v", err)
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		t.Fatalf("Failed to get worktree: %v", err)
+	}
+
+	for _, version := range []string{"1.0.0", "1.1.0", "2.0.0", "1.1.0"} {
+		commitPak(t, worktree, client.repoPath, "name: demo\nversion: "+version+"\n")
+	}
+
+	versions, err := client.Versions(context.Background(), "demo")
+	if err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+	if expected := []string{"1.1.0", "2.0.0", "1.0.0"}; !reflect.DeepEqual(versions, expected) {
+		t.Errorf("Expected versions %v, got %v", expected, versions)
+	}
+
+	if _, err := client.Versions(context.Background(), "missing"); err == nil {
+		t.Error("Expected error for a package that is not in the index")
+	}
+}
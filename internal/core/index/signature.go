@@ -0,0 +1,43 @@
+package index
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/LoriKarikari/compak/internal/core/trust"
+)
+
+const signatureExt = ".sig"
+
+func (c *Client) verifyPackage(name string, data, signature []byte) error {
+	if len(signature) == 0 {
+		return c.verifyUnsigned(name)
+	}
+
+	key, err := c.trust.Verify(data, signature)
+	switch {
+	case errors.Is(err, trust.ErrNoTrustedKeys) && !c.requireSignatures:
+		fmt.Printf("Warning: %s is signed but no trusted keys are configured in %s; signature not verified\n", name, c.trust.Dir())
+		return nil
+	case err != nil:
+		return fmt.Errorf("signature verification failed for %s: %w", name, err)
+	}
+
+	fmt.Printf("Verified signature for %s (key %s)\n", name, key)
+	return nil
+}
+
+func (c *Client) verifyUnsigned(name string) error {
+	if c.requireSignatures {
+		return fmt.Errorf("package %s is not signed and signatures are required", name)
+	}
+
+	keys, err := c.trust.Keys()
+	if err != nil {
+		return err
+	}
+	if len(keys) > 0 {
+		return fmt.Errorf("package %s is not signed but trusted keys are configured in %s", name, c.trust.Dir())
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package index
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+
+	"github.com/LoriKarikari/compak/internal/core/trust"
+)
+
+func newLocalIndex(t *testing.T, files map[string][]byte) *Client {
+	t.Helper()
+	repoPath := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(repoPath, ".git"), 0o750); err != nil {
+		t.Fatalf("Failed to create repo: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Join(repoPath, defaultPaksSubdir), 0o750); err != nil {
+		t.Fatalf("Failed to create paks dir: %v", err)
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(repoPath, defaultPaksSubdir, name), data, 0o600); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	return &Client{
+		repoPath:   repoPath,
+		paksSubdir: defaultPaksSubdir,
+		trust:      trust.NewStore(filepath.Join(t.TempDir(), "trust")),
+	}
+}
+
+func TestLoadPackageFromIndex_Signatures(t *testing.T) {
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	signer, err := ssh.NewSignerFromKey(privateKey)
+	if err != nil {
+		t.Fatalf("Failed to create signer: %v", err)
+	}
+
+	pak := []byte("name: signed\nversion: 1.0.0\nsource: https://example.com/compose.yaml\nsourceDigest: sha256:0000000000000000000000000000000000000000000000000000000000000000\n")
+	sig, err := trust.Sign(signer, pak)
+	if err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+	tampered := append([]byte{}, pak...)
+	tampered[len(tampered)-2] = '1'
+
+	files := map[string][]byte{
+		"signed.yaml":       pak,
+		"signed.yaml.sig":   sig,
+		"tampered.yaml":     tampered,
+		"tampered.yaml.sig": sig,
+		"unsigned.yaml":     []byte("name: unsigned\nversion: 1.0.0\n"),
+	}
+
+	tests := []struct {
+		name        string
+		pak         string
+		trustKey    bool
+		require     bool
+		expectError bool
+	}{
+		{name: "signed and trusted", pak: "signed", trustKey: true},
+		{name: "signed and trusted with policy", pak: "signed", trustKey: true, require: true},
+		{name: "signed without trusted keys", pak: "signed"},
+		{name: "signed without trusted keys with policy", pak: "signed", require: true, expectError: true},
+		{name: "tampered", pak: "tampered", trustKey: true, expectError: true},
+		{name: "unsigned", pak: "unsigned"},
+		{name: "unsigned with trusted keys", pak: "unsigned", trustKey: true, expectError: true},
+		{name: "unsigned with policy", pak: "unsigned", trustKey: true, require: true, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newLocalIndex(t, files)
+			client.RequireSignatures(tt.require)
+			if tt.trustKey {
+				if _, err := client.trust.Add("index", ssh.MarshalAuthorizedKey(signer.PublicKey())); err != nil {
+					t.Fatalf("Failed to trust key: %v", err)
+				}
+			}
+
+			data, err := client.LoadPackageFromIndex(context.Background(), tt.pak)
+			if tt.expectError {
+				if err == nil {
+					t.Error("Expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error but got: %v", err)
+			}
+			if string(data) != string(files[tt.pak+".yaml"]) {
+				t.Errorf("Unexpected package data: %s", data)
+			}
+		})
+	}
+}
@@ -0,0 +1,422 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/compose-spec/compose-go/v2/dotenv"
+	"github.com/compose-spec/compose-go/v2/loader"
+	composetemplate "github.com/compose-spec/compose-go/v2/template"
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/docker/compose/v2/pkg/api"
+	"gopkg.in/yaml.v3"
+
+	"github.com/LoriKarikari/compak/internal/core/compose"
+	"github.com/LoriKarikari/compak/internal/core/template"
+)
+
+const (
+	adoptedVersion     = "0.0.0"
+	adoptOverlayName   = "adopt.yaml"
+	composeEnvPrefix   = "COMPOSE_"
+	composeProjectName = "COMPOSE_PROJECT_NAME"
+)
+
+type AdoptOptions struct {
+	Name         string
+	ProjectName  string
+	ComposeFiles []string
+	Candidate    *Package
+	ForceMatch   bool
+}
+
+type adoption struct {
+	dir     string
+	files   []string
+	values  map[string]string
+	current *types.Project
+	pkg     Package
+	pak     string
+	overlay *Overlay
+	pinned  []string
+}
+
+func (m *Manager) Adopt(ctx context.Context, dir string, opts AdoptOptions) (InstalledPackage, error) {
+	if err := validatePackageName(opts.Name); err != nil {
+		return InstalledPackage{}, fmt.Errorf("invalid package name: %w", err)
+	}
+	if _, err := m.client.GetInstalledPackage(opts.Name); err == nil {
+		return InstalledPackage{}, fmt.Errorf("package %s is already installed", opts.Name)
+	}
+
+	packageDir := filepath.Join(m.packagesDir, opts.Name)
+	if _, err := os.Stat(packageDir); err == nil {
+		return InstalledPackage{}, fmt.Errorf("package directory %s already exists", packageDir)
+	}
+
+	a, err := m.planAdoption(dir, opts)
+	if err != nil {
+		return InstalledPackage{}, err
+	}
+
+	installedPkg, project, err := m.stageAdoption(a, packageDir)
+	if err == nil {
+		err = m.switchProject(ctx, a.current, project)
+	}
+	if err != nil {
+		_ = os.RemoveAll(packageDir)
+		return InstalledPackage{}, err
+	}
+
+	if err := m.client.install(installedPkg); err != nil {
+		return InstalledPackage{}, fmt.Errorf("%s now runs from %s but could not be recorded: %w", project.Name, packageDir, err)
+	}
+	return m.client.GetInstalledPackage(a.pkg.Name)
+}
+
+func (m *Manager) planAdoption(dir string, opts AdoptOptions) (*adoption, error) {
+	files := opts.ComposeFiles
+	if len(files) == 0 {
+		found, err := compose.FindComposeFiles(dir)
+		if err != nil {
+			return nil, err
+		}
+		files = found
+	}
+
+	env, err := readEnv(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	name := opts.ProjectName
+	if name == "" {
+		name = existingProjectName(dir, files, env)
+	}
+	current, err := m.loadProject(dir, name, files...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load compose project: %w", err)
+	}
+
+	params, err := inferParameters(dir, files, env)
+	if err != nil {
+		return nil, err
+	}
+
+	a := &adoption{dir: dir, files: files, current: current, values: make(map[string]string)}
+	for key, value := range env {
+		if !strings.HasPrefix(key, composeEnvPrefix) {
+			a.values[key] = value
+		}
+	}
+	a.pkg, a.pak = adoptedPackage(opts, params)
+	if err := a.pinMounts(); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+func (m *Manager) stageAdoption(a *adoption, packageDir string) (InstalledPackage, *types.Project, error) {
+	if err := copyAdoptedFiles(a.dir, packageDir, a.pinned); err != nil {
+		return InstalledPackage{}, nil, fmt.Errorf("failed to copy project files: %w", err)
+	}
+	if err := template.NewEngine(a.values).WriteEnvFile(packageDir); err != nil {
+		return InstalledPackage{}, nil, fmt.Errorf("failed to write env file: %w", err)
+	}
+
+	var overlays []Overlay
+	if a.overlay != nil {
+		overlays = append(overlays, *a.overlay)
+	}
+	overlayFiles, err := writeOverlays(packageDir, overlays)
+	if err != nil {
+		return InstalledPackage{}, nil, err
+	}
+	composeFiles := append(slices.Clone(a.files), overlayFiles...)
+
+	project, err := m.loadProject(packageDir, projectName(a.pkg.Name), composeFiles...)
+	if err != nil {
+		return InstalledPackage{}, nil, fmt.Errorf("failed to load adopted project: %w", err)
+	}
+	ports, err := publishedPorts(project)
+	if err != nil {
+		return InstalledPackage{}, nil, err
+	}
+	checksums, err := fileChecksums(packageDir, composeFiles)
+	if err != nil {
+		return InstalledPackage{}, nil, err
+	}
+
+	return InstalledPackage{
+		Package:      a.pkg,
+		Pak:          a.pak,
+		Values:       a.values,
+		Overlays:     overlays,
+		ComposeFiles: composeFiles,
+		Ports:        ports,
+		Checksums:    checksums,
+	}, project, nil
+}
+
+func (m *Manager) switchProject(ctx context.Context, current, project *types.Project) error {
+	if current.Name == project.Name {
+		fmt.Printf("Recreating %s from the package directory...\n", project.Name)
+		if err := m.engine.Up(ctx, project, true, nil); err != nil {
+			return fmt.Errorf("failed to start services: %w", err)
+		}
+		return nil
+	}
+
+	containers, err := m.engine.PS(ctx, current)
+	if err != nil {
+		return fmt.Errorf("failed to get containers of %s: %w", current.Name, err)
+	}
+	running := len(containers) > 0
+	overlap := canOverlap(project)
+
+	if running && !overlap {
+		fmt.Printf("Stopping %s (shared ports or data prevent a zero-downtime switch)...\n", current.Name)
+		if err := m.engine.Down(ctx, current, api.DownOptions{}); err != nil {
+			return fmt.Errorf("failed to stop %s: %w", current.Name, err)
+		}
+	}
+
+	fmt.Printf("Starting %s...\n", project.Name)
+	if err := m.engine.Up(ctx, project, true, nil); err != nil {
+		err = fmt.Errorf("failed to start services: %w", err)
+		if running && !overlap {
+			err = errors.Join(err, m.engine.Up(ctx, current, true, nil))
+		}
+		return err
+	}
+
+	if running && overlap {
+		fmt.Printf("Removing %s...\n", current.Name)
+		if err := m.engine.Down(ctx, current, api.DownOptions{}); err != nil {
+			return fmt.Errorf("failed to remove %s: %w", current.Name, err)
+		}
+	}
+	return nil
+}
+
+func canOverlap(project *types.Project) bool {
+	for _, service := range project.Services {
+		if service.ContainerName != "" {
+			return false
+		}
+		for _, port := range service.Ports {
+			if port.Published != "" {
+				return false
+			}
+		}
+		for _, volume := range service.Volumes {
+			if volume.Type == types.VolumeTypeVolume || volume.Type == types.VolumeTypeBind {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func readEnv(dir string) (map[string]string, error) {
+	path := filepath.Join(dir, envFile)
+	if _, err := os.Stat(path); errors.Is(err, fs.ErrNotExist) {
+		return map[string]string{}, nil
+	}
+	env, err := dotenv.Read(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", envFile, err)
+	}
+	return env, nil
+}
+
+func existingProjectName(dir string, files []string, env map[string]string) string {
+	if name := env[composeProjectName]; name != "" {
+		return loader.NormalizeProjectName(name)
+	}
+
+	var name string
+	for _, file := range files {
+		data, err := os.ReadFile(filepath.Join(dir, file))
+		if err != nil {
+			continue
+		}
+		var header struct {
+			Name string `yaml:"name"`
+		}
+		if yaml.Unmarshal(data, &header) == nil && header.Name != "" {
+			name = header.Name
+		}
+	}
+	if name == "" {
+		name = filepath.Base(dir)
+	}
+	return loader.NormalizeProjectName(name)
+}
+
+func inferParameters(dir string, files []string, env map[string]string) (map[string]Param, error) {
+	params := make(map[string]Param)
+	for _, file := range files {
+		data, err := os.ReadFile(filepath.Join(dir, file))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", file, err)
+		}
+		var dict map[string]any
+		if err := yaml.Unmarshal(data, &dict); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", file, err)
+		}
+
+		for name, variable := range composetemplate.ExtractVariables(dict, composetemplate.DefaultPattern) {
+			if _, seen := params[name]; seen || strings.HasPrefix(name, composeEnvPrefix) {
+				continue
+			}
+			params[name] = Param{
+				Description: "Inferred from " + file,
+				Type:        "string",
+				Default:     variable.DefaultValue,
+				Required:    variable.Required,
+			}
+		}
+	}
+
+	for name := range env {
+		if _, seen := params[name]; !seen && !strings.HasPrefix(name, composeEnvPrefix) {
+			params[name] = Param{Description: "Set in " + envFile, Type: "string"}
+		}
+	}
+	return params, nil
+}
+
+func adoptedPackage(opts AdoptOptions, params map[string]Param) (Package, string) {
+	if opts.Candidate != nil && (opts.ForceMatch || MatchesParameters(*opts.Candidate, params)) {
+		adopted := *opts.Candidate
+		adopted.Name = opts.Name
+		adopted.Parameters = maps.Clone(adopted.Parameters)
+		if adopted.Parameters == nil {
+			adopted.Parameters = make(map[string]Param)
+		}
+		for name, param := range params {
+			if _, ok := adopted.Parameters[name]; !ok {
+				adopted.Parameters[name] = param
+			}
+		}
+		if opts.Candidate.Name == opts.Name {
+			return adopted, ""
+		}
+		return adopted, opts.Candidate.Name
+	}
+
+	return Package{
+		Name:        opts.Name,
+		Version:     adoptedVersion,
+		Description: "Adopted compose project",
+		Parameters:  params,
+	}, ""
+}
+
+func MatchesParameters(candidate Package, params map[string]Param) bool {
+	for name := range params {
+		if _, ok := candidate.Parameters[name]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func (a *adoption) pinMounts() error {
+	overlay := make(map[string]any)
+
+	volumes := make(map[string]any)
+	for _, key := range slices.Sorted(maps.Keys(a.current.Volumes)) {
+		if a.current.Volumes[key].External {
+			continue
+		}
+		name, err := compose.VolumeName(a.current, key)
+		if err != nil {
+			return err
+		}
+		volumes[key] = map[string]any{"name": name}
+	}
+	if len(volumes) > 0 {
+		overlay["volumes"] = volumes
+	}
+
+	services := make(map[string]any)
+	for _, name := range a.current.ServiceNames() {
+		if mounts := a.pinnedBinds(a.current.Services[name]); len(mounts) > 0 {
+			services[name] = map[string]any{"volumes": mounts}
+		}
+	}
+	if len(services) > 0 {
+		overlay["services"] = services
+	}
+
+	if len(overlay) == 0 {
+		return nil
+	}
+	content, err := yaml.Marshal(overlay)
+	if err != nil {
+		return fmt.Errorf("failed to generate adoption overlay: %w", err)
+	}
+	a.overlay = &Overlay{Name: adoptOverlayName, Content: string(content)}
+	return nil
+}
+
+func (a *adoption) pinnedBinds(service types.ServiceConfig) []map[string]any {
+	var mounts []map[string]any
+	for _, volume := range service.Volumes {
+		if volume.Type != types.VolumeTypeBind {
+			continue
+		}
+		rel, err := filepath.Rel(a.dir, volume.Source)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		if info, err := os.Stat(volume.Source); err == nil && !info.IsDir() {
+			continue
+		}
+
+		mounts = append(mounts, map[string]any{"type": types.VolumeTypeBind, "source": volume.Source, "target": volume.Target})
+		if rel != "." && !slices.Contains(a.pinned, volume.Source) {
+			a.pinned = append(a.pinned, volume.Source)
+		}
+	}
+	return mounts
+}
+
+func copyAdoptedFiles(src, dst string, skip []string) error {
+	return filepath.WalkDir(src, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+
+		switch {
+		case entry.IsDir() && (entry.Name() == ".git" || slices.Contains(skip, path)):
+			return filepath.SkipDir
+		case entry.IsDir():
+			return os.MkdirAll(target, 0o750)
+		case entry.Type()&fs.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		case entry.Type().IsRegular():
+			return copyFile(path, target)
+		default:
+			return nil
+		}
+	})
+}
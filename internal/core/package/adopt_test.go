@@ -0,0 +1,270 @@
+package pkg
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/compose-spec/compose-go/v2/types"
+
+	"github.com/LoriKarikari/compak/internal/core/compose"
+	"github.com/LoriKarikari/compak/internal/core/compose/composetest"
+)
+
+const adoptComposeFile = `services:
+  web:
+    image: nginx:alpine
+    ports:
+      - "${PORT:-8080}:80"
+    volumes:
+      - ./data:/usr/share/nginx/html
+      - ./nginx.conf:/etc/nginx/conf.d/default.conf:ro
+      - cache:/var/cache/nginx
+volumes:
+  cache: {}
+`
+
+func writeAdoptProject(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := filepath.Join(t.TempDir(), "My App")
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+			t.Fatalf("Expected no error but got: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("Expected no error but got: %v", err)
+		}
+	}
+	return dir
+}
+
+func TestManagerAdopt(t *testing.T) {
+	dir := writeAdoptProject(t, map[string]string{
+		testComposeFilename:  adoptComposeFile,
+		".env":               "PORT=9090\nCOMPOSE_PROJECT_NAME=legacy\n",
+		"nginx.conf":         "server {}\n",
+		"data/index.html":    "hello\n",
+		".git/HEAD":          "ref: refs/heads/main\n",
+		"scripts/backup.sh":  "#!/bin/sh\n",
+		"data/assets/app.js": "\n",
+	})
+
+	ctx := context.Background()
+	engine := composetest.NewEngine()
+	legacy, err := compose.LoadProject(dir, "legacy", testComposeFilename)
+	if err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+	if err := engine.Up(ctx, legacy, true, nil); err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+
+	stateDir := t.TempDir()
+	manager := NewManager(NewClient(stateDir), engine, stateDir)
+	installed, err := manager.Adopt(ctx, dir, AdoptOptions{Name: "web"})
+	if err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+
+	calls := engine.Calls()
+	var switchover []composetest.Call
+	for _, call := range calls[1:] {
+		if call.Method == composetest.MethodUp || call.Method == composetest.MethodDown {
+			switchover = append(switchover, call)
+		}
+	}
+	expected := []composetest.Call{{Method: composetest.MethodDown, Project: "legacy"}, {Method: composetest.MethodUp, Project: "compak-web"}}
+	if !reflect.DeepEqual(switchover, expected) {
+		t.Errorf("Expected the old project to be stopped before the new one starts, got %v", switchover)
+	}
+	if engine.Running("legacy") || !engine.Running("compak-web") {
+		t.Error("Expected only compak-web to be running")
+	}
+
+	if installed.Package.Version != adoptedVersion || installed.Status != "installed" {
+		t.Errorf("Unexpected installed package: %+v", installed)
+	}
+	if param := installed.Package.Parameters["PORT"]; param.Default != "8080" {
+		t.Errorf("Expected PORT parameter with default 8080, got %+v", param)
+	}
+	if !reflect.DeepEqual(installed.Values, map[string]string{"PORT": "9090"}) {
+		t.Errorf("Expected values from .env without compose settings, got %v", installed.Values)
+	}
+
+	packageDir := filepath.Join(stateDir, "packages", "web")
+	for _, name := range []string{"nginx.conf", "scripts/backup.sh"} {
+		if _, err := os.Stat(filepath.Join(packageDir, name)); err != nil {
+			t.Errorf("Expected %s to be copied: %v", name, err)
+		}
+	}
+	for _, name := range []string{"data", ".git"} {
+		if _, err := os.Stat(filepath.Join(packageDir, name)); !os.IsNotExist(err) {
+			t.Errorf("Expected %s not to be copied", name)
+		}
+	}
+
+	project := engine.Project("compak-web")
+	if name, _ := compose.VolumeName(project, "cache"); name != "legacy_cache" {
+		t.Errorf("Expected the existing volume to be kept, got %s", name)
+	}
+	for _, volume := range project.Services["web"].Volumes {
+		if volume.Target == "/usr/share/nginx/html" && volume.Source != filepath.Join(dir, "data") {
+			t.Errorf("Expected data to stay bind-mounted from %s, got %s", dir, volume.Source)
+		}
+	}
+
+	report, err := manager.Diff(ctx, "web")
+	if err != nil || !report.Clean() {
+		t.Errorf("Expected no drift after adopting, got %v (%v)", report.Drifts, err)
+	}
+
+	if _, err := manager.Adopt(ctx, dir, AdoptOptions{Name: "web"}); err == nil {
+		t.Error("Expected error adopting an installed package")
+	}
+}
+
+func TestManagerAdoptWithoutDowntime(t *testing.T) {
+	dir := writeAdoptProject(t, map[string]string{
+		testComposeFilename: "name: worker\nservices:\n  job:\n    image: busybox\n    command: sleep infinity\n",
+	})
+
+	ctx := context.Background()
+	engine := composetest.NewEngine()
+	existing, err := compose.LoadProject(dir, "worker", testComposeFilename)
+	if err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+	if err := engine.Up(ctx, existing, true, nil); err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+
+	stateDir := t.TempDir()
+	manager := NewManager(NewClient(stateDir), engine, stateDir)
+	if _, err := manager.Adopt(ctx, dir, AdoptOptions{Name: "jobs"}); err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+
+	var switchover []composetest.Call
+	for _, call := range engine.Calls()[1:] {
+		if call.Method == composetest.MethodUp || call.Method == composetest.MethodDown {
+			switchover = append(switchover, call)
+		}
+	}
+	expected := []composetest.Call{{Method: composetest.MethodUp, Project: "compak-jobs"}, {Method: composetest.MethodDown, Project: "worker"}}
+	if !reflect.DeepEqual(switchover, expected) {
+		t.Errorf("Expected the new project to start before the old one is removed, got %v", switchover)
+	}
+}
+
+func TestExistingProjectName(t *testing.T) {
+	tests := []struct {
+		name     string
+		compose  string
+		env      map[string]string
+		expected string
+	}{
+		{name: "directory name", compose: "services: {}\n", expected: "myapp"},
+		{name: "name attribute", compose: "name: Blog\nservices: {}\n", expected: "blog"},
+		{name: "env override", compose: "name: blog\nservices: {}\n", env: map[string]string{composeProjectName: "prod"}, expected: "prod"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := writeAdoptProject(t, map[string]string{testComposeFilename: tt.compose})
+			if got := existingProjectName(dir, []string{testComposeFilename}, tt.env); got != tt.expected {
+				t.Errorf("Expected project name %s, got %s", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestAdoptedPackage(t *testing.T) {
+	params := map[string]Param{"PORT": {Type: "string", Default: "8080"}}
+	candidate := &Package{Name: "nginx", Version: "1.2.0", Parameters: map[string]Param{"PORT": {Type: "port", Default: "80"}}}
+
+	tests := []struct {
+		name     string
+		opts     AdoptOptions
+		expected string
+		pak      string
+	}{
+		{name: "no candidate", opts: AdoptOptions{Name: "web"}, expected: adoptedVersion},
+		{name: "matching candidate", opts: AdoptOptions{Name: "web", Candidate: candidate}, expected: "1.2.0", pak: "nginx"},
+		{
+			name:     "mismatched candidate",
+			opts:     AdoptOptions{Name: "web", Candidate: &Package{Name: "nginx", Version: "1.2.0"}},
+			expected: adoptedVersion,
+		},
+		{
+			name:     "forced candidate",
+			opts:     AdoptOptions{Name: "web", Candidate: &Package{Name: "nginx", Version: "1.2.0"}, ForceMatch: true},
+			expected: "1.2.0",
+			pak:      "nginx",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			adopted, pak := adoptedPackage(tt.opts, params)
+			if adopted.Name != "web" || adopted.Version != tt.expected || pak != tt.pak {
+				t.Errorf("Expected web@%s from %q, got %s@%s from %q", tt.expected, tt.pak, adopted.Name, adopted.Version, pak)
+			}
+			if _, ok := adopted.Parameters["PORT"]; !ok {
+				t.Errorf("Expected PORT parameter, got %v", adopted.Parameters)
+			}
+		})
+	}
+
+	if candidate.Parameters["PORT"].Type != "port" {
+		t.Error("Expected candidate parameters to be left untouched")
+	}
+}
+
+func TestCanOverlap(t *testing.T) {
+	tests := []struct {
+		name     string
+		service  types.ServiceConfig
+		expected bool
+	}{
+		{name: "stateless", service: types.ServiceConfig{Name: "job"}, expected: true},
+		{name: "published port", service: types.ServiceConfig{Name: "web", Ports: []types.ServicePortConfig{{Target: 80, Published: "8080"}}}},
+		{name: "container name", service: types.ServiceConfig{Name: "web", ContainerName: "web"}},
+		{name: "named volume", service: types.ServiceConfig{Name: "db", Volumes: []types.ServiceVolumeConfig{{Type: types.VolumeTypeVolume, Source: "data", Target: "/data"}}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			project := &types.Project{Services: types.Services{tt.service.Name: tt.service}}
+			if got := canOverlap(project); got != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestManagerAdoptKeepsFilesAfterSwitch(t *testing.T) {
+	dir := writeAdoptProject(t, map[string]string{
+		testComposeFilename: "services:\n  web:\n    image: nginx:alpine\n",
+	})
+
+	ctx := context.Background()
+	engine := composetest.NewEngine()
+	stateDir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(stateDir, "installed.json"), 0o750); err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+
+	manager := NewManager(NewClient(stateDir), engine, stateDir)
+	if _, err := manager.Adopt(ctx, dir, AdoptOptions{Name: "web"}); err == nil {
+		t.Fatal("Expected error when the package cannot be recorded")
+	}
+	if !engine.Running("compak-web") {
+		t.Error("Expected compak-web to keep running")
+	}
+	if _, err := os.Stat(filepath.Join(stateDir, "packages", "web", testComposeFilename)); err != nil {
+		t.Errorf("Expected the files compak-web runs from to be kept: %v", err)
+	}
+}
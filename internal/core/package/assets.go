@@ -0,0 +1,103 @@
+package pkg
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func validateAssets(assets []Asset) error {
+	seen := make(map[string]bool, len(assets))
+	for _, asset := range assets {
+		if asset.URL == "" {
+			return fmt.Errorf("asset %s has no url", asset.Path)
+		}
+		if err := validateAssetPath(asset.Path); err != nil {
+			return err
+		}
+		if !isSHA256Hex(asset.SHA256) {
+			return fmt.Errorf("asset %s must declare a sha256 checksum (64 hex characters)", asset.Path)
+		}
+
+		clean := filepath.Clean(asset.Path)
+		if seen[clean] {
+			return fmt.Errorf("asset path %s declared more than once", asset.Path)
+		}
+		seen[clean] = true
+	}
+	return nil
+}
+
+func validateAssetPath(path string) error {
+	if path == "" {
+		return fmt.Errorf("asset path cannot be empty")
+	}
+	if filepath.IsAbs(path) {
+		return fmt.Errorf("asset path %s must be relative to the package directory", path)
+	}
+	if err := validatePath(path); err != nil {
+		return fmt.Errorf("invalid asset path %s: %w", path, err)
+	}
+	return nil
+}
+
+func downloadAssets(assets []Asset, packageDir string) (err error) {
+	if len(assets) == 0 {
+		return nil
+	}
+
+	root, err := os.OpenRoot(packageDir)
+	if err != nil {
+		return fmt.Errorf("failed to open package directory: %w", err)
+	}
+	defer func() {
+		if closeErr := root.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}()
+
+	for _, asset := range assets {
+		fmt.Printf("Downloading asset %s...\n", asset.Path)
+
+		data, err := fetchURL(asset.URL)
+		if err != nil {
+			return fmt.Errorf("failed to download asset %s: %w", asset.Path, err)
+		}
+
+		if err := verifySHA256(data, asset.SHA256); err != nil {
+			return fmt.Errorf("asset %s: %w", asset.Path, err)
+		}
+
+		if dir := filepath.Dir(asset.Path); dir != "." {
+			if err := root.MkdirAll(dir, 0o750); err != nil {
+				return fmt.Errorf("failed to create directory for asset %s: %w", asset.Path, err)
+			}
+		}
+
+		if err := root.WriteFile(asset.Path, data, 0o600); err != nil {
+			return fmt.Errorf("failed to write asset %s: %w", asset.Path, err)
+		}
+	}
+
+	return nil
+}
+
+func verifySHA256(data []byte, expected string) error {
+	sum := sha256.Sum256(data)
+	actual := hex.EncodeToString(sum[:])
+	if !strings.EqualFold(actual, expected) {
+		return fmt.Errorf("checksum mismatch: expected sha256 %s, got %s", strings.ToLower(expected), actual)
+	}
+	return nil
+}
+
+func isSHA256Hex(value string) bool {
+	if len(value) != sha256.Size*2 {
+		return false
+	}
+	_, err := hex.DecodeString(value)
+	return err == nil
+}
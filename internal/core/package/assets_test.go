@@ -0,0 +1,135 @@
+package pkg
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func sha256Hex(data string) string {
+	sum := sha256.Sum256([]byte(data))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestValidateAssets(t *testing.T) {
+	validSum := sha256Hex("content")
+
+	tests := []struct {
+		name      string
+		assets    []Asset
+		expectErr bool
+	}{
+		{name: "valid", assets: []Asset{{URL: "https://example.com/nginx.conf", Path: "config/nginx.conf", SHA256: validSum}}},
+		{name: "missing url", assets: []Asset{{Path: "nginx.conf", SHA256: validSum}}, expectErr: true},
+		{name: "missing checksum", assets: []Asset{{URL: "https://example.com/a", Path: "a"}}, expectErr: true},
+		{name: "malformed checksum", assets: []Asset{{URL: "https://example.com/a", Path: "a", SHA256: "abc"}}, expectErr: true},
+		{name: "absolute path", assets: []Asset{{URL: "https://example.com/a", Path: "/etc/passwd", SHA256: validSum}}, expectErr: true},
+		{name: "path traversal", assets: []Asset{{URL: "https://example.com/a", Path: "../../.bashrc", SHA256: validSum}}, expectErr: true},
+		{
+			name: "duplicate path",
+			assets: []Asset{
+				{URL: "https://example.com/a", Path: "init.sql", SHA256: validSum},
+				{URL: "https://example.com/b", Path: "./init.sql", SHA256: validSum},
+			},
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateAssets(tt.assets)
+			if tt.expectErr && err == nil {
+				t.Error("Expected error but got none")
+			}
+			if !tt.expectErr && err != nil {
+				t.Errorf("Expected no error but got: %v", err)
+			}
+		})
+	}
+}
+
+func TestDownloadAssets(t *testing.T) {
+	files := map[string]string{
+		"/nginx.conf": "server { listen 80; }\n",
+		"/init.sql":   "CREATE TABLE t (id int);\n",
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		content, ok := files[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Errorf("Failed to write response: %v", err)
+		}
+	}))
+	defer server.Close()
+
+	packageDir := t.TempDir()
+	assets := []Asset{
+		{URL: server.URL + "/nginx.conf", Path: "config/nginx.conf", SHA256: sha256Hex(files["/nginx.conf"])},
+		{URL: server.URL + "/init.sql", Path: "init.sql", SHA256: strings.ToUpper(sha256Hex(files["/init.sql"]))},
+	}
+
+	if err := downloadAssets(assets, packageDir); err != nil {
+		t.Fatalf("downloadAssets failed: %v", err)
+	}
+
+	for _, asset := range assets {
+		data, err := os.ReadFile(filepath.Join(packageDir, asset.Path))
+		if err != nil {
+			t.Fatalf("Failed to read %s: %v", asset.Path, err)
+		}
+		if sha256Hex(string(data)) != strings.ToLower(asset.SHA256) {
+			t.Errorf("Content mismatch for %s", asset.Path)
+		}
+	}
+}
+
+func TestDownloadAssetsChecksumMismatch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := w.Write([]byte("tampered")); err != nil {
+			t.Errorf("Failed to write response: %v", err)
+		}
+	}))
+	defer server.Close()
+
+	packageDir := t.TempDir()
+	err := downloadAssets([]Asset{{URL: server.URL, Path: "init.sql", SHA256: sha256Hex("original")}}, packageDir)
+	if err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Fatalf("Expected checksum mismatch error, got %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(packageDir, "init.sql")); !os.IsNotExist(err) {
+		t.Error("Expected asset not to be written on checksum mismatch")
+	}
+}
+
+func TestDownloadAssetsRejectsSymlinkEscape(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := w.Write([]byte("content")); err != nil {
+			t.Errorf("Failed to write response: %v", err)
+		}
+	}))
+	defer server.Close()
+
+	packageDir := t.TempDir()
+	outside := t.TempDir()
+	if err := os.Symlink(outside, filepath.Join(packageDir, "link")); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+
+	err := downloadAssets([]Asset{{URL: server.URL, Path: "link/escape.txt", SHA256: sha256Hex("content")}}, packageDir)
+	if err == nil {
+		t.Fatal("Expected error when writing through a symlink outside the package directory")
+	}
+	if _, err := os.Stat(filepath.Join(outside, "escape.txt")); !os.IsNotExist(err) {
+		t.Error("Asset escaped the package directory")
+	}
+}
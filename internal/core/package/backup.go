@@ -0,0 +1,241 @@
+package pkg
+
+import (
+	"archive/tar"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/klauspost/compress/zstd"
+
+	"github.com/LoriKarikari/compak/internal/core/compose"
+)
+
+const (
+	backupFormat       = 1
+	backupManifestFile = "manifest.json"
+	backupStateFile    = "installed.json"
+	backupPackageDir   = "package"
+	backupVolumesDir   = "volumes"
+)
+
+type BackupOptions struct {
+	Pause bool
+}
+
+type BackupManifest struct {
+	Format     int            `json:"format"`
+	Package    string         `json:"package"`
+	Version    string         `json:"version"`
+	CreatedAt  time.Time      `json:"created_at"`
+	Volumes    []BackupVolume `json:"volumes,omitempty"`
+	BindMounts []string       `json:"bind_mounts,omitempty"`
+}
+
+type BackupVolume struct {
+	Key  string `json:"key"`
+	Name string `json:"name"`
+}
+
+func (m *Manager) Backup(ctx context.Context, packageName string, w io.Writer, opts BackupOptions) (err error) {
+	project, err := m.resolveProject(packageName, nil)
+	if err != nil {
+		return err
+	}
+	installedPkg, err := m.client.GetInstalledPackage(packageName)
+	if err != nil {
+		return fmt.Errorf("package not found: %w", err)
+	}
+
+	if installedPkg.Status != "stopped" {
+		resume, err := m.quiesce(ctx, project, opts.Pause)
+		if err != nil {
+			return err
+		}
+		defer func() { err = errors.Join(err, resume()) }()
+	}
+
+	return m.writeBackup(ctx, project, installedPkg, w)
+}
+
+func (m *Manager) quiesce(ctx context.Context, project *types.Project, pause bool) (func() error, error) {
+	if pause {
+		fmt.Printf("Pausing %s...\n", project.Name)
+		if err := m.engine.Pause(ctx, project); err != nil {
+			return nil, fmt.Errorf("failed to pause %s: %w", project.Name, err)
+		}
+		return func() error { return m.engine.Unpause(context.WithoutCancel(ctx), project) }, nil
+	}
+
+	fmt.Printf("Stopping %s...\n", project.Name)
+	if err := m.engine.Stop(ctx, project, nil); err != nil {
+		return nil, fmt.Errorf("failed to stop %s: %w", project.Name, err)
+	}
+	return func() error { return m.engine.Start(context.WithoutCancel(ctx), project, nil) }, nil
+}
+
+func (m *Manager) writeBackup(ctx context.Context, project *types.Project, installedPkg InstalledPackage, w io.Writer) error {
+	encoder, err := zstd.NewWriter(w)
+	if err != nil {
+		return fmt.Errorf("failed to create backup archive: %w", err)
+	}
+	archive := tar.NewWriter(encoder)
+
+	packageDir := filepath.Join(m.packagesDir, installedPkg.Package.Name)
+	manifest := BackupManifest{
+		Format:    backupFormat,
+		Package:   installedPkg.Package.Name,
+		Version:   installedPkg.Package.Version,
+		CreatedAt: time.Now().UTC(),
+		Volumes:   backupVolumes(project),
+	}
+	var external []string
+	manifest.BindMounts, external = bindMounts(project, packageDir)
+	if len(external) > 0 {
+		fmt.Printf("Warning: bind mounts outside the package directory are not included in the backup: %s\n", strings.Join(external, ", "))
+	}
+
+	if err := writeJSONEntry(archive, backupManifestFile, manifest); err != nil {
+		return err
+	}
+	if err := writeJSONEntry(archive, backupStateFile, installedPkg); err != nil {
+		return err
+	}
+	if err := archiveDir(archive, packageDir, backupPackageDir); err != nil {
+		return err
+	}
+	for _, volume := range manifest.Volumes {
+		fmt.Printf("Archiving volume %s...\n", volume.Name)
+		if err := m.archiveVolume(ctx, archive, project, volume.Key); err != nil {
+			return err
+		}
+	}
+
+	if err := archive.Close(); err != nil {
+		return fmt.Errorf("failed to write backup archive: %w", err)
+	}
+	return encoder.Close()
+}
+
+func backupVolumes(project *types.Project) []BackupVolume {
+	var volumes []BackupVolume
+	for key, volume := range project.Volumes {
+		if volume.External {
+			continue
+		}
+		name, err := compose.VolumeName(project, key)
+		if err != nil {
+			continue
+		}
+		volumes = append(volumes, BackupVolume{Key: key, Name: name})
+	}
+	slices.SortFunc(volumes, func(a, b BackupVolume) int { return strings.Compare(a.Key, b.Key) })
+	return volumes
+}
+
+func writeJSONEntry(archive *tar.Writer, name string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", name, err)
+	}
+	header := &tar.Header{Name: name, Mode: 0o600, Size: int64(len(data)), Typeflag: tar.TypeReg, ModTime: time.Now()}
+	if err := archive.WriteHeader(header); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	if _, err := archive.Write(data); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	return nil
+}
+
+func archiveDir(archive *tar.Writer, dir, prefix string) error {
+	return filepath.WalkDir(dir, func(file string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, file)
+		if err != nil {
+			return err
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+
+		var link string
+		if info.Mode()&fs.ModeSymlink != 0 {
+			if link, err = os.Readlink(file); err != nil {
+				return err
+			}
+		}
+		header, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return fmt.Errorf("failed to archive %s: %w", file, err)
+		}
+		header.Name = path.Join(prefix, filepath.ToSlash(rel))
+		if entry.IsDir() {
+			header.Name += "/"
+		}
+		if err := archive.WriteHeader(header); err != nil {
+			return fmt.Errorf("failed to archive %s: %w", file, err)
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		return copyFileTo(archive, file)
+	})
+}
+
+func copyFileTo(w io.Writer, file string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return fmt.Errorf("failed to archive %s: %w", file, err)
+	}
+	defer func() { _ = f.Close() }()
+
+	if _, err := io.Copy(w, f); err != nil {
+		return fmt.Errorf("failed to archive %s: %w", file, err)
+	}
+	return nil
+}
+
+func (m *Manager) archiveVolume(ctx context.Context, archive *tar.Writer, project *types.Project, key string) error {
+	reader, writer := io.Pipe()
+	go func() {
+		_ = writer.CloseWithError(m.engine.ExportVolume(ctx, project, key, writer))
+	}()
+	defer func() { _ = reader.Close() }()
+
+	prefix := path.Join(backupVolumesDir, key)
+	volume := tar.NewReader(reader)
+	for {
+		header, err := volume.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to export volume %s: %w", key, err)
+		}
+
+		header.Name = path.Join(prefix, header.Name)
+		if header.Typeflag == tar.TypeDir {
+			header.Name += "/"
+		}
+		if err := archive.WriteHeader(header); err != nil {
+			return fmt.Errorf("failed to archive volume %s: %w", key, err)
+		}
+		if _, err := io.Copy(archive, volume); err != nil {
+			return fmt.Errorf("failed to archive volume %s: %w", key, err)
+		}
+	}
+}
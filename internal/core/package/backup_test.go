@@ -0,0 +1,154 @@
+package pkg
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+
+	"github.com/LoriKarikari/compak/internal/core/compose/composetest"
+)
+
+const backupComposeFile = `services:
+  web:
+    image: nginx:alpine
+    volumes:
+      - ./config:/etc/app
+      - data:/var/lib/app
+volumes:
+  data: {}
+`
+
+func setupBackupPackage(t *testing.T, engine *composetest.Engine) *Manager {
+	t.Helper()
+
+	manager, _ := setupRunningPackage(t, engine, InstalledPackage{Package: Package{Name: "demo", Version: "1.0.0"}}, map[string]string{
+		testComposeFilename: backupComposeFile,
+		"config/app.conf":   "answer=42\n",
+	})
+	engine.SetVolumeFile("compak-demo_data", "db/records", []byte("row-1\n"))
+	return manager
+}
+
+func TestBackupRestoreRoundTrip(t *testing.T) {
+	source := composetest.NewEngine()
+	manager := setupBackupPackage(t, source)
+
+	var archive bytes.Buffer
+	if err := manager.Backup(context.Background(), "demo", &archive, BackupOptions{}); err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+	if source.Count(composetest.MethodStop) != 1 || source.Count(composetest.MethodStart) != 1 {
+		t.Errorf("Expected the package to be stopped and started once, got calls %v", source.Calls())
+	}
+	if state := source.State("compak-demo", "web"); state != "running" {
+		t.Errorf("Expected web to be running after backup, got %s", state)
+	}
+
+	target := composetest.NewEngine()
+	stateDir := t.TempDir()
+	restorer := NewManager(NewClient(stateDir), target, stateDir)
+	installed, err := restorer.Restore(context.Background(), bytes.NewReader(archive.Bytes()))
+	if err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+	if installed.Package.Version != "1.0.0" || installed.Status != "installed" {
+		t.Errorf("Expected demo@1.0.0 to be installed, got %+v", installed)
+	}
+
+	config, err := os.ReadFile(filepath.Join(stateDir, "packages", "demo", "config", "app.conf"))
+	if err != nil || string(config) != "answer=42\n" {
+		t.Errorf("Expected bind-mounted config to be restored, got %q (%v)", config, err)
+	}
+	if data, ok := target.VolumeFile("compak-demo_data", "db/records"); !ok || string(data) != "row-1\n" {
+		t.Errorf("Expected volume data to be restored, got %q", data)
+	}
+	if !target.Running("compak-demo") {
+		t.Error("Expected restored project to be running")
+	}
+
+	if _, err := restorer.Restore(context.Background(), bytes.NewReader(archive.Bytes())); err == nil {
+		t.Error("Expected error restoring over an installed package")
+	}
+}
+
+func TestBackupPauseKeepsStoppedPackagesStopped(t *testing.T) {
+	tests := []struct {
+		name    string
+		pause   bool
+		stopped bool
+		methods map[string]int
+	}{
+		{name: "pause", pause: true, methods: map[string]int{composetest.MethodPause: 1, composetest.MethodUnpause: 1, composetest.MethodStop: 0}},
+		{name: "stopped package", stopped: true, methods: map[string]int{composetest.MethodStop: 0, composetest.MethodStart: 0}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine := composetest.NewEngine()
+			manager := setupBackupPackage(t, engine)
+			if tt.stopped {
+				if err := manager.client.setStatus("demo", "stopped"); err != nil {
+					t.Fatalf("Expected no error but got: %v", err)
+				}
+			}
+
+			if err := manager.Backup(context.Background(), "demo", &bytes.Buffer{}, BackupOptions{Pause: tt.pause}); err != nil {
+				t.Fatalf("Expected no error but got: %v", err)
+			}
+			for method, expected := range tt.methods {
+				if got := engine.Count(method); got != expected {
+					t.Errorf("Expected %d %s calls, got %d", expected, method, got)
+				}
+			}
+		})
+	}
+}
+
+func TestRestoreRejectsUnsafePaths(t *testing.T) {
+	tests := []string{"package/../escape", "package//etc/passwd", "other/file"}
+
+	for _, name := range tests {
+		t.Run(name, func(t *testing.T) {
+			var archive bytes.Buffer
+			encoder, err := zstd.NewWriter(&archive)
+			if err != nil {
+				t.Fatalf("Expected no error but got: %v", err)
+			}
+			writer := tar.NewWriter(encoder)
+			if err := writeJSONEntry(writer, backupManifestFile, BackupManifest{Format: backupFormat, Package: "demo", Version: "1.0.0"}); err != nil {
+				t.Fatalf("Expected no error but got: %v", err)
+			}
+			if err := writeJSONEntry(writer, backupStateFile, InstalledPackage{Package: Package{Name: "demo", Version: "1.0.0"}, Status: "installed"}); err != nil {
+				t.Fatalf("Expected no error but got: %v", err)
+			}
+			if err := writeJSONEntry(writer, name, "x"); err != nil {
+				t.Fatalf("Expected no error but got: %v", err)
+			}
+			if err := writer.Close(); err != nil {
+				t.Fatalf("Expected no error but got: %v", err)
+			}
+			if err := encoder.Close(); err != nil {
+				t.Fatalf("Expected no error but got: %v", err)
+			}
+
+			stateDir := t.TempDir()
+			manager := NewManager(NewClient(stateDir), composetest.NewEngine(), stateDir)
+			_, err = manager.Restore(context.Background(), &archive)
+			if err == nil {
+				t.Fatal("Expected error for unsafe archive entry")
+			}
+			if _, statErr := os.Stat(filepath.Join(stateDir, "packages", "demo")); !os.IsNotExist(statErr) {
+				t.Errorf("Expected package directory to be cleaned up after %v", err)
+			}
+			if strings.Contains(err.Error(), "already") {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		})
+	}
+}
@@ -2,11 +2,11 @@ package pkg
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
-	"regexp"
 	"strings"
 	"time"
 
@@ -59,21 +59,38 @@ func (c *Client) Install(pkg Package, values map[string]string) error {
 		return fmt.Errorf("parameter validation failed: %w", err)
 	}
 
-	installedPkg := InstalledPackage{
-		Package:     pkg,
-		InstallTime: time.Now(),
-		Values:      mergedValues,
-		Status:      "installed",
-	}
+	return c.install(InstalledPackage{
+		Package: pkg,
+		Values:  mergedValues,
+	})
+}
+
+func (c *Client) install(installedPkg InstalledPackage) error {
+	installedPkg.InstallTime = time.Now()
+	installedPkg.Status = "installed"
 
 	if err := c.saveInstalledPackage(installedPkg); err != nil {
 		return fmt.Errorf("failed to save package state: %w", err)
 	}
 
-	fmt.Printf("Successfully installed %s@%s\n", pkg.Name, pkg.Version)
+	if err := c.updateLock(installedPkg.Package.Name, NewLockEntry(installedPkg)); err != nil {
+		return err
+	}
+
+	fmt.Printf("Successfully installed %s@%s\n", installedPkg.Package.Name, installedPkg.Package.Version)
 	return nil
 }
 
+func (c *Client) fail(installedPkg InstalledPackage, cause error) error {
+	installedPkg.InstallTime = time.Now()
+	installedPkg.Status = "failed"
+
+	if err := c.saveInstalledPackage(installedPkg); err != nil {
+		return errors.Join(cause, fmt.Errorf("failed to save package state: %w", err))
+	}
+	return cause
+}
+
 func (c *Client) Uninstall(packageName string) error {
 	installedPkg, err := c.GetInstalledPackage(packageName)
 	if err != nil {
@@ -89,10 +106,6 @@ func (c *Client) Uninstall(packageName string) error {
 }
 
 func (c *Client) List() ([]InstalledPackage, error) {
-	if err := c.ensureStateDir(); err != nil {
-		return nil, fmt.Errorf("failed to ensure state directory: %w", err)
-	}
-
 	stateFile := filepath.Join(c.stateDir, "installed.json")
 	if _, err := os.Stat(stateFile); os.IsNotExist(err) {
 		return []InstalledPackage{}, nil
@@ -120,6 +133,10 @@ func (c *Client) ensureStateDir() error {
 }
 
 func (c *Client) mergeValues(pkg Package, overrides map[string]string) map[string]string {
+	return MergeValues(pkg, overrides)
+}
+
+func MergeValues(pkg Package, overrides map[string]string) map[string]string {
 	defaults := lo.MapEntries(pkg.Parameters, func(name string, param Param) (string, string) {
 		return name, param.Default
 	})
@@ -133,57 +150,29 @@ func (c *Client) mergeValues(pkg Package, overrides map[string]string) map[strin
 
 func (c *Client) validateParameters(params map[string]Param, values map[string]string) error {
 	for name, param := range params {
-		value, exists := values[name]
-		if param.Required && (!exists || value == "") {
-			return fmt.Errorf("required parameter '%s' is missing", name)
-		}
-		if exists && value != "" {
-			if err := validateParameterValue(name, value, param); err != nil {
-				return err
-			}
-		}
-	}
-	return nil
-}
-
-func validateParameterValue(name, value string, param Param) error {
-	if len(value) > 1000 {
-		return fmt.Errorf("parameter '%s' value too long (max 1000 characters)", name)
-	}
-
-	if strings.ContainsAny(value, "\x00\r\n") {
-		return fmt.Errorf("parameter '%s' contains invalid characters", name)
-	}
-
-	switch param.Type {
-	case "string":
-		return nil
-	case "number":
-		matched, err := regexp.MatchString(`^-?\d+(\.\d+)?$`, value)
+		visible, err := param.IsVisible(values)
 		if err != nil {
-			return fmt.Errorf("parameter '%s' regex error: %w", name, err)
+			return fmt.Errorf("parameter '%s': %w", name, err)
 		}
-		if !matched {
-			return fmt.Errorf("parameter '%s' must be a valid number", name)
+		if !visible {
+			continue
 		}
-	case "boolean":
-		matched, err := regexp.MatchString(`^(true|false|yes|no|1|0)$`, strings.ToLower(value))
+
+		required, err := param.IsRequired(values)
 		if err != nil {
-			return fmt.Errorf("parameter '%s' regex error: %w", name, err)
+			return fmt.Errorf("parameter '%s': %w", name, err)
 		}
-		if !matched {
-			return fmt.Errorf("parameter '%s' must be a boolean value (true/false)", name)
-		}
-	case "port":
-		matched, err := regexp.MatchString(`^([1-9]\d{0,3}|[1-5]\d{4}|6[0-4]\d{3}|65[0-4]\d{2}|655[0-2]\d|6553[0-5])$`, value)
-		if err != nil {
-			return fmt.Errorf("parameter '%s' regex error: %w", name, err)
+
+		value, exists := values[name]
+		if required && (!exists || value == "") {
+			return fmt.Errorf("required parameter '%s' is missing", name)
 		}
-		if !matched {
-			return fmt.Errorf("parameter '%s' must be a valid port number (1-65535)", name)
+		if exists && value != "" {
+			if err := ValidateParameterValue(name, value, param); err != nil {
+				return err
+			}
 		}
 	}
-
 	return nil
 }
 
@@ -210,6 +199,19 @@ func (c *Client) saveInstalledPackage(pkg InstalledPackage) error {
 	return os.WriteFile(stateFile, data, 0o600)
 }
 
+func (c *Client) setStatus(name, status string) error {
+	installedPkg, err := c.GetInstalledPackage(name)
+	if err != nil {
+		return err
+	}
+
+	installedPkg.Status = status
+	if err := c.saveInstalledPackage(installedPkg); err != nil {
+		return fmt.Errorf("failed to save package state: %w", err)
+	}
+	return nil
+}
+
 func (c *Client) GetInstalledPackage(name string) (InstalledPackage, error) {
 	stateFile := filepath.Join(c.stateDir, "installed.json")
 	data, err := c.safeReadFile(stateFile)
@@ -302,6 +304,7 @@ func validateInstalledPackage(pkg InstalledPackage) error {
 		"installed": true,
 		"failed":    true,
 		"updating":  true,
+		"stopped":   true,
 	}
 
 	if !allowedStatuses[pkg.Status] {
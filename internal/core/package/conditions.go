@@ -0,0 +1,255 @@
+package pkg
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+type condition func(values map[string]string) bool
+
+type conditionParser struct {
+	tokens []string
+	pos    int
+	refs   []string
+}
+
+func (p Param) IsVisible(values map[string]string) (bool, error) {
+	if p.VisibleIf == "" {
+		return true, nil
+	}
+	return evaluateCondition(p.VisibleIf, values)
+}
+
+func (p Param) IsRequired(values map[string]string) (bool, error) {
+	visible, err := p.IsVisible(values)
+	if err != nil || !visible {
+		return false, err
+	}
+	if p.Required || p.RequiredIf == "" {
+		return p.Required, nil
+	}
+	return evaluateCondition(p.RequiredIf, values)
+}
+
+func evaluateCondition(expr string, values map[string]string) (bool, error) {
+	cond, _, err := parseCondition(expr)
+	if err != nil {
+		return false, err
+	}
+	return cond(values), nil
+}
+
+func validateConditionRefs(name, field, expr string, params map[string]Param) error {
+	if expr == "" {
+		return nil
+	}
+
+	_, refs, err := parseCondition(expr)
+	if err != nil {
+		return fmt.Errorf("parameter '%s' has invalid %s: %w", name, field, err)
+	}
+
+	for _, ref := range refs {
+		if ref == name {
+			return fmt.Errorf("parameter '%s' %s cannot reference itself", name, field)
+		}
+		if _, exists := params[ref]; !exists {
+			return fmt.Errorf("parameter '%s' %s references unknown parameter '%s'", name, field, ref)
+		}
+	}
+	return nil
+}
+
+func parseCondition(expr string) (condition, []string, error) {
+	tokens, err := tokenizeCondition(expr)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, nil, fmt.Errorf("empty expression")
+	}
+
+	p := &conditionParser{tokens: tokens}
+	cond, err := p.parseOr()
+	if err != nil {
+		return nil, nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, nil, fmt.Errorf("unexpected %q in %q", p.tokens[p.pos], expr)
+	}
+	return cond, p.refs, nil
+}
+
+func (p *conditionParser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+func (p *conditionParser) next() string {
+	tok := p.peek()
+	p.pos++
+	return tok
+}
+
+func (p *conditionParser) parseOr() (condition, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek() == "||" {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(values map[string]string) bool { return l(values) || right(values) }
+	}
+	return left, nil
+}
+
+func (p *conditionParser) parseAnd() (condition, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek() == "&&" {
+		p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(values map[string]string) bool { return l(values) && right(values) }
+	}
+	return left, nil
+}
+
+func (p *conditionParser) parseUnary() (condition, error) {
+	switch p.peek() {
+	case "!":
+		p.next()
+		inner, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return func(values map[string]string) bool { return !inner(values) }, nil
+	case "(":
+		p.next()
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.next() != ")" {
+			return nil, fmt.Errorf("missing closing parenthesis")
+		}
+		return inner, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *conditionParser) parseComparison() (condition, error) {
+	name := p.next()
+	if !isConditionIdent(name) {
+		return nil, fmt.Errorf("expected parameter name, got %q", name)
+	}
+	p.refs = append(p.refs, name)
+
+	op := p.peek()
+	if op != "==" && op != "!=" {
+		return func(values map[string]string) bool { return isTruthy(values[name]) }, nil
+	}
+	p.next()
+
+	operand := p.next()
+	if operand == "" || isConditionOperator(operand) {
+		return nil, fmt.Errorf("expected value after %s", op)
+	}
+	expected := strings.Trim(operand, `"'`)
+
+	if op == "==" {
+		return func(values map[string]string) bool { return conditionEqual(values[name], expected) }, nil
+	}
+	return func(values map[string]string) bool { return !conditionEqual(values[name], expected) }, nil
+}
+
+func tokenizeCondition(expr string) ([]string, error) {
+	var tokens []string
+	for i := 0; i < len(expr); {
+		c := expr[i]
+		switch {
+		case c == ' ' || c == '\t':
+			i++
+		case strings.HasPrefix(expr[i:], "&&"), strings.HasPrefix(expr[i:], "||"),
+			strings.HasPrefix(expr[i:], "=="), strings.HasPrefix(expr[i:], "!="):
+			tokens = append(tokens, expr[i:i+2])
+			i += 2
+		case c == '!' || c == '(' || c == ')':
+			tokens = append(tokens, string(c))
+			i++
+		case c == '"' || c == '\'':
+			end := strings.IndexByte(expr[i+1:], c)
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated string in %q", expr)
+			}
+			tokens = append(tokens, expr[i:i+end+2])
+			i += end + 2
+		default:
+			start := i
+			for i < len(expr) && !strings.ContainsRune(" \t!()&|=\"'", rune(expr[i])) {
+				i++
+			}
+			if start == i {
+				return nil, fmt.Errorf("unexpected character %q in %q", c, expr)
+			}
+			tokens = append(tokens, expr[start:i])
+		}
+	}
+	return tokens, nil
+}
+
+func isConditionIdent(tok string) bool {
+	if tok == "" {
+		return false
+	}
+	for _, r := range tok {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' && r != '-' && r != '.' {
+			return false
+		}
+	}
+	return true
+}
+
+func isConditionOperator(tok string) bool {
+	switch tok {
+	case "&&", "||", "==", "!=", "!", "(", ")":
+		return true
+	}
+	return false
+}
+
+func isTruthy(value string) bool {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "true", "yes", "1", "on":
+		return true
+	}
+	return false
+}
+
+func conditionEqual(actual, expected string) bool {
+	if isBooleanLiteral(expected) && isBooleanLiteral(actual) {
+		return isTruthy(actual) == isTruthy(expected)
+	}
+	return actual == expected
+}
+
+func isBooleanLiteral(value string) bool {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "true", "false", "yes", "no", "1", "0", "on", "off":
+		return true
+	}
+	return false
+}
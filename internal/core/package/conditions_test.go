@@ -0,0 +1,92 @@
+package pkg
+
+import (
+	"testing"
+)
+
+func TestEvaluateCondition(t *testing.T) {
+	values := map[string]string{
+		"ENABLE_MAIL": "true",
+		"MAIL_MODE":   "smtp",
+		"DEBUG":       "0",
+	}
+
+	tests := []struct {
+		expr      string
+		want      bool
+		expectErr bool
+	}{
+		{expr: "ENABLE_MAIL", want: true},
+		{expr: "!ENABLE_MAIL", want: false},
+		{expr: "DEBUG", want: false},
+		{expr: "ENABLE_MAIL == true", want: true},
+		{expr: "ENABLE_MAIL == yes", want: true},
+		{expr: "MAIL_MODE == 'smtp'", want: true},
+		{expr: `MAIL_MODE != "smtp"`, want: false},
+		{expr: "MISSING", want: false},
+		{expr: "ENABLE_MAIL && MAIL_MODE == sendmail", want: false},
+		{expr: "DEBUG || MAIL_MODE == smtp", want: true},
+		{expr: "!(DEBUG || !ENABLE_MAIL)", want: true},
+		{expr: "", expectErr: true},
+		{expr: "ENABLE_MAIL ==", expectErr: true},
+		{expr: "(ENABLE_MAIL", expectErr: true},
+		{expr: "MAIL_MODE == 'smtp", expectErr: true},
+		{expr: "ENABLE_MAIL DEBUG", expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			got, err := evaluateCondition(tt.expr, values)
+			if tt.expectErr {
+				if err == nil {
+					t.Error("Expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error but got: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("evaluateCondition(%q) = %v, want %v", tt.expr, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClient_ValidateParametersConditional(t *testing.T) {
+	client := NewClient(t.TempDir())
+
+	params := map[string]Param{
+		"ENABLE_MAIL":   {Type: "boolean"},
+		"SMTP_HOST":     {Type: "hostname", RequiredIf: "ENABLE_MAIL"},
+		"SMTP_PASSWORD": {Required: true, VisibleIf: "ENABLE_MAIL"},
+	}
+
+	if err := client.validateParameters(params, map[string]string{"ENABLE_MAIL": "false"}); err != nil {
+		t.Errorf("Expected hidden and conditional parameters to be skipped, got: %v", err)
+	}
+
+	values := map[string]string{"ENABLE_MAIL": "true", "SMTP_PASSWORD": "secret"}
+	if err := client.validateParameters(params, values); err == nil {
+		t.Error("Expected error for missing SMTP_HOST")
+	}
+
+	values["SMTP_HOST"] = "smtp.example.com"
+	if err := client.validateParameters(params, values); err != nil {
+		t.Errorf("Expected no error but got: %v", err)
+	}
+}
+
+func TestValidateParamDefinitionsConditionRefs(t *testing.T) {
+	params := map[string]Param{
+		"SMTP_HOST": {RequiredIf: "ENABLE_MAIL"},
+	}
+	if err := ValidateParamDefinitions(params); err == nil {
+		t.Error("Expected error for reference to unknown parameter")
+	}
+
+	params["ENABLE_MAIL"] = Param{Type: "boolean", VisibleIf: "ENABLE_MAIL"}
+	if err := ValidateParamDefinitions(params); err == nil {
+		t.Error("Expected error for self reference")
+	}
+}
@@ -0,0 +1,340 @@
+package pkg
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/compose-spec/compose-go/v2/dotenv"
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/docker/compose/v2/pkg/api"
+
+	"github.com/LoriKarikari/compak/internal/core/compose"
+	"github.com/LoriKarikari/compak/internal/core/template"
+)
+
+const envFile = ".env"
+
+const (
+	DriftValue   = "value"
+	DriftFile    = "file"
+	DriftMissing = "missing"
+	DriftOrphan  = "orphan"
+	DriftImage   = "image"
+	DriftConfig  = "config"
+	DriftState   = "state"
+)
+
+type Drift struct {
+	Kind   string
+	Target string
+	Detail string
+}
+
+func (d Drift) String() string {
+	return fmt.Sprintf("%-8s %s: %s", d.Kind, d.Target, d.Detail)
+}
+
+type DriftReport struct {
+	Package string
+	Drifts  []Drift
+}
+
+func (r DriftReport) Clean() bool {
+	return len(r.Drifts) == 0
+}
+
+func (r DriftReport) has(kind string) bool {
+	return slices.ContainsFunc(r.Drifts, func(d Drift) bool { return d.Kind == kind })
+}
+
+type RepairOptions struct {
+	Accept bool
+}
+
+func (m *Manager) Diff(ctx context.Context, packageName string) (DriftReport, error) {
+	if err := validatePackageName(packageName); err != nil {
+		return DriftReport{}, fmt.Errorf("invalid package name: %w", err)
+	}
+
+	installedPkg, err := m.client.GetInstalledPackage(packageName)
+	if err != nil {
+		return DriftReport{}, fmt.Errorf("package %s is not installed", packageName)
+	}
+
+	report := DriftReport{Package: packageName}
+	packageDir := filepath.Join(m.packagesDir, packageName)
+	if _, err := os.Stat(packageDir); err != nil {
+		report.Drifts = append(report.Drifts, Drift{Kind: DriftFile, Target: packageDir, Detail: "package directory is missing"})
+		return report, nil
+	}
+
+	report.Drifts = append(report.Drifts, valueDrift(packageDir, installedPkg.Values)...)
+	report.Drifts = append(report.Drifts, fileDrift(packageDir, installedPkg.Checksums)...)
+
+	project, err := m.loadProject(packageDir, projectName(packageName), installedPkg.ComposeFiles...)
+	if err != nil {
+		report.Drifts = append(report.Drifts, Drift{Kind: DriftFile, Target: "compose project", Detail: err.Error()})
+		return report, nil
+	}
+
+	containers, err := m.engine.PS(ctx, project)
+	if err != nil {
+		return DriftReport{}, fmt.Errorf("failed to get containers: %w", err)
+	}
+	report.Drifts = append(report.Drifts, containerDrift(project, containers, installedPkg.Status == "stopped")...)
+
+	return report, nil
+}
+
+func (m *Manager) Repair(ctx context.Context, packageName string, opts RepairOptions) (DriftReport, error) {
+	report, err := m.Diff(ctx, packageName)
+	if err != nil || report.Clean() {
+		return report, err
+	}
+
+	installedPkg, err := m.client.GetInstalledPackage(packageName)
+	if err != nil {
+		return report, fmt.Errorf("package %s is not installed", packageName)
+	}
+
+	packageDir := filepath.Join(m.packagesDir, packageName)
+	if _, err := os.Stat(packageDir); err != nil {
+		return report, fmt.Errorf("package directory for %s is missing; reinstall or restore it from a backup", packageName)
+	}
+
+	if opts.Accept {
+		if err := acceptDiskState(packageDir, &installedPkg); err != nil {
+			return report, err
+		}
+	} else if err := restoreDesiredFiles(packageDir, installedPkg, report); err != nil {
+		return report, err
+	}
+
+	if installedPkg.Status == "stopped" {
+		fmt.Printf("%s is stopped; run 'compak start %s' to bring it up with the repaired files\n", packageName, packageName)
+	} else {
+		if err := m.reconcile(ctx, packageName, installedPkg); err != nil {
+			return report, err
+		}
+		installedPkg.Status = "installed"
+	}
+
+	if err := m.client.saveInstalledPackage(installedPkg); err != nil {
+		return report, fmt.Errorf("failed to save package state: %w", err)
+	}
+	return report, nil
+}
+
+func (m *Manager) reconcile(ctx context.Context, packageName string, installedPkg InstalledPackage) error {
+	packageDir := filepath.Join(m.packagesDir, packageName)
+	project, err := m.loadProject(packageDir, projectName(packageName), installedPkg.ComposeFiles...)
+	if err != nil {
+		return fmt.Errorf("failed to load compose project: %w", err)
+	}
+
+	fmt.Printf("Reconciling %s...\n", packageName)
+	if err := m.engine.Up(ctx, project, true, nil); err != nil {
+		return fmt.Errorf("failed to start services: %w", err)
+	}
+	return nil
+}
+
+func acceptDiskState(packageDir string, installedPkg *InstalledPackage) error {
+	values, err := readEnvFile(packageDir, installedPkg.Values)
+	switch {
+	case os.IsNotExist(err):
+		fmt.Printf("%s is missing, rewriting it from stored values...\n", envFile)
+		if err := template.NewEngine(installedPkg.Values).WriteEnvFile(packageDir); err != nil {
+			return fmt.Errorf("failed to write env file: %w", err)
+		}
+		values = installedPkg.Values
+	case err != nil:
+		return fmt.Errorf("failed to read %s: %w", envFile, err)
+	}
+	checksums, err := fileChecksums(packageDir, slices.Collect(maps.Keys(installedPkg.Checksums)))
+	if err != nil {
+		return err
+	}
+
+	installedPkg.Values = values
+	if len(installedPkg.Checksums) > 0 {
+		installedPkg.Checksums = checksums
+	}
+	return nil
+}
+
+func restoreDesiredFiles(packageDir string, installedPkg InstalledPackage, report DriftReport) error {
+	if report.has(DriftFile) {
+		var files []string
+		for _, drift := range report.Drifts {
+			if drift.Kind == DriftFile {
+				files = append(files, drift.Target)
+			}
+		}
+		return fmt.Errorf("%s changed on disk and cannot be restored; run repair with --accept to keep the changes, or reinstall the package", strings.Join(files, ", "))
+	}
+
+	if report.has(DriftValue) {
+		fmt.Printf("Rewriting %s from stored values...\n", envFile)
+		if err := template.NewEngine(installedPkg.Values).WriteEnvFile(packageDir); err != nil {
+			return fmt.Errorf("failed to write env file: %w", err)
+		}
+	}
+	return nil
+}
+
+func fileChecksums(packageDir string, files []string) (map[string]string, error) {
+	checksums := make(map[string]string, len(files))
+	for _, file := range files {
+		rel := file
+		if filepath.IsAbs(file) {
+			var err error
+			if rel, err = filepath.Rel(packageDir, file); err != nil {
+				return nil, fmt.Errorf("failed to resolve %s: %w", file, err)
+			}
+		}
+
+		sum, err := fileChecksum(filepath.Join(packageDir, rel))
+		if err != nil {
+			return nil, err
+		}
+		checksums[filepath.ToSlash(rel)] = sum
+	}
+	return checksums, nil
+}
+
+func fileChecksum(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func valueDrift(packageDir string, stored map[string]string) []Drift {
+	onDisk, err := readEnvFile(packageDir, stored)
+	if err != nil {
+		if len(stored) == 0 {
+			return nil
+		}
+		return []Drift{{Kind: DriftValue, Target: envFile, Detail: "missing or unreadable"}}
+	}
+
+	var drifts []Drift
+	keys := slices.Sorted(maps.Keys(stored))
+	for key := range onDisk {
+		if _, ok := stored[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+
+	for _, key := range keys {
+		want, stored := stored[key]
+		got, present := onDisk[key]
+		switch {
+		case !present:
+			drifts = append(drifts, Drift{Kind: DriftValue, Target: key, Detail: "removed from " + envFile})
+		case !stored:
+			drifts = append(drifts, Drift{Kind: DriftValue, Target: key, Detail: "added to " + envFile})
+		case want != got:
+			drifts = append(drifts, Drift{Kind: DriftValue, Target: key, Detail: "differs from the installed value"})
+		}
+	}
+	return drifts
+}
+
+func readEnvFile(packageDir string, stored map[string]string) (map[string]string, error) {
+	path := filepath.Join(packageDir, envFile)
+	values, err := dotenv.Read(path)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, line := range strings.Split(string(data), "\n") {
+		key, raw, ok := strings.Cut(strings.TrimSuffix(line, "\r"), "=")
+		if !ok {
+			continue
+		}
+		key = strings.TrimSpace(key)
+		if want, ok := stored[key]; ok && want == raw && values[key] != want {
+			values[key] = want
+		}
+	}
+	return values, nil
+}
+
+func fileDrift(packageDir string, checksums map[string]string) []Drift {
+	var drifts []Drift
+	for _, file := range slices.Sorted(maps.Keys(checksums)) {
+		sum, err := fileChecksum(filepath.Join(packageDir, filepath.FromSlash(file)))
+		switch {
+		case err != nil:
+			drifts = append(drifts, Drift{Kind: DriftFile, Target: file, Detail: "missing"})
+		case sum != checksums[file]:
+			drifts = append(drifts, Drift{Kind: DriftFile, Target: file, Detail: "modified since install"})
+		}
+	}
+	return drifts
+}
+
+func containerDrift(project *types.Project, containers []api.ContainerSummary, stopped bool) []Drift {
+	byService := make(map[string][]api.ContainerSummary)
+	for _, container := range containers {
+		byService[container.Service] = append(byService[container.Service], container)
+	}
+
+	var drifts []Drift
+	for _, name := range project.ServiceNames() {
+		service := project.Services[name]
+		if service.GetScale() == 0 {
+			continue
+		}
+		if len(byService[name]) == 0 {
+			drifts = append(drifts, Drift{Kind: DriftMissing, Target: name, Detail: "no container exists"})
+			continue
+		}
+		for _, container := range byService[name] {
+			drifts = append(drifts, serviceDrift(service, container, stopped)...)
+		}
+	}
+
+	for _, service := range slices.Sorted(maps.Keys(byService)) {
+		if _, ok := project.Services[service]; !ok {
+			for _, container := range byService[service] {
+				drifts = append(drifts, Drift{Kind: DriftOrphan, Target: container.Name, Detail: "container is not part of the compose project"})
+			}
+		}
+	}
+	return drifts
+}
+
+func serviceDrift(service types.ServiceConfig, container api.ContainerSummary, stopped bool) []Drift {
+	var drifts []Drift
+	if service.Image != "" && container.Image != service.Image {
+		drifts = append(drifts, Drift{Kind: DriftImage, Target: service.Name, Detail: fmt.Sprintf("runs %s, expected %s", container.Image, service.Image)})
+	}
+
+	if hash := container.Labels[api.ConfigHashLabel]; hash != "" {
+		if expected, err := compose.ServiceHash(service); err == nil && hash != expected {
+			drifts = append(drifts, Drift{Kind: DriftConfig, Target: service.Name, Detail: "container was created from a different configuration"})
+		}
+	}
+
+	if !stopped && container.State != "running" && (container.ExitCode != 0 || !runsOnce(service)) {
+		drifts = append(drifts, Drift{Kind: DriftState, Target: service.Name, Detail: fmt.Sprintf("container %s is %s", container.Name, container.State)})
+	}
+	return drifts
+}
@@ -0,0 +1,296 @@
+package pkg
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/docker/compose/v2/pkg/api"
+
+	"github.com/LoriKarikari/compak/internal/core/compose/composetest"
+)
+
+const driftComposeFile = "services:\n  web:\n    image: nginx:alpine\n    environment:\n      - GREETING=${GREETING}\n"
+
+func setupDriftPackage(t *testing.T) (*Manager, *composetest.Engine, string) {
+	t.Helper()
+
+	engine := composetest.NewEngine()
+	manager, packageDir := setupRunningPackage(t, engine, InstalledPackage{
+		Package:      Package{Name: "demo", Version: "1.0.0"},
+		Values:       map[string]string{"GREETING": "hello"},
+		ComposeFiles: []string{testComposeFilename},
+	}, map[string]string{
+		testComposeFilename: driftComposeFile,
+		".env":              "GREETING=hello\n",
+	})
+	return manager, engine, packageDir
+}
+
+func TestContainerDrift(t *testing.T) {
+	project := &types.Project{Name: "compak-demo", Services: types.Services{
+		"web": {Name: "web", Image: "nginx:alpine"},
+		"job": {Name: "job", Image: "busybox"},
+	}}
+
+	tests := []struct {
+		name       string
+		containers []api.ContainerSummary
+		stopped    bool
+		expected   []string
+	}{
+		{
+			name:       "in sync",
+			containers: []api.ContainerSummary{{Service: "web", Image: "nginx:alpine", State: "running"}, {Service: "job", Image: "busybox", State: "exited"}},
+		},
+		{
+			name:       "missing and orphaned",
+			containers: []api.ContainerSummary{{Service: "web", Image: "nginx:alpine", State: "running"}, {Name: "old-1", Service: "old", State: "running"}},
+			expected:   []string{DriftMissing, DriftOrphan},
+		},
+		{
+			name:       "changed image and config",
+			containers: []api.ContainerSummary{{Service: "web", Image: "nginx:1.25", State: "running", Labels: map[string]string{api.ConfigHashLabel: "stale"}}, {Service: "job", Image: "busybox", State: "exited"}},
+			expected:   []string{DriftImage, DriftConfig},
+		},
+		{
+			name:       "exited while installed",
+			containers: []api.ContainerSummary{{Service: "web", Image: "nginx:alpine", State: "exited", ExitCode: 137}, {Service: "job", Image: "busybox", State: "exited", ExitCode: 1}},
+			expected:   []string{DriftState, DriftState},
+		},
+		{
+			name:       "exited while stopped",
+			containers: []api.ContainerSummary{{Service: "web", Image: "nginx:alpine", State: "exited", ExitCode: 137}, {Service: "job", Image: "busybox", State: "exited"}},
+			stopped:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var kinds []string
+			for _, drift := range containerDrift(project, tt.containers, tt.stopped) {
+				kinds = append(kinds, drift.Kind)
+			}
+			if !reflect.DeepEqual(kinds, tt.expected) {
+				t.Errorf("Expected drift %v, got %v", tt.expected, kinds)
+			}
+		})
+	}
+}
+
+func TestManagerDiff(t *testing.T) {
+	tests := []struct {
+		name     string
+		change   func(t *testing.T, engine *composetest.Engine, packageDir string)
+		expected []string
+	}{
+		{name: "clean", change: func(*testing.T, *composetest.Engine, string) {}},
+		{
+			name: "edited values",
+			change: func(t *testing.T, _ *composetest.Engine, packageDir string) {
+				if err := os.WriteFile(filepath.Join(packageDir, ".env"), []byte("GREETING=bye\nEXTRA=1\n"), 0o600); err != nil {
+					t.Fatalf("Expected no error but got: %v", err)
+				}
+			},
+			expected: []string{"value EXTRA", "value GREETING", "config web"},
+		},
+		{
+			name: "edited compose file",
+			change: func(t *testing.T, _ *composetest.Engine, packageDir string) {
+				if err := os.WriteFile(filepath.Join(packageDir, testComposeFilename), []byte(driftComposeFile+"  db:\n    image: postgres\n"), 0o600); err != nil {
+					t.Fatalf("Expected no error but got: %v", err)
+				}
+			},
+			expected: []string{"file " + testComposeFilename, "missing db"},
+		},
+		{
+			name: "container changes",
+			change: func(t *testing.T, engine *composetest.Engine, _ string) {
+				if err := engine.SetImage("compak-demo", "web", "nginx:1.25"); err != nil {
+					t.Fatalf("Expected no error but got: %v", err)
+				}
+			},
+			expected: []string{"image web"},
+		},
+		{
+			name: "removed container",
+			change: func(_ *testing.T, engine *composetest.Engine, _ string) {
+				engine.RemoveContainer("compak-demo", "web")
+			},
+			expected: []string{"missing web"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manager, engine, packageDir := setupDriftPackage(t)
+			tt.change(t, engine, packageDir)
+
+			report, err := manager.Diff(context.Background(), "demo")
+			if err != nil {
+				t.Fatalf("Expected no error but got: %v", err)
+			}
+
+			var got []string
+			for _, drift := range report.Drifts {
+				got = append(got, drift.Kind+" "+drift.Target)
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("Expected drift %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestManagerRepair(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("restores values and containers", func(t *testing.T) {
+		manager, engine, packageDir := setupDriftPackage(t)
+		if err := os.WriteFile(filepath.Join(packageDir, ".env"), []byte("GREETING=bye\n"), 0o600); err != nil {
+			t.Fatalf("Expected no error but got: %v", err)
+		}
+		engine.RemoveContainer("compak-demo", "web")
+
+		if _, err := manager.Repair(ctx, "demo", RepairOptions{}); err != nil {
+			t.Fatalf("Expected no error but got: %v", err)
+		}
+		report, err := manager.Diff(ctx, "demo")
+		if err != nil || !report.Clean() {
+			t.Errorf("Expected no drift after repair, got %v (%v)", report.Drifts, err)
+		}
+	})
+
+	t.Run("rewrites a deleted env file", func(t *testing.T) {
+		for _, accept := range []bool{false, true} {
+			manager, _, packageDir := setupDriftPackage(t)
+			if err := os.Remove(filepath.Join(packageDir, ".env")); err != nil {
+				t.Fatalf("Expected no error but got: %v", err)
+			}
+
+			report, err := manager.Diff(ctx, "demo")
+			expected := []Drift{
+				{Kind: DriftValue, Target: ".env", Detail: "missing or unreadable"},
+				{Kind: DriftConfig, Target: "web", Detail: "container was created from a different configuration"},
+			}
+			if err != nil || !reflect.DeepEqual(report.Drifts, expected) {
+				t.Fatalf("Expected value and config drift for the missing env file, got %v (%v)", report.Drifts, err)
+			}
+			if _, err := manager.Repair(ctx, "demo", RepairOptions{Accept: accept}); err != nil {
+				t.Fatalf("accept=%v: expected no error but got: %v", accept, err)
+			}
+			if report, err := manager.Diff(ctx, "demo"); err != nil || !report.Clean() {
+				t.Errorf("accept=%v: expected no drift after repair, got %v (%v)", accept, report.Drifts, err)
+			}
+		}
+	})
+
+	t.Run("round-trips special characters", func(t *testing.T) {
+		manager, _, _ := setupDriftPackage(t)
+		installed, err := manager.client.GetInstalledPackage("demo")
+		if err != nil {
+			t.Fatalf("Expected no error but got: %v", err)
+		}
+		installed.Values = map[string]string{"GREETING": "pa$$word #1"}
+		if err := manager.client.saveInstalledPackage(installed); err != nil {
+			t.Fatalf("Expected no error but got: %v", err)
+		}
+
+		if _, err := manager.Repair(ctx, "demo", RepairOptions{}); err != nil {
+			t.Fatalf("Expected no error but got: %v", err)
+		}
+		if report, err := manager.Diff(ctx, "demo"); err != nil || !report.Clean() {
+			t.Errorf("Expected no drift after repair, got %v (%v)", report.Drifts, err)
+		}
+	})
+
+	t.Run("keeps stopped packages stopped", func(t *testing.T) {
+		manager, engine, packageDir := setupDriftPackage(t)
+		if err := manager.Stop(ctx, "demo", nil); err != nil {
+			t.Fatalf("Expected no error but got: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(packageDir, ".env"), []byte("GREETING=bye\n"), 0o600); err != nil {
+			t.Fatalf("Expected no error but got: %v", err)
+		}
+
+		ups := engine.Count(composetest.MethodUp)
+		if _, err := manager.Repair(ctx, "demo", RepairOptions{}); err != nil {
+			t.Fatalf("Expected no error but got: %v", err)
+		}
+		if engine.Count(composetest.MethodUp) != ups || engine.State("compak-demo", "web") != "exited" {
+			t.Error("Expected repair not to start a stopped package")
+		}
+		installed, err := manager.client.GetInstalledPackage("demo")
+		if err != nil || installed.Status != "stopped" {
+			t.Errorf("Expected status stopped, got %+v (%v)", installed, err)
+		}
+		if env, err := os.ReadFile(filepath.Join(packageDir, ".env")); err != nil || string(env) != "GREETING=hello\n" {
+			t.Errorf("Expected .env to be rewritten, got %q (%v)", env, err)
+		}
+	})
+
+	t.Run("refuses modified compose files", func(t *testing.T) {
+		manager, engine, packageDir := setupDriftPackage(t)
+		if err := os.WriteFile(filepath.Join(packageDir, testComposeFilename), []byte(driftComposeFile+"    restart: always\n"), 0o600); err != nil {
+			t.Fatalf("Expected no error but got: %v", err)
+		}
+
+		ups := engine.Count(composetest.MethodUp)
+		if _, err := manager.Repair(ctx, "demo", RepairOptions{}); err == nil {
+			t.Error("Expected error repairing a modified compose file")
+		}
+		if engine.Count(composetest.MethodUp) != ups {
+			t.Error("Expected no Up call after a refused repair")
+		}
+	})
+
+	t.Run("accepts disk state", func(t *testing.T) {
+		manager, _, packageDir := setupDriftPackage(t)
+		if err := os.WriteFile(filepath.Join(packageDir, testComposeFilename), []byte(driftComposeFile+"    restart: always\n"), 0o600); err != nil {
+			t.Fatalf("Expected no error but got: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(packageDir, ".env"), []byte("GREETING=bye\n"), 0o600); err != nil {
+			t.Fatalf("Expected no error but got: %v", err)
+		}
+
+		if _, err := manager.Repair(ctx, "demo", RepairOptions{Accept: true}); err != nil {
+			t.Fatalf("Expected no error but got: %v", err)
+		}
+		report, err := manager.Diff(ctx, "demo")
+		if err != nil || !report.Clean() {
+			t.Errorf("Expected no drift after accepting, got %v (%v)", report.Drifts, err)
+		}
+
+		installed, err := manager.client.GetInstalledPackage("demo")
+		if err != nil {
+			t.Fatalf("Expected no error but got: %v", err)
+		}
+		if installed.Values["GREETING"] != "bye" {
+			t.Errorf("Expected accepted value to be stored, got %v", installed.Values)
+		}
+	})
+}
+
+func TestValueDriftAcceptsUnquotedEnvFiles(t *testing.T) {
+	packageDir := t.TempDir()
+	stored := map[string]string{"GREETING": "hello", "PASSWORD": "pa$$word #1", "TOKEN": "a$b"}
+	env := "GREETING=hello\nPASSWORD=pa$$word #1\nTOKEN=a$b\n"
+	if err := os.WriteFile(filepath.Join(packageDir, ".env"), []byte(env), 0o600); err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+
+	if drifts := valueDrift(packageDir, stored); len(drifts) != 0 {
+		t.Errorf("Expected no drift for an env file written without quoting, got %v", drifts)
+	}
+
+	installed := InstalledPackage{Values: stored}
+	if err := acceptDiskState(packageDir, &installed); err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+	if !reflect.DeepEqual(installed.Values, stored) {
+		t.Errorf("Expected values %v to be kept, got %v", stored, installed.Values)
+	}
+}
@@ -0,0 +1,77 @@
+package pkg
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func (m *Manager) DryRun(pkg Package, values map[string]string, opts DeployOptions) error {
+	return m.prepareTemporary(pkg, values, opts, func(d *deployment, tempDir string) error {
+		return m.reportDryRun(pkg, d, tempDir, opts)
+	})
+}
+
+func (m *Manager) CheckPolicy(pkg Package, values map[string]string, opts DeployOptions) error {
+	return m.prepareTemporary(pkg, values, opts, func(d *deployment, _ string) error {
+		return enforcePolicy(pkg.Name, d.project, opts)
+	})
+}
+
+func (m *Manager) prepareTemporary(pkg Package, values map[string]string, opts DeployOptions, check func(d *deployment, tempDir string) error) (err error) {
+	if err := m.validateDeployment(pkg, opts); err != nil {
+		return err
+	}
+
+	tempDir, err := os.MkdirTemp("", "compak-dry-run-")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	defer func() {
+		if removeErr := os.RemoveAll(tempDir); removeErr != nil && err == nil {
+			err = removeErr
+		}
+	}()
+
+	d, err := m.prepareDeployment(pkg, values, opts, tempDir, filepath.Join(m.packagesDir, pkg.Name))
+	if err != nil {
+		return err
+	}
+	return check(d, tempDir)
+}
+
+func (m *Manager) reportDryRun(pkg Package, d *deployment, tempDir string, opts DeployOptions) error {
+	config, err := d.project.MarshalYAML()
+	if err != nil {
+		return fmt.Errorf("failed to render compose config: %w", err)
+	}
+
+	fmt.Printf("# Compose configuration for %s (project %s)\n", pkg.Name, d.project.Name)
+	fmt.Print(string(config))
+
+	fmt.Println("\nImages:")
+	for _, name := range d.project.ServiceNames() {
+		image := d.project.Services[name].Image
+		if image == "" {
+			image = "(built locally)"
+		}
+		fmt.Printf("  %s\t%s\n", name, image)
+	}
+	fmt.Println()
+
+	var problems []string
+	if err := enforcePolicy(pkg.Name, d.project, opts); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if _, err := m.checkPortConflicts(pkg, d, tempDir, opts); err != nil {
+		problems = append(problems, err.Error())
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("dry run found problems:\n%s", strings.Join(problems, "\n"))
+	}
+
+	fmt.Printf("Dry run complete: %s would be deployed; nothing was pulled, started or saved\n", pkg.Name)
+	return nil
+}
@@ -0,0 +1,70 @@
+package pkg
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/LoriKarikari/compak/internal/core/template"
+)
+
+func packageFiles(pkg Package, opts DeployOptions, composeFiles []string) ([]string, error) {
+	files := append([]string{".env"}, composeFiles...)
+
+	if opts.SourcePath != "" {
+		err := filepath.WalkDir(opts.SourcePath, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil || entry.IsDir() {
+				return err
+			}
+			rel, err := filepath.Rel(opts.SourcePath, path)
+			if err != nil {
+				return err
+			}
+			files = append(files, renderedFiles(pkg, filepath.ToSlash(rel))...)
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list package files: %w", err)
+		}
+	}
+	for _, asset := range pkg.Assets {
+		files = append(files, renderedFiles(pkg, filepath.ToSlash(filepath.Clean(asset.Path)))...)
+	}
+
+	slices.Sort(files)
+	return slices.Compact(files), nil
+}
+
+func renderedFiles(pkg Package, path string) []string {
+	if rendered, ok := strings.CutSuffix(path, template.TemplateExt); ok && pkg.Render {
+		return []string{path, rendered}
+	}
+	return []string{path}
+}
+
+func previousFiles(installed InstalledPackage) []string {
+	if len(installed.Files) > 0 {
+		return installed.Files
+	}
+
+	files := append([]string{".env"}, installed.ComposeFiles...)
+	for _, asset := range installed.Package.Assets {
+		files = append(files, renderedFiles(installed.Package, filepath.ToSlash(filepath.Clean(asset.Path)))...)
+	}
+	return files
+}
+
+func removePackageFiles(packageDir string, installed InstalledPackage) error {
+	for _, file := range previousFiles(installed) {
+		if !filepath.IsLocal(file) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(packageDir, filepath.FromSlash(file))); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove %s from the previous install: %w", file, err)
+		}
+	}
+	return nil
+}
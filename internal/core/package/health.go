@@ -0,0 +1,96 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/docker/compose/v2/pkg/api"
+)
+
+const DefaultWaitTimeout = 5 * time.Minute
+
+var healthPollInterval = 2 * time.Second
+
+func (m *Manager) WaitHealthy(ctx context.Context, project *types.Project, timeout time.Duration) error {
+	if timeout <= 0 {
+		timeout = DefaultWaitTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(healthPollInterval)
+	defer ticker.Stop()
+
+	for {
+		pending, err := m.pendingServices(ctx, project)
+		if err == nil && len(pending) == 0 {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			if err != nil {
+				return fmt.Errorf("services not ready after %s: %w", timeout, err)
+			}
+			return fmt.Errorf("services not ready after %s: %s", timeout, strings.Join(pending, ", "))
+		case <-ticker.C:
+		}
+	}
+}
+
+func (m *Manager) pendingServices(ctx context.Context, project *types.Project) ([]string, error) {
+	containers, err := m.engine.PS(ctx, project)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to get container status: %w", err)
+	}
+
+	byService := make(map[string][]api.ContainerSummary)
+	for _, container := range containers {
+		byService[container.Service] = append(byService[container.Service], container)
+	}
+
+	var pending []string
+	for _, name := range project.ServiceNames() {
+		service := project.Services[name]
+		if service.GetScale() == 0 {
+			continue
+		}
+		if problem := serviceProblem(service, byService[name]); problem != "" {
+			pending = append(pending, fmt.Sprintf("%s (%s)", name, problem))
+		}
+	}
+	return pending, nil
+}
+
+func serviceProblem(service types.ServiceConfig, containers []api.ContainerSummary) string {
+	if len(containers) == 0 {
+		return "not created"
+	}
+	for _, container := range containers {
+		if container.State == "exited" && container.ExitCode == 0 && runsOnce(service) {
+			continue
+		}
+		switch {
+		case container.State != "running":
+			return container.State
+		case container.Health != "" && container.Health != "healthy":
+			return container.Health
+		}
+	}
+	return ""
+}
+
+func runsOnce(service types.ServiceConfig) bool {
+	restart := service.Restart
+	if service.Deploy != nil && service.Deploy.RestartPolicy != nil {
+		restart = service.Deploy.RestartPolicy.Condition
+	}
+	return restart == "" || restart == "no" || restart == "none" || strings.HasPrefix(restart, "on-failure")
+}
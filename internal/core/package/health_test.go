@@ -0,0 +1,87 @@
+package pkg
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/docker/compose/v2/pkg/api"
+
+	"github.com/LoriKarikari/compak/internal/core/compose/composetest"
+)
+
+func TestServiceProblem(t *testing.T) {
+	tests := []struct {
+		name       string
+		restart    string
+		containers []api.ContainerSummary
+		expected   string
+	}{
+		{name: "missing", expected: "not created"},
+		{name: "running", containers: []api.ContainerSummary{{State: "running"}}},
+		{name: "healthy", containers: []api.ContainerSummary{{State: "running", Health: "healthy"}}},
+		{name: "starting", containers: []api.ContainerSummary{{State: "running", Health: "starting"}}, expected: "starting"},
+		{name: "unhealthy replica", containers: []api.ContainerSummary{{State: "running"}, {State: "running", Health: "unhealthy"}}, expected: "unhealthy"},
+		{name: "completed", containers: []api.ContainerSummary{{State: "exited", ExitCode: 0}}},
+		{name: "completed long-running", restart: "unless-stopped", containers: []api.ContainerSummary{{State: "exited", ExitCode: 0}}, expected: "exited"},
+		{name: "crashed", containers: []api.ContainerSummary{{State: "exited", ExitCode: 1}}, expected: "exited"},
+		{name: "restarting", containers: []api.ContainerSummary{{State: "restarting"}}, expected: "restarting"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := serviceProblem(types.ServiceConfig{Restart: tt.restart}, tt.containers); got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestWaitHealthy(t *testing.T) {
+	previous := healthPollInterval
+	healthPollInterval = 10 * time.Millisecond
+	t.Cleanup(func() { healthPollInterval = previous })
+
+	project := &types.Project{Name: "compak-demo", Services: types.Services{
+		"web": {Name: "web", Image: "nginx:alpine"},
+		"db":  {Name: "db", Image: "postgres:16"},
+	}}
+
+	tests := []struct {
+		name    string
+		health  string
+		recover bool
+		wantErr string
+	}{
+		{name: "no healthcheck"},
+		{name: "becomes healthy", health: "starting", recover: true},
+		{name: "stays unhealthy", health: "unhealthy", wantErr: "db (unhealthy), web (unhealthy)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine := composetest.NewEngine()
+			engine.HealthNext(tt.health)
+			if err := engine.Up(context.Background(), project, true, nil); err != nil {
+				t.Fatalf("Expected no error but got: %v", err)
+			}
+			if tt.recover {
+				time.AfterFunc(30*time.Millisecond, func() {
+					_ = engine.SetHealth(project.Name, "web", "healthy")
+					_ = engine.SetHealth(project.Name, "db", "healthy")
+				})
+			}
+
+			manager := NewManager(NewClient(t.TempDir()), engine, t.TempDir())
+			err := manager.WaitHealthy(context.Background(), project, 200*time.Millisecond)
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("Expected no error but got: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("Expected error containing %q, got: %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"gopkg.in/yaml.v3"
+
+	"github.com/LoriKarikari/compak/internal/core/compose"
+)
+
+const imagePinsFile = ".compak-images.yaml"
+
+func imageServices(project *types.Project) []string {
+	var services []string
+	for _, name := range project.ServiceNames() {
+		if project.Services[name].Image != "" {
+			services = append(services, name)
+		}
+	}
+	slices.Sort(services)
+	return services
+}
+
+func (m *Manager) resolveImages(ctx context.Context, project *types.Project) (map[string]string, error) {
+	services := imageServices(project)
+
+	var images []string
+	for _, service := range services {
+		if image := project.Services[service].Image; !slices.Contains(images, image) {
+			images = append(images, image)
+		}
+	}
+
+	digests, err := m.engine.ImageDigests(ctx, images...)
+	if err != nil {
+		return nil, err
+	}
+
+	pinned := make(map[string]string, len(services))
+	for _, service := range services {
+		image := project.Services[service].Image
+		pinned[service] = compose.PinImage(image, digests[image])
+	}
+	return pinned, nil
+}
+
+func (m *Manager) recordImages(ctx context.Context, project *types.Project, opts DeployOptions) (map[string]string, error) {
+	images, err := m.resolveImages(ctx, project)
+	switch {
+	case err != nil && (opts.Lock != nil || opts.PinImages):
+		return nil, fmt.Errorf("failed to resolve image digests: %w", err)
+	case err != nil:
+		fmt.Printf("Warning: failed to resolve image digests: %v\n", err)
+		return nil, nil
+	case opts.Lock != nil:
+		return images, opts.Lock.verifyImages(images)
+	}
+	return images, nil
+}
+
+func (m *Manager) pinImages(packageDir, name string, d *deployment, images map[string]string) error {
+	if len(images) == 0 {
+		return nil
+	}
+
+	pins, err := writeImagePins(packageDir, images)
+	if err != nil {
+		return err
+	}
+
+	composeFiles := append(slices.Clone(d.composeFiles), pins)
+	project, err := m.loadProject(packageDir, projectName(name), composeFiles...)
+	if err != nil {
+		return fmt.Errorf("failed to load compose project: %w", err)
+	}
+
+	d.composeFiles, d.project = composeFiles, project
+	return nil
+}
+
+func ImageDigest(image string) string {
+	if _, digest, found := strings.Cut(image, "@"); found {
+		return digest
+	}
+	return ""
+}
+
+func writeImagePins(packageDir string, images map[string]string) (string, error) {
+	services := make(map[string]map[string]string, len(images))
+	for service, image := range images {
+		services[service] = map[string]string{"image": image}
+	}
+
+	data, err := yaml.Marshal(map[string]any{"services": services})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode image pins: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(packageDir, imagePinsFile), data, 0o600); err != nil {
+		return "", fmt.Errorf("failed to write image pins: %w", err)
+	}
+	return imagePinsFile, nil
+}
+
+func composeDigest(packageDir string, composeFiles []string, sourceDigest string) (string, error) {
+	if sourceDigest != "" {
+		return sourceDigest, nil
+	}
+
+	contents := make([][]byte, 0, len(composeFiles))
+	for _, file := range composeFiles {
+		data, err := os.ReadFile(filepath.Join(packageDir, file))
+		if err != nil {
+			return "", fmt.Errorf("failed to read compose file: %w", err)
+		}
+		contents = append(contents, data)
+	}
+	return SourcesDigest(contents), nil
+}
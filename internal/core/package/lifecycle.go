@@ -0,0 +1,126 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/docker/compose/v2/pkg/api"
+)
+
+func (m *Manager) Stop(ctx context.Context, packageName string, services []string) error {
+	project, err := m.resolveProject(packageName, services)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Stopping %s...\n", describeTarget(packageName, services))
+	if err := m.engine.Stop(ctx, project, services); err != nil {
+		return fmt.Errorf("failed to stop %s: %w", packageName, err)
+	}
+
+	return m.updateLifecycleStatus(packageName, services, "stopped")
+}
+
+func (m *Manager) Start(ctx context.Context, packageName string, services []string) error {
+	project, err := m.resolveProject(packageName, services)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Starting %s...\n", describeTarget(packageName, services))
+	if err := m.engine.Start(ctx, project, services); err != nil {
+		return fmt.Errorf("failed to start %s: %w", packageName, err)
+	}
+
+	return m.updateLifecycleStatus(packageName, services, "installed")
+}
+
+func (m *Manager) Restart(ctx context.Context, packageName string, services []string) error {
+	project, err := m.resolveProject(packageName, services)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Restarting %s...\n", describeTarget(packageName, services))
+	if err := m.engine.Restart(ctx, project, services); err != nil {
+		return fmt.Errorf("failed to restart %s: %w", packageName, err)
+	}
+
+	return m.updateLifecycleStatus(packageName, services, "installed")
+}
+
+func (m *Manager) Down(ctx context.Context, packageName string) error {
+	installedPkg, err := m.client.GetInstalledPackage(packageName)
+	if err != nil {
+		return fmt.Errorf("package %s is not installed", packageName)
+	}
+	if _, err := os.Stat(filepath.Join(m.packagesDir, packageName)); os.IsNotExist(err) {
+		return nil
+	}
+
+	project, err := m.installedProject(installedPkg)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Stopping %s...\n", packageName)
+	if err := m.engine.Down(ctx, project, api.DownOptions{}); err != nil {
+		return fmt.Errorf("failed to stop services: %w", err)
+	}
+	return nil
+}
+
+func (m *Manager) updateLifecycleStatus(packageName string, services []string, status string) error {
+	if len(services) > 0 {
+		return nil
+	}
+
+	installedPkg, err := m.client.GetInstalledPackage(packageName)
+	if err != nil {
+		return err
+	}
+	if installedPkg.Status == "failed" {
+		return nil
+	}
+	return m.client.setStatus(packageName, status)
+}
+
+func (m *Manager) resolveProject(packageName string, services []string) (*types.Project, error) {
+	if err := validatePackageName(packageName); err != nil {
+		return nil, fmt.Errorf("invalid package name: %w", err)
+	}
+
+	installedPkg, err := m.client.GetInstalledPackage(packageName)
+	if err != nil {
+		return nil, fmt.Errorf("package %s is not installed", packageName)
+	}
+
+	packageDir := filepath.Join(m.packagesDir, packageName)
+	if _, err := os.Stat(packageDir); os.IsNotExist(err) {
+		return nil, fmt.Errorf("package directory for %s not found", packageName)
+	}
+
+	project, err := m.installedProject(installedPkg)
+	if err != nil {
+		return nil, err
+	}
+	available := project.ServiceNames()
+	for _, service := range services {
+		if len(available) > 0 && !slices.Contains(available, service) {
+			return nil, fmt.Errorf("package %s has no service %q (available: %s)", packageName, service, strings.Join(available, ", "))
+		}
+	}
+	return project, nil
+}
+
+func describeTarget(packageName string, services []string) string {
+	if len(services) == 0 {
+		return packageName
+	}
+	return fmt.Sprintf("%s (%s)", packageName, strings.Join(services, ", "))
+}
@@ -0,0 +1,36 @@
+package pkg
+
+import (
+	"context"
+	"testing"
+
+	"github.com/LoriKarikari/compak/internal/core/compose/composetest"
+)
+
+func TestLifecycleKeepsFailedStatus(t *testing.T) {
+	engine := composetest.NewEngine()
+	manager, _ := setupRunningPackage(t, engine, InstalledPackage{Package: Package{Name: "demo", Version: "1.0.0"}}, map[string]string{
+		testComposeFilename: "services:\n  web:\n    image: nginx:alpine\n",
+	})
+	if err := manager.client.setStatus("demo", "failed"); err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+
+	ctx := context.Background()
+	for name, action := range map[string]func(*Manager, context.Context, string, []string) error{
+		"stop":    (*Manager).Stop,
+		"start":   (*Manager).Start,
+		"restart": (*Manager).Restart,
+	} {
+		if err := action(manager, ctx, "demo", nil); err != nil {
+			t.Fatalf("%s failed: %v", name, err)
+		}
+		installed, err := manager.client.GetInstalledPackage("demo")
+		if err != nil {
+			t.Fatalf("Expected no error but got: %v", err)
+		}
+		if installed.Status != "failed" {
+			t.Errorf("%s: expected status failed, got %s", name, installed.Status)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package pkg
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"gopkg.in/yaml.v3"
+)
+
+const LockFile = "compak.lock"
+
+type Lock struct {
+	Packages map[string]LockEntry `yaml:"packages"`
+}
+
+type LockEntry struct {
+	Pak           string            `yaml:"pak,omitempty"`
+	Version       string            `yaml:"version"`
+	IndexCommit   string            `yaml:"index_commit,omitempty"`
+	Source        Sources           `yaml:"source,omitempty"`
+	ComposeDigest string            `yaml:"compose_sha256"`
+	Images        map[string]string `yaml:"images,omitempty"`
+
+	composeUnchecked bool
+}
+
+func LoadLock(path string) (*Lock, error) {
+	lock := &Lock{}
+	data, err := os.ReadFile(filepath.Clean(path))
+	switch {
+	case os.IsNotExist(err):
+	case err != nil:
+		return nil, fmt.Errorf("failed to read lockfile: %w", err)
+	default:
+		if err := yaml.Unmarshal(data, lock); err != nil {
+			return nil, fmt.Errorf("failed to parse lockfile %s: %w", path, err)
+		}
+	}
+
+	if lock.Packages == nil {
+		lock.Packages = make(map[string]LockEntry)
+	}
+	return lock, nil
+}
+
+func (l *Lock) Save(path string) error {
+	data, err := yaml.Marshal(l)
+	if err != nil {
+		return fmt.Errorf("failed to encode lockfile: %w", err)
+	}
+	return os.WriteFile(path, data, 0o600)
+}
+
+func NewLockEntry(installed InstalledPackage) LockEntry {
+	pak := installed.Pak
+	if pak == "" && installed.IndexCommit != "" {
+		pak = installed.Package.Name
+	}
+
+	return LockEntry{
+		Pak:           pak,
+		Version:       installed.Package.Version,
+		IndexCommit:   installed.IndexCommit,
+		Source:        installed.Package.Source,
+		ComposeDigest: installed.ComposeDigest,
+		Images:        installed.Images,
+	}
+}
+
+func NewRollbackLockEntry(installed InstalledPackage) LockEntry {
+	entry := NewLockEntry(installed)
+	entry.composeUnchecked = true
+	return entry
+}
+
+func (e LockEntry) Verify(pkg Package) error {
+	var drift []string
+	if pkg.Version != e.Version {
+		drift = append(drift, fmt.Sprintf("version is %s, locked %s", pkg.Version, e.Version))
+	}
+	if !slices.Equal(pkg.Source, e.Source) {
+		drift = append(drift, fmt.Sprintf("source is %q, locked %q", pkg.Source.String(), e.Source.String()))
+	}
+
+	if len(drift) > 0 {
+		return fmt.Errorf("%s drifted from the lockfile: %s", pkg.Name, strings.Join(drift, "; "))
+	}
+	return nil
+}
+
+func (e LockEntry) verifyCompose(digest string) error {
+	if !e.composeUnchecked && !strings.EqualFold(e.ComposeDigest, digest) {
+		return fmt.Errorf("compose files drifted from the lockfile: locked %s, got %s", e.ComposeDigest, digest)
+	}
+	return nil
+}
+
+func (e LockEntry) verifyServices(project *types.Project) error {
+	if len(e.Images) == 0 {
+		return fmt.Errorf("lockfile has no image digests to reproduce")
+	}
+
+	services := slices.Sorted(maps.Keys(e.Images))
+	if current := imageServices(project); !slices.Equal(services, current) {
+		return fmt.Errorf("services drifted from the lockfile: locked %s, got %s", strings.Join(services, ", "), strings.Join(current, ", "))
+	}
+	return nil
+}
+
+func (e LockEntry) verifyImages(images map[string]string) error {
+	for _, service := range slices.Sorted(maps.Keys(e.Images)) {
+		if images[service] != e.Images[service] {
+			return fmt.Errorf("image of %s drifted from the lockfile: locked %s, got %s", service, e.Images[service], images[service])
+		}
+	}
+	return nil
+}
+
+func (c *Client) lockPath() string {
+	return filepath.Join(c.stateDir, LockFile)
+}
+
+func (c *Client) updateLock(name string, entry LockEntry) error {
+	lock, err := LoadLock(c.lockPath())
+	if err != nil {
+		return err
+	}
+
+	lock.Packages[name] = entry
+	if err := lock.Save(c.lockPath()); err != nil {
+		return fmt.Errorf("failed to update lockfile: %w", err)
+	}
+	return nil
+}
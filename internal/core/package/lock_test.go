@@ -0,0 +1,219 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/LoriKarikari/compak/internal/core/compose/composetest"
+)
+
+func TestLockRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), LockFile)
+
+	lock, err := LoadLock(path)
+	if err != nil || len(lock.Packages) != 0 {
+		t.Fatalf("Expected an empty lock for a missing file, got %+v (%v)", lock, err)
+	}
+
+	lock.Packages["blog"] = LockEntry{
+		Pak:           "wordpress",
+		Version:       "6.4.2",
+		IndexCommit:   "0123abcd",
+		Source:        Sources{"https://example.com/compose.yaml"},
+		ComposeDigest: "sha256:abc",
+		Images:        map[string]string{"web": "wordpress:6.4@sha256:def"},
+	}
+	if err := lock.Save(path); err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+
+	loaded, err := LoadLock(path)
+	if err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+	if !reflect.DeepEqual(loaded, lock) {
+		t.Errorf("Expected %+v, got %+v", lock, loaded)
+	}
+}
+
+func TestNewLockEntry(t *testing.T) {
+	tests := []struct {
+		name      string
+		installed InstalledPackage
+		pak       string
+	}{
+		{name: "index", installed: InstalledPackage{Package: Package{Name: "nginx"}, IndexCommit: "abc"}, pak: "nginx"},
+		{name: "instance", installed: InstalledPackage{Package: Package{Name: "blog"}, Pak: "wordpress", IndexCommit: "abc"}, pak: "wordpress"},
+		{name: "local", installed: InstalledPackage{Package: Package{Name: "demo"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if entry := NewLockEntry(tt.installed); entry.Pak != tt.pak {
+				t.Errorf("Expected pak %q, got %q", tt.pak, entry.Pak)
+			}
+		})
+	}
+}
+
+func TestLockEntryVerify(t *testing.T) {
+	entry := LockEntry{Version: "1.0.0", Source: Sources{"https://example.com/a.yaml"}}
+
+	tests := []struct {
+		name    string
+		pkg     Package
+		wantErr string
+	}{
+		{name: "match", pkg: Package{Name: "demo", Version: "1.0.0", Source: Sources{"https://example.com/a.yaml"}}},
+		{name: "version", pkg: Package{Name: "demo", Version: "1.0.1", Source: Sources{"https://example.com/a.yaml"}}, wantErr: "version is 1.0.1, locked 1.0.0"},
+		{name: "source", pkg: Package{Name: "demo", Version: "1.0.0", Source: Sources{"https://example.com/b.yaml"}}, wantErr: "source is"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := entry.Verify(tt.pkg)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Expected no error but got: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestClientMaintainsLock(t *testing.T) {
+	stateDir := t.TempDir()
+	client := NewClient(stateDir)
+
+	installed := InstalledPackage{Package: Package{Name: "demo", Version: "1.0.0"}, ComposeDigest: "sha256:abc"}
+	if err := client.install(installed); err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+
+	lock, err := LoadLock(filepath.Join(stateDir, LockFile))
+	if err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+	if entry := lock.Packages["demo"]; entry.Version != "1.0.0" || entry.ComposeDigest != "sha256:abc" {
+		t.Errorf("Expected demo to be locked, got %+v", lock.Packages)
+	}
+
+	if err := client.Uninstall("demo"); err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+	if lock, err = LoadLock(filepath.Join(stateDir, LockFile)); err != nil || lock.Packages["demo"].Version != "1.0.0" {
+		t.Errorf("Expected demo to stay locked for reinstalls, got %+v (%v)", lock, err)
+	}
+}
+
+func TestDeployWithLock(t *testing.T) {
+	source := t.TempDir()
+	if err := os.WriteFile(filepath.Join(source, testComposeFilename), []byte(driftComposeFile), 0o600); err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+
+	stateDir := t.TempDir()
+	client := NewClient(stateDir)
+	engine := composetest.NewEngine()
+	manager := NewManager(client, engine, stateDir)
+	demo := Package{Name: "demo", Version: "1.0.0"}
+
+	if err := manager.DeployWithOptions(demo, nil, DeployOptions{SourcePath: source}); err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+	installed, err := client.GetInstalledPackage("demo")
+	if err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+	if installed.ComposeDigest == "" || !strings.HasPrefix(installed.Images["web"], "nginx:alpine@sha256:") {
+		t.Fatalf("Expected compose and image digests to be recorded, got %+v", installed)
+	}
+
+	lock := NewLockEntry(installed)
+	tests := []struct {
+		name    string
+		prepare func(entry *LockEntry)
+		wantErr string
+	}{
+		{name: "reproduce"},
+		{name: "compose drift", prepare: func(entry *LockEntry) { entry.ComposeDigest = "sha256:other" }, wantErr: "compose files drifted"},
+		{name: "service drift", prepare: func(entry *LockEntry) { entry.Images = map[string]string{"db": "postgres@sha256:1"} }, wantErr: "services drifted"},
+		{name: "no images", prepare: func(entry *LockEntry) { entry.Images = nil }, wantErr: "no image digests"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry := lock
+			entry.Images = map[string]string{"web": lock.Images["web"]}
+			if tt.prepare != nil {
+				tt.prepare(&entry)
+			}
+
+			err := manager.DeployWithOptions(demo, nil, DeployOptions{SourcePath: source, Lock: &entry})
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Expected no error but got: %v", err)
+				}
+				if image := engine.Project("compak-demo").Services["web"].Image; image != lock.Images["web"] {
+					t.Errorf("Expected web to run %s, got %s", lock.Images["web"], image)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestDeployPinImages(t *testing.T) {
+	source := t.TempDir()
+	if err := os.WriteFile(filepath.Join(source, testComposeFilename), []byte(driftComposeFile), 0o600); err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+
+	stateDir := t.TempDir()
+	client := NewClient(stateDir)
+	engine := composetest.NewEngine()
+	manager := NewManager(client, engine, stateDir)
+	demo := Package{Name: "demo", Version: "1.0.0"}
+
+	engine.Fail(composetest.MethodImages, errors.New("no registry digest"))
+	err := manager.DeployWithOptions(demo, nil, DeployOptions{SourcePath: source, PinImages: true})
+	if err == nil || engine.Running("compak-demo") {
+		t.Fatalf("Expected pinning to fail before starting, got %v", err)
+	}
+
+	engine.Fail(composetest.MethodImages, nil)
+	digest := "sha256:" + strings.Repeat("c", 64)
+	engine.SetDigest("nginx:alpine", digest)
+	if err := manager.DeployWithOptions(demo, nil, DeployOptions{SourcePath: source, PinImages: true}); err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+
+	if image := engine.Project("compak-demo").Services["web"].Image; image != "nginx:alpine@"+digest {
+		t.Errorf("Expected the running project to use the digest, got %s", image)
+	}
+	installed, err := client.GetInstalledPackage("demo")
+	if err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+	if !installed.ImagesPinned || ImageDigest(installed.Images["web"]) != digest || !slices.Contains(installed.ComposeFiles, imagePinsFile) {
+		t.Errorf("Expected pinned images to be recorded, got %+v", installed)
+	}
+
+	report, err := manager.Diff(context.Background(), "demo")
+	if err != nil || !report.Clean() {
+		t.Errorf("Expected a pinned install to have no drift, got %+v (%v)", report, err)
+	}
+}
@@ -0,0 +1,57 @@
+package pkg
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/docker/compose/v2/pkg/api"
+)
+
+func (m *Manager) Logs(ctx context.Context, packageName string, consumer api.LogConsumer, options api.LogOptions) error {
+	project, err := m.resolveProject(packageName, options.Services)
+	if err != nil {
+		return err
+	}
+
+	options.Project = project
+	return m.engine.Logs(ctx, project, &serviceConsumer{project: project.Name, services: project.ServiceNames(), next: consumer}, options)
+}
+
+type serviceConsumer struct {
+	project  string
+	services []string
+	next     api.LogConsumer
+}
+
+func (c *serviceConsumer) Log(container, message string) {
+	c.next.Log(c.service(container), message)
+}
+
+func (c *serviceConsumer) Err(container, message string) {
+	c.next.Err(c.service(container), message)
+}
+
+func (c *serviceConsumer) Status(container, message string) {
+	c.next.Status(c.service(container), message)
+}
+
+func (c *serviceConsumer) service(container string) string {
+	name := container
+	for _, separator := range []string{"-", "_"} {
+		if trimmed, ok := strings.CutPrefix(name, c.project+separator); ok {
+			name = trimmed
+			break
+		}
+	}
+	if i := strings.LastIndexAny(name, "-_"); i > 0 {
+		if _, err := strconv.Atoi(name[i+1:]); err == nil {
+			name = name[:i]
+		}
+	}
+	if slices.Contains(c.services, name) {
+		return name
+	}
+	return container
+}
@@ -0,0 +1,29 @@
+package pkg
+
+import "testing"
+
+func TestServiceConsumerResolvesService(t *testing.T) {
+	consumer := &serviceConsumer{project: "compak-demo", services: []string{"web", "web-api"}}
+
+	tests := []struct {
+		container string
+		expected  string
+	}{
+		{container: "web-1", expected: "web"},
+		{container: "web-api-2", expected: "web-api"},
+		{container: "compak-demo-web-1", expected: "web"},
+		{container: "compak-demo-web-api-2", expected: "web-api"},
+		{container: "compak-demo_web_1", expected: "web"},
+		{container: "something-else", expected: "something-else"},
+		{container: "compak-demo", expected: "compak-demo"},
+		{container: "db-1", expected: "db-1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.container, func(t *testing.T) {
+			if got := consumer.service(tt.container); got != tt.expected {
+				t.Errorf("Expected service %s, got %s", tt.expected, got)
+			}
+		})
+	}
+}
@@ -11,23 +11,31 @@ import (
 	"strings"
 	"time"
 
+	"github.com/compose-spec/compose-go/v2/types"
 	"gopkg.in/yaml.v3"
 
 	"github.com/LoriKarikari/compak/internal/core/compose"
 	"github.com/LoriKarikari/compak/internal/core/template"
 )
 
+const (
+	defaultComposeFile = "docker-compose.yaml"
+	maxDownloadSize    = 50 * 1024 * 1024
+)
+
 type Manager struct {
-	client        *Client
-	composeClient *compose.Client
-	packagesDir   string
+	client      *Client
+	engine      compose.Engine
+	packagesDir string
+	trashDir    string
 }
 
-func NewManager(client *Client, composeClient *compose.Client, stateDir string) *Manager {
+func NewManager(client *Client, engine compose.Engine, stateDir string) *Manager {
 	return &Manager{
-		client:        client,
-		composeClient: composeClient,
-		packagesDir:   filepath.Join(stateDir, "packages"),
+		client:      client,
+		engine:      engine,
+		packagesDir: filepath.Join(stateDir, "packages"),
+		trashDir:    filepath.Join(stateDir, "trash"),
 	}
 }
 
@@ -53,11 +61,15 @@ func validatePath(path string) error {
 }
 
 func (m *Manager) Deploy(pkg Package, values map[string]string) error {
-	return m.DeployFromPath(pkg, values, "")
+	return m.DeployWithOptions(pkg, values, DeployOptions{})
 }
 
 func (m *Manager) DeployFromPath(pkg Package, values map[string]string, sourcePath string) error {
-	if err := m.validatePackageAndPath(pkg.Name, sourcePath); err != nil {
+	return m.DeployWithOptions(pkg, values, DeployOptions{SourcePath: sourcePath})
+}
+
+func (m *Manager) DeployWithOptions(pkg Package, values map[string]string, opts DeployOptions) error {
+	if err := m.validateDeployment(pkg, opts); err != nil {
 		return err
 	}
 
@@ -65,135 +77,269 @@ func (m *Manager) DeployFromPath(pkg Package, values map[string]string, sourcePa
 	if err := os.MkdirAll(packageDir, 0o750); err != nil {
 		return fmt.Errorf("failed to create package directory: %w", err)
 	}
+	if previous, err := m.client.GetInstalledPackage(pkg.Name); err == nil {
+		if err := removePackageFiles(packageDir, previous); err != nil {
+			return err
+		}
+	}
 
-	mergedValues := m.client.mergeValues(pkg, values)
-	if err := m.setupPackageFiles(packageDir, sourcePath, pkg, mergedValues); err != nil {
+	d, err := m.prepareDeployment(pkg, values, opts, packageDir, packageDir)
+	if err != nil {
 		return err
 	}
 
-	ctx := context.Background()
-	projectName := fmt.Sprintf("compak-%s", pkg.Name)
+	if err := enforcePolicy(pkg.Name, d.project, opts); err != nil {
+		return err
+	}
 
-	project, err := m.composeClient.LoadProject(packageDir, projectName)
+	ports, err := m.checkPortConflicts(pkg, d, packageDir, opts)
 	if err != nil {
-		return fmt.Errorf("failed to load compose project: %w", err)
+		return err
 	}
 
+	ctx := context.Background()
+
 	fmt.Printf("Deploying %s...\n", pkg.Name)
 
-	if err := m.composeClient.Pull(ctx, project); err != nil {
+	if err := m.engine.Pull(ctx, d.project); err != nil {
 		fmt.Printf("Warning: failed to pull images: %v\n", err)
 	}
 
-	if err := m.composeClient.Up(ctx, project, true, nil); err != nil {
-		return fmt.Errorf("failed to start services: %w", err)
+	images, err := m.recordImages(ctx, d.project, opts)
+	if err != nil {
+		return err
 	}
 
-	return m.client.Install(pkg, mergedValues)
-}
+	if opts.PinImages && opts.Lock == nil {
+		if err := m.pinImages(packageDir, pkg.Name, d, images); err != nil {
+			return err
+		}
+	}
 
-func (m *Manager) validatePackageAndPath(packageName, sourcePath string) error {
-	if err := validatePackageName(packageName); err != nil {
-		return fmt.Errorf("invalid package name: %w", err)
+	checksums, err := fileChecksums(packageDir, d.composeFiles)
+	if err != nil {
+		return err
 	}
-	if sourcePath != "" {
-		if err := validatePath(sourcePath); err != nil {
-			return fmt.Errorf("invalid source path: %w", err)
-		}
+	files, err := packageFiles(pkg, opts, d.composeFiles)
+	if err != nil {
+		return err
 	}
-	return nil
-}
 
-func (m *Manager) setupPackageFiles(packageDir, sourcePath string, pkg Package, mergedValues map[string]string) error {
-	composePath := filepath.Join(packageDir, "docker-compose.yaml")
+	if err := m.engine.Up(ctx, d.project, true, nil); err != nil {
+		return fmt.Errorf("failed to start services: %w", err)
+	}
 
-	switch {
-	case sourcePath != "":
-		if err := copyDir(sourcePath, packageDir); err != nil {
-			return fmt.Errorf("failed to copy package files: %w", err)
-		}
-	case pkg.Source != "":
-		fmt.Printf("Downloading compose file from %s...\n", pkg.Source)
-		if err := downloadComposeFile(pkg.Source, composePath); err != nil {
-			return fmt.Errorf("failed to download compose file: %w", err)
-		}
-	default:
-		if err := m.writeComposeFile(composePath, pkg); err != nil {
-			return fmt.Errorf("failed to write compose file: %w", err)
+	installed := InstalledPackage{
+		Package:       pkg,
+		Pak:           opts.Pak,
+		Values:        d.values,
+		Overlays:      opts.Overlays,
+		ComposeFiles:  d.composeFiles,
+		SourceDigest:  d.sourceDigest,
+		IndexCommit:   opts.IndexCommit,
+		ComposeDigest: d.composeDigest,
+		Images:        images,
+		ImagesPinned:  opts.PinImages || opts.Lock != nil,
+		Ports:         ports,
+		Checksums:     checksums,
+		Files:         files,
+	}
+
+	if opts.Wait {
+		fmt.Printf("Waiting for %s to become healthy...\n", pkg.Name)
+		if err := m.WaitHealthy(ctx, d.project, opts.WaitTimeout); err != nil {
+			return m.client.fail(installed, fmt.Errorf("%s is not healthy: %w", pkg.Name, err))
 		}
 	}
 
-	engine := template.NewEngine(mergedValues)
-	if err := engine.WriteEnvFile(packageDir); err != nil {
-		return fmt.Errorf("failed to write env file: %w", err)
-	}
+	return m.client.install(installed)
+}
 
-	return nil
+type deployment struct {
+	project       *types.Project
+	composeFiles  []string
+	sourceDigest  string
+	composeDigest string
+	values        map[string]string
 }
 
-func (m *Manager) Stop(packageName string) error {
-	if err := validatePackageName(packageName); err != nil {
-		return fmt.Errorf("invalid package name: %w", err)
+func (m *Manager) prepareDeployment(pkg Package, values map[string]string, opts DeployOptions, packageDir, workingDir string) (*deployment, error) {
+	mergedValues := m.client.mergeValues(pkg, values)
+	if err := m.client.validateParameters(pkg.Parameters, mergedValues); err != nil {
+		return nil, fmt.Errorf("parameter validation failed: %w", err)
 	}
 
-	installedPkg, err := m.client.GetInstalledPackage(packageName)
+	composeFiles, sourceDigest, err := m.setupPackageFiles(packageDir, pkg, mergedValues, opts)
 	if err != nil {
-		return fmt.Errorf("package not found: %w", err)
+		return nil, err
 	}
 
-	packageDir := filepath.Join(m.packagesDir, packageName)
-	dirExists := true
-	if _, err := os.Stat(packageDir); os.IsNotExist(err) {
-		fmt.Printf("Warning: package directory not found, cleaning up metadata only\n")
-		dirExists = false
+	digest, err := composeDigest(packageDir, composeFiles, sourceDigest)
+	if err != nil {
+		return nil, err
 	}
 
-	if dirExists {
-		ctx := context.Background()
-		projectName := fmt.Sprintf("compak-%s", packageName)
+	userOverlays, err := writeOverlays(packageDir, opts.Overlays)
+	if err != nil {
+		return nil, err
+	}
+	composeFiles = append(composeFiles, pkg.Overlays...)
+	composeFiles = append(composeFiles, userOverlays...)
 
-		fmt.Printf("Stopping %s...\n", packageName)
-		if err := m.composeClient.Down(ctx, projectName); err != nil {
-			return fmt.Errorf("failed to stop services: %w", err)
+	if opts.Lock != nil {
+		if composeFiles, err = applyLock(packageDir, composeFiles, digest, *opts.Lock); err != nil {
+			return nil, err
 		}
+	}
+
+	var project *types.Project
+	if workingDir == packageDir {
+		project, err = m.loadProject(packageDir, projectName(pkg.Name), composeFiles...)
+	} else {
+		project, err = compose.LoadProjectIn(packageDir, workingDir, projectName(pkg.Name), composeFiles...)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load compose project: %w", err)
+	}
 
-		fmt.Printf("Cleaning up %s...\n", packageName)
-		if err := os.RemoveAll(packageDir); err != nil {
-			fmt.Printf("Warning: failed to remove package directory: %v\n", err)
+	if opts.Lock != nil {
+		if err := opts.Lock.verifyServices(project); err != nil {
+			return nil, err
 		}
 	}
 
-	return m.client.Uninstall(installedPkg.Package.Name)
+	return &deployment{
+		project:       project,
+		composeFiles:  composeFiles,
+		sourceDigest:  sourceDigest,
+		composeDigest: digest,
+		values:        mergedValues,
+	}, nil
 }
 
-func (m *Manager) Status(packageName string) (string, error) {
-	if err := validatePackageName(packageName); err != nil {
-		return "", fmt.Errorf("invalid package name: %w", err)
+func applyLock(packageDir string, composeFiles []string, digest string, lock LockEntry) ([]string, error) {
+	if err := lock.verifyCompose(digest); err != nil {
+		return nil, err
+	}
+
+	pins, err := writeImagePins(packageDir, lock.Images)
+	if err != nil {
+		return nil, err
 	}
+	return append(composeFiles, pins), nil
+}
 
-	packageDir := filepath.Join(m.packagesDir, packageName)
-	if _, err := os.Stat(packageDir); os.IsNotExist(err) {
-		return "", fmt.Errorf("package not found")
+func (m *Manager) loadProject(projectDir, name string, files ...string) (*types.Project, error) {
+	if m.engine == nil {
+		return compose.LoadProject(projectDir, name, files...)
 	}
+	return m.engine.LoadProject(projectDir, name, files...)
+}
 
-	ctx := context.Background()
-	projectName := fmt.Sprintf("compak-%s", packageName)
+func (m *Manager) installedProject(installed InstalledPackage) (*types.Project, error) {
+	name := installed.Package.Name
+	packageDir := filepath.Join(m.packagesDir, name)
 
-	containers, err := m.composeClient.PS(ctx, projectName)
+	project, err := m.loadProject(packageDir, projectName(name), installed.ComposeFiles...)
 	if err != nil {
-		return "", fmt.Errorf("failed to get status: %w", err)
+		return nil, fmt.Errorf("failed to load compose project for %s: %w", name, err)
 	}
+	return project, nil
+}
+
+func projectName(packageName string) string {
+	return fmt.Sprintf("compak-%s", packageName)
+}
 
-	var output string
-	for _, container := range containers {
-		output += fmt.Sprintf("%s\t%s\t%s\n",
-			container.Name,
-			container.State,
-			container.Status,
-		)
+func (m *Manager) validateDeployment(pkg Package, opts DeployOptions) error {
+	if err := m.validatePackageAndPath(pkg.Name, opts.SourcePath); err != nil {
+		return err
+	}
+	if err := validatePackageOverlays(pkg); err != nil {
+		return err
+	}
+	if err := validateSourceDigest(pkg); err != nil {
+		return err
+	}
+	if err := validateAssets(pkg.Assets); err != nil {
+		return fmt.Errorf("invalid assets: %w", err)
+	}
+	return nil
+}
+
+func (m *Manager) validatePackageAndPath(packageName, sourcePath string) error {
+	if err := validatePackageName(packageName); err != nil {
+		return fmt.Errorf("invalid package name: %w", err)
+	}
+	if sourcePath != "" {
+		if err := validatePath(sourcePath); err != nil {
+			return fmt.Errorf("invalid source path: %w", err)
+		}
+	}
+	return nil
+}
+
+func (m *Manager) setupPackageFiles(packageDir string, pkg Package, mergedValues map[string]string, opts DeployOptions) (composeFiles []string, sourceDigest string, err error) {
+	switch {
+	case opts.SourcePath != "":
+		if err := copyDir(opts.SourcePath, packageDir); err != nil {
+			return nil, "", fmt.Errorf("failed to copy package files: %w", err)
+		}
+	case len(pkg.Source) > 0:
+		composeFiles, sourceDigest, err = downloadSources(pkg, packageDir, opts.QuietUnpinned)
+		if err != nil {
+			return nil, "", err
+		}
+	default:
+		if err := m.writeComposeFile(filepath.Join(packageDir, defaultComposeFile), pkg); err != nil {
+			return nil, "", fmt.Errorf("failed to write compose file: %w", err)
+		}
+		composeFiles = []string{defaultComposeFile}
+	}
+
+	if err := downloadAssets(pkg.Assets, packageDir); err != nil {
+		return nil, "", err
+	}
+
+	engine := template.NewEngine(mergedValues)
+	if err := engine.WriteEnvFile(packageDir); err != nil {
+		return nil, "", fmt.Errorf("failed to write env file: %w", err)
 	}
 
-	return output, nil
+	if pkg.Render {
+		templates, err := shippedTemplates(pkg.Assets, opts.SourcePath)
+		if err != nil {
+			return nil, "", err
+		}
+		if err := engine.RenderFiles(packageDir, templates); err != nil {
+			return nil, "", fmt.Errorf("failed to render templates: %w", err)
+		}
+	}
+
+	if composeFiles == nil {
+		found, err := compose.FindComposeFiles(packageDir)
+		if err != nil {
+			return nil, "", err
+		}
+		composeFiles = found
+	}
+
+	return composeFiles, sourceDigest, nil
+}
+
+func shippedTemplates(assets []Asset, sourcePath string) ([]string, error) {
+	var templates []string
+	if sourcePath != "" {
+		found, err := template.FindTemplates(sourcePath)
+		if err != nil {
+			return nil, err
+		}
+		templates = found
+	}
+	for _, asset := range assets {
+		templates = append(templates, filepath.ToSlash(filepath.Clean(asset.Path)))
+	}
+	return templates, nil
 }
 
 func (m *Manager) LoadPackageFromDir(dir string) (result *Package, err error) {
@@ -211,13 +357,21 @@ func (m *Manager) LoadPackageFromDir(dir string) (result *Package, err error) {
 		}
 	}()
 
-	if pkg, err := m.loadPackageFile(root, "package.yaml", yaml.Unmarshal); err == nil {
+	pkg, err := m.loadPackageFile(root, "package.yaml", yaml.Unmarshal)
+	if err == nil {
 		return pkg, nil
 	}
+	if !os.IsNotExist(err) {
+		return nil, err
+	}
 
-	if pkg, err := m.loadPackageFile(root, "package.json", json.Unmarshal); err == nil {
+	pkg, err = m.loadPackageFile(root, "package.json", json.Unmarshal)
+	if err == nil {
 		return pkg, nil
 	}
+	if !os.IsNotExist(err) {
+		return nil, err
+	}
 
 	return nil, fmt.Errorf("package.yaml or package.json not found")
 }
@@ -243,6 +397,10 @@ func (m *Manager) loadPackageFile(root *os.Root, filename string, unmarshal func
 		return nil, fmt.Errorf("failed to parse %s: %w", filename, err)
 	}
 
+	if err := ValidateParamDefinitions(p.Parameters); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", filename, err)
+	}
+
 	return &p, nil
 }
 
@@ -261,18 +419,62 @@ services:
 	return os.WriteFile(path, []byte(composeContent), 0o600)
 }
 
-func downloadComposeFile(url, destPath string) (err error) {
+func downloadSources(pkg Package, packageDir string, quietUnpinned bool) ([]string, string, error) {
+	names, err := sourceFileNames(pkg.Source)
+	if err != nil {
+		return nil, "", err
+	}
+
+	contents := make([][]byte, 0, len(pkg.Source))
+	for _, source := range pkg.Source {
+		fmt.Printf("Downloading compose file from %s...\n", source)
+		data, err := fetchComposeFile(source)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to download compose file: %w", err)
+		}
+		contents = append(contents, data)
+	}
+
+	digest := SourcesDigest(contents)
+	if err := verifySourceDigest(pkg, digest, quietUnpinned); err != nil {
+		return nil, "", err
+	}
+
+	for i, data := range contents {
+		if err := os.WriteFile(filepath.Join(packageDir, names[i]), data, 0o600); err != nil {
+			return nil, "", fmt.Errorf("failed to write compose file: %w", err)
+		}
+	}
+
+	return names, digest, nil
+}
+
+func fetchComposeFile(url string) ([]byte, error) {
+	data, err := fetchURL(url)
+	if err != nil {
+		return nil, err
+	}
+
+	var composeCheck map[string]any
+	if err := yaml.Unmarshal(data, &composeCheck); err != nil {
+		return nil, fmt.Errorf("downloaded file is not valid YAML: %w", err)
+	}
+
+	return data, nil
+}
+
+func fetchURL(url string) (data []byte, err error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, http.NoBody)
 	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
-		return fmt.Errorf("failed to download from %s: %w", url, err)
+		return nil, fmt.Errorf("failed to download from %s: %w", url, err)
 	}
 	defer func() {
 		if closeErr := resp.Body.Close(); closeErr != nil && err == nil {
@@ -281,24 +483,18 @@ func downloadComposeFile(url, destPath string) (err error) {
 	}()
 
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("failed to download from %s: status %d", url, resp.StatusCode)
+		return nil, fmt.Errorf("failed to download from %s: status %d", url, resp.StatusCode)
 	}
 
-	data, err := io.ReadAll(resp.Body)
+	data, err = io.ReadAll(io.LimitReader(resp.Body, maxDownloadSize+1))
 	if err != nil {
-		return fmt.Errorf("failed to read response: %w", err)
+		return nil, fmt.Errorf("failed to read response: %w", err)
 	}
-
-	var composeCheck map[string]any
-	if err := yaml.Unmarshal(data, &composeCheck); err != nil {
-		return fmt.Errorf("downloaded file is not valid YAML: %w", err)
-	}
-
-	if err := os.WriteFile(destPath, data, 0o600); err != nil {
-		return fmt.Errorf("failed to write compose file: %w", err)
+	if len(data) > maxDownloadSize {
+		return nil, fmt.Errorf("download from %s exceeds %d bytes", url, maxDownloadSize)
 	}
 
-	return nil
+	return data, nil
 }
 
 func copyFile(src, dst string) (err error) {
@@ -1,94 +1,328 @@
 package pkg
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"reflect"
 	"testing"
+
+	"github.com/LoriKarikari/compak/internal/core/compose/composetest"
 )
 
 const testComposeFilename = "docker-compose.yaml"
 
-func TestDownloadComposeFile(t *testing.T) {
-	composeContent := `services:
-  app:
-    image: nginx:alpine
-    ports:
-      - "8080:80"
-`
+func setupRunningPackage(t *testing.T, engine *composetest.Engine, installed InstalledPackage, files map[string]string) (*Manager, string) {
+	t.Helper()
 
-	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "text/yaml")
-		w.WriteHeader(http.StatusOK)
-		if _, err := w.Write([]byte(composeContent)); err != nil {
-			t.Errorf("Failed to write response: %v", err)
+	stateDir := t.TempDir()
+	packageDir := filepath.Join(stateDir, "packages", installed.Package.Name)
+	for name, content := range files {
+		path := filepath.Join(packageDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+			t.Fatalf("Expected no error but got: %v", err)
 		}
-	}))
-	defer server.Close()
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("Expected no error but got: %v", err)
+		}
+	}
 
-	tempDir := t.TempDir()
-	destPath := filepath.Join(tempDir, testComposeFilename)
+	if len(installed.ComposeFiles) > 0 {
+		checksums, err := fileChecksums(packageDir, installed.ComposeFiles)
+		if err != nil {
+			t.Fatalf("Expected no error but got: %v", err)
+		}
+		installed.Checksums = checksums
+	}
 
-	err := downloadComposeFile(server.URL, destPath)
-	if err != nil {
-		t.Fatalf("downloadComposeFile failed: %v", err)
+	client := NewClient(stateDir)
+	if err := client.install(installed); err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
 	}
 
-	data, err := os.ReadFile(destPath)
+	manager := NewManager(client, engine, stateDir)
+	project, err := manager.installedProject(installed)
 	if err != nil {
-		t.Fatalf("Failed to read downloaded file: %v", err)
+		t.Fatalf("Expected no error but got: %v", err)
 	}
-
-	if string(data) != composeContent {
-		t.Errorf("Downloaded content mismatch.\nGot:\n%s\nWant:\n%s", string(data), composeContent)
+	if err := engine.Up(context.Background(), project, true, nil); err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
 	}
+	return manager, packageDir
 }
 
-func TestDownloadComposeFileInvalidYAML(t *testing.T) {
+func TestDownloadSourcesErrors(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusOK)
+		if r.URL.Path == "/missing.yaml" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
 		if _, err := w.Write([]byte("this is not valid yaml: [[[")); err != nil {
 			t.Errorf("Failed to write response: %v", err)
 		}
 	}))
 	defer server.Close()
 
-	tempDir := t.TempDir()
-	destPath := filepath.Join(tempDir, testComposeFilename)
+	tests := []struct {
+		name   string
+		source string
+	}{
+		{name: "invalid yaml", source: server.URL + "/compose.yaml"},
+		{name: "http error", source: server.URL + "/missing.yaml"},
+	}
 
-	err := downloadComposeFile(server.URL, destPath)
-	if err == nil {
-		t.Error("Expected error for invalid YAML, got nil")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := downloadSources(Package{Name: "broken", Source: Sources{tt.source}}, t.TempDir(), true); err == nil {
+				t.Error("Expected an error, got nil")
+			}
+		})
 	}
 }
 
-func TestDownloadComposeFileHTTPError(t *testing.T) {
+func TestDownloadSources(t *testing.T) {
+	files := map[string]string{
+		"/docker/compose.yaml":          "services:\n  app:\n    image: nginx:alpine\n",
+		"/docker/compose.override.yaml": "services:\n  app:\n    ports:\n      - \"8080:80\"\n",
+	}
+
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusNotFound)
+		content, ok := files[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Errorf("Failed to write response: %v", err)
+		}
 	}))
 	defer server.Close()
 
-	tempDir := t.TempDir()
-	destPath := filepath.Join(tempDir, testComposeFilename)
+	packageDir := t.TempDir()
+	names, digest, err := downloadSources(Package{
+		Name: "multi",
+		Source: Sources{
+			server.URL + "/docker/compose.yaml",
+			server.URL + "/docker/compose.override.yaml",
+		},
+	}, packageDir, true)
+	if err != nil {
+		t.Fatalf("downloadSources failed: %v", err)
+	}
+
+	expected := []string{"compose.yaml", "compose.override.yaml"}
+	assertSources(t, names, expected)
+	if digest != SourcesDigest([][]byte{[]byte(files["/docker/compose.yaml"]), []byte(files["/docker/compose.override.yaml"])}) {
+		t.Errorf("Unexpected digest %s", digest)
+	}
+	for _, name := range names {
+		data, err := os.ReadFile(filepath.Join(packageDir, name))
+		if err != nil {
+			t.Fatalf("Failed to read %s: %v", name, err)
+		}
+		if string(data) != files["/docker/"+name] {
+			t.Errorf("Content mismatch for %s", name)
+		}
+	}
+}
+
+func TestSetupPackageFilesDiscoversLocalComposeFiles(t *testing.T) {
+	sourceDir := t.TempDir()
+	for _, name := range []string{"compose.yml", "compose.override.yml", "package.yaml"} {
+		if err := os.WriteFile(filepath.Join(sourceDir, name), []byte("services: {}\n"), 0o600); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	manager := NewManager(NewClient(t.TempDir()), nil, t.TempDir())
+	packageDir := t.TempDir()
+
+	files, _, err := manager.setupPackageFiles(packageDir, Package{Name: "local"}, nil, DeployOptions{SourcePath: sourceDir})
+	if err != nil {
+		t.Fatalf("setupPackageFiles failed: %v", err)
+	}
+
+	assertSources(t, files, []string{"compose.yml", "compose.override.yml"})
+}
 
-	err := downloadComposeFile(server.URL, destPath)
-	if err == nil {
-		t.Error("Expected error for 404 response, got nil")
+func TestSetupPackageFilesRendersOnlyShippedTemplates(t *testing.T) {
+	sourceDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(sourceDir, "compose.yaml.tmpl"), []byte("services: {}\n# {{ .Values.TAG }}\n"), 0o600); err != nil {
+		t.Fatalf("Failed to write template: %v", err)
+	}
+
+	packageDir := t.TempDir()
+	userData := filepath.Join(packageDir, "library", "notes.txt.tmpl")
+	if err := os.MkdirAll(filepath.Dir(userData), 0o750); err != nil {
+		t.Fatalf("Failed to create data dir: %v", err)
+	}
+	if err := os.WriteFile(userData, []byte("{{ .Values.TAG"), 0o600); err != nil {
+		t.Fatalf("Failed to write user data: %v", err)
+	}
+
+	manager := NewManager(NewClient(t.TempDir()), nil, t.TempDir())
+	pkg := Package{Name: "local", Render: true}
+	if _, _, err := manager.setupPackageFiles(packageDir, pkg, map[string]string{"TAG": "alpine"}, DeployOptions{SourcePath: sourceDir}); err != nil {
+		t.Fatalf("setupPackageFiles failed: %v", err)
+	}
+
+	rendered, err := os.ReadFile(filepath.Join(packageDir, "compose.yaml"))
+	if err != nil || string(rendered) != "services: {}\n# alpine\n" {
+		t.Errorf("Expected the shipped template to be rendered, got %q (%v)", rendered, err)
+	}
+	if _, err := os.Stat(filepath.Join(packageDir, "library", "notes.txt")); !os.IsNotExist(err) {
+		t.Errorf("Expected user data to be left alone, got %v", err)
 	}
 }
 
-func TestDownloadComposeFileTimeout(t *testing.T) {
+func TestDownloadSourcesVerifiesDigest(t *testing.T) {
+	composeContent := "services:\n  app:\n    image: nginx:alpine\n"
+
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		<-r.Context().Done()
+		if _, err := w.Write([]byte(composeContent)); err != nil {
+			t.Errorf("Failed to write response: %v", err)
+		}
 	}))
 	defer server.Close()
 
-	tempDir := t.TempDir()
-	destPath := filepath.Join(tempDir, testComposeFilename)
+	pinned := SourcesDigest([][]byte{[]byte(composeContent)})
+	wrong := SourcesDigest([][]byte{[]byte("something else")})
+
+	tests := []struct {
+		name          string
+		digest        string
+		quietUnpinned bool
+		expectErr     bool
+	}{
+		{name: "matching digest", digest: pinned},
+		{name: "mismatched digest", digest: wrong, expectErr: true},
+		{name: "mismatched digest with quiet unpinned", digest: wrong, quietUnpinned: true, expectErr: true},
+		{name: "unpinned warns"},
+		{name: "unpinned quiet", quietUnpinned: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			packageDir := t.TempDir()
+			p := Package{Name: "pinned", Source: Sources{server.URL + "/compose.yaml"}, SourceDigest: tt.digest}
+
+			_, digest, err := downloadSources(p, packageDir, tt.quietUnpinned)
+			if tt.expectErr {
+				if err == nil {
+					t.Fatal("Expected error but got none")
+				}
+				if _, statErr := os.Stat(filepath.Join(packageDir, "compose.yaml")); !os.IsNotExist(statErr) {
+					t.Error("Expected compose file not to be written when verification fails")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error but got: %v", err)
+			}
+			if digest != pinned {
+				t.Errorf("Expected digest %s, got %s", pinned, digest)
+			}
+		})
+	}
+}
+
+func TestDryRunDoesNotDeploy(t *testing.T) {
+	sourceDir := t.TempDir()
+	composeContent := "services:\n  web:\n    image: nginx:${TAG}\n"
+	if err := os.WriteFile(filepath.Join(sourceDir, "compose.yaml"), []byte(composeContent), 0o600); err != nil {
+		t.Fatalf("Failed to write compose file: %v", err)
+	}
+
+	stateDir := t.TempDir()
+	client := NewClient(stateDir)
+	manager := NewManager(client, nil, stateDir)
+
+	pkg := Package{
+		Name:       "dry",
+		Version:    "1.0.0",
+		Parameters: map[string]Param{"TAG": {Type: "string", Default: "1.27"}},
+	}
+
+	if err := manager.DryRun(pkg, nil, DeployOptions{SourcePath: sourceDir}); err != nil {
+		t.Fatalf("DryRun failed: %v", err)
+	}
+
+	if _, err := client.GetInstalledPackage("dry"); err == nil {
+		t.Error("Expected dry run not to record the package")
+	}
+	if _, err := os.Stat(filepath.Join(stateDir, "packages", "dry")); !os.IsNotExist(err) {
+		t.Error("Expected dry run not to create the package directory")
+	}
+
+	invalid := pkg
+	invalid.Parameters = map[string]Param{"TAG": {Type: "string", Required: true}}
+	if err := manager.DryRun(invalid, nil, DeployOptions{SourcePath: sourceDir}); err == nil {
+		t.Error("Expected dry run to report missing required parameter")
+	}
+}
+
+func TestDryRunUsesPackageDir(t *testing.T) {
+	sourceDir := t.TempDir()
+	composeContent := "services:\n  web:\n    image: nginx:alpine\n    env_file: app.env\n    volumes:\n      - ./data:/data\n"
+	if err := os.WriteFile(filepath.Join(sourceDir, "compose.yaml"), []byte(composeContent), 0o600); err != nil {
+		t.Fatalf("Failed to write compose file: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(sourceDir, "app.env"), []byte("MODE=dry\n"), 0o600); err != nil {
+		t.Fatalf("Failed to write env file: %v", err)
+	}
+
+	stateDir := t.TempDir()
+	manager := NewManager(NewClient(stateDir), nil, stateDir)
+	packageDir := filepath.Join(stateDir, "packages", "dry")
+
+	pkg := Package{Name: "dry", Version: "1.0.0"}
+	err := manager.prepareTemporary(pkg, nil, DeployOptions{SourcePath: sourceDir}, func(d *deployment, _ string) error {
+		web := d.project.Services["web"]
+		if d.project.WorkingDir != packageDir {
+			t.Errorf("Expected working dir %s, got %s", packageDir, d.project.WorkingDir)
+		}
+		if len(web.Volumes) != 1 || web.Volumes[0].Source != filepath.Join(packageDir, "data") {
+			t.Errorf("Expected bind mount under %s, got %+v", packageDir, web.Volumes)
+		}
+		if len(web.EnvFiles) != 1 || web.EnvFiles[0].Path != filepath.Join(packageDir, "app.env") {
+			t.Errorf("Expected env file under %s, got %+v", packageDir, web.EnvFiles)
+		}
+		if mode := web.Environment["MODE"]; mode == nil || *mode != "dry" {
+			t.Errorf("Expected MODE from the rendered env file, got %v", web.Environment)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("prepareTemporary failed: %v", err)
+	}
+}
+
+func TestPackageFiles(t *testing.T) {
+	sourceDir := t.TempDir()
+	for _, name := range []string{"compose.yaml", "config/app.conf.tmpl"} {
+		path := filepath.Join(sourceDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+			t.Fatalf("Failed to create %s: %v", name, err)
+		}
+		if err := os.WriteFile(path, []byte("x"), 0o600); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	pkg := Package{Name: "local", Render: true, Assets: []Asset{{Path: "init/setup.sh"}}}
+	files, err := packageFiles(pkg, DeployOptions{SourcePath: sourceDir}, []string{"compose.yaml", ".compak-overlays/gpu.yaml"})
+	if err != nil {
+		t.Fatalf("packageFiles failed: %v", err)
+	}
+	expected := []string{".compak-overlays/gpu.yaml", ".env", "compose.yaml", "config/app.conf", "config/app.conf.tmpl", "init/setup.sh"}
+	if !reflect.DeepEqual(files, expected) {
+		t.Errorf("Expected %v, got %v", expected, files)
+	}
 
-	err := downloadComposeFile(server.URL, destPath)
-	if err == nil {
-		t.Error("Expected timeout error, got nil")
+	legacy := InstalledPackage{Package: pkg, ComposeFiles: []string{"compose.yaml"}}
+	if files := previousFiles(legacy); !reflect.DeepEqual(files, []string{".env", "compose.yaml", "init/setup.sh"}) {
+		t.Errorf("Expected compose files, .env and assets for a state entry without files, got %v", files)
 	}
 }
@@ -0,0 +1,95 @@
+package pkg
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const overlaysDir = ".compak-overlays"
+
+func ReadOverlays(paths []string) ([]Overlay, error) {
+	overlays := make([]Overlay, 0, len(paths))
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read overlay %s: %w", path, err)
+		}
+		if err := validateOverlayContent(data); err != nil {
+			return nil, fmt.Errorf("invalid overlay %s: %w", path, err)
+		}
+		overlays = append(overlays, Overlay{
+			Name:    filepath.Base(path),
+			Content: string(data),
+		})
+	}
+	return overlays, nil
+}
+
+func validateOverlayContent(data []byte) error {
+	var content map[string]any
+	if err := yaml.Unmarshal(data, &content); err != nil {
+		return fmt.Errorf("not valid YAML: %w", err)
+	}
+	if content == nil {
+		return fmt.Errorf("overlay is empty")
+	}
+	return nil
+}
+
+func validatePackageOverlays(pkg Package) error {
+	for _, overlay := range pkg.Overlays {
+		if filepath.IsAbs(overlay) {
+			return fmt.Errorf("overlay %s must be relative to the package directory", overlay)
+		}
+		if err := validatePath(overlay); err != nil {
+			return fmt.Errorf("invalid overlay %s: %w", overlay, err)
+		}
+	}
+	return nil
+}
+
+func writeOverlays(packageDir string, overlays []Overlay) ([]string, error) {
+	dir := filepath.Join(packageDir, overlaysDir)
+	if err := os.RemoveAll(dir); err != nil {
+		return nil, fmt.Errorf("failed to clear overlays directory: %w", err)
+	}
+	if len(overlays) == 0 {
+		return nil, nil
+	}
+
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create overlays directory: %w", err)
+	}
+
+	files := make([]string, 0, len(overlays))
+	for i, overlay := range overlays {
+		if err := validateOverlayContent([]byte(overlay.Content)); err != nil {
+			return nil, fmt.Errorf("invalid overlay %s: %w", overlay.Name, err)
+		}
+
+		name := fmt.Sprintf("%02d-%s", i+1, sanitizeOverlayName(overlay.Name))
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(overlay.Content), 0o600); err != nil {
+			return nil, fmt.Errorf("failed to write overlay %s: %w", overlay.Name, err)
+		}
+		files = append(files, filepath.Join(overlaysDir, name))
+	}
+	return files, nil
+}
+
+func sanitizeOverlayName(name string) string {
+	name = filepath.Base(name)
+	name = strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' || r < ' ' {
+			return '_'
+		}
+		return r
+	}, name)
+	if name == "." || name == ".." || name == "" {
+		return "overlay.yaml"
+	}
+	return name
+}
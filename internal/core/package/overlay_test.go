@@ -0,0 +1,123 @@
+package pkg
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadOverlays(t *testing.T) {
+	tempDir := t.TempDir()
+
+	validPath := filepath.Join(tempDir, "limits.yaml")
+	if err := os.WriteFile(validPath, []byte("services:\n  app:\n    mem_limit: 512m\n"), 0o600); err != nil {
+		t.Fatalf("Failed to write overlay: %v", err)
+	}
+
+	invalidPath := filepath.Join(tempDir, "broken.yaml")
+	if err := os.WriteFile(invalidPath, []byte("services: [[["), 0o600); err != nil {
+		t.Fatalf("Failed to write overlay: %v", err)
+	}
+
+	overlays, err := ReadOverlays([]string{validPath})
+	if err != nil {
+		t.Fatalf("ReadOverlays failed: %v", err)
+	}
+	if len(overlays) != 1 || overlays[0].Name != "limits.yaml" {
+		t.Fatalf("Unexpected overlays: %+v", overlays)
+	}
+
+	if _, err := ReadOverlays([]string{invalidPath}); err == nil {
+		t.Error("Expected error for invalid overlay YAML")
+	}
+
+	if _, err := ReadOverlays([]string{filepath.Join(tempDir, "missing.yaml")}); err == nil {
+		t.Error("Expected error for missing overlay file")
+	}
+}
+
+func TestWriteOverlays(t *testing.T) {
+	packageDir := t.TempDir()
+
+	overlays := []Overlay{
+		{Name: "labels.yaml", Content: "services:\n  app:\n    labels:\n      team: infra\n"},
+		{Name: "../../escape.yaml", Content: "services:\n  app:\n    mem_limit: 1g\n"},
+	}
+
+	files, err := writeOverlays(packageDir, overlays)
+	if err != nil {
+		t.Fatalf("writeOverlays failed: %v", err)
+	}
+
+	expected := []string{
+		filepath.Join(overlaysDir, "01-labels.yaml"),
+		filepath.Join(overlaysDir, "02-escape.yaml"),
+	}
+	if len(files) != len(expected) {
+		t.Fatalf("Expected %d files, got %v", len(expected), files)
+	}
+	for i, file := range files {
+		if file != expected[i] {
+			t.Errorf("Expected %s, got %s", expected[i], file)
+		}
+		if _, err := os.Stat(filepath.Join(packageDir, file)); err != nil {
+			t.Errorf("Overlay file not written: %v", err)
+		}
+	}
+
+	files, err = writeOverlays(packageDir, nil)
+	if err != nil {
+		t.Fatalf("writeOverlays failed: %v", err)
+	}
+	if len(files) != 0 {
+		t.Errorf("Expected no overlay files, got %v", files)
+	}
+	if _, err := os.Stat(filepath.Join(packageDir, overlaysDir)); !os.IsNotExist(err) {
+		t.Error("Expected stale overlays to be removed")
+	}
+}
+
+func TestValidatePackageOverlays(t *testing.T) {
+	tests := []struct {
+		name      string
+		overlays  []string
+		expectErr bool
+	}{
+		{name: "relative overlay", overlays: []string{"overrides/gpu.yaml"}},
+		{name: "absolute overlay", overlays: []string{"/etc/compose.yaml"}, expectErr: true},
+		{name: "traversal", overlays: []string{"../other/compose.yaml"}, expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validatePackageOverlays(Package{Overlays: tt.overlays})
+			if tt.expectErr && err == nil {
+				t.Error("Expected error but got none")
+			}
+			if !tt.expectErr && err != nil {
+				t.Errorf("Expected no error but got: %v", err)
+			}
+		})
+	}
+}
+
+func TestClient_InstallPersistsOverlays(t *testing.T) {
+	client := NewClient(t.TempDir())
+
+	overlays := []Overlay{{Name: "labels.yaml", Content: "services: {}\n"}}
+	err := client.install(InstalledPackage{
+		Package:  Package{Name: "test-package", Version: "1.0.0"},
+		Overlays: overlays,
+	})
+	if err != nil {
+		t.Fatalf("install failed: %v", err)
+	}
+
+	installed, err := client.GetInstalledPackage("test-package")
+	if err != nil {
+		t.Fatalf("GetInstalledPackage failed: %v", err)
+	}
+	if len(installed.Overlays) != 1 || installed.Overlays[0] != overlays[0] {
+		t.Errorf("Expected overlays to be persisted, got %+v", installed.Overlays)
+	}
+}
@@ -2,33 +2,84 @@ package pkg
 
 import (
 	"time"
+
+	"github.com/LoriKarikari/compak/internal/core/policy"
 )
 
 type Package struct {
-	Name        string            `yaml:"name" json:"name"`
-	Version     string            `yaml:"version" json:"version"`
-	Description string            `yaml:"description" json:"description"`
-	Author      string            `yaml:"author" json:"author"`
-	License     string            `yaml:"license" json:"license"`
-	Homepage    string            `yaml:"homepage" json:"homepage"`
-	Repository  string            `yaml:"repository" json:"repository"`
-	Source      string            `yaml:"source" json:"source"`
-	Parameters  map[string]Param  `yaml:"parameters" json:"parameters"`
-	Values      map[string]string `yaml:"values" json:"values"`
+	Name         string            `yaml:"name" json:"name"`
+	Version      string            `yaml:"version" json:"version"`
+	Description  string            `yaml:"description" json:"description"`
+	Author       string            `yaml:"author" json:"author"`
+	License      string            `yaml:"license" json:"license"`
+	Homepage     string            `yaml:"homepage" json:"homepage"`
+	Repository   string            `yaml:"repository" json:"repository"`
+	Source       Sources           `yaml:"source" json:"source"`
+	SourceDigest string            `yaml:"sourceDigest,omitempty" json:"sourceDigest,omitempty"`
+	Render       bool              `yaml:"render,omitempty" json:"render,omitempty"`
+	Overlays     []string          `yaml:"overlays,omitempty" json:"overlays,omitempty"`
+	Assets       []Asset           `yaml:"assets,omitempty" json:"assets,omitempty"`
+	Parameters   map[string]Param  `yaml:"parameters" json:"parameters"`
+	Values       map[string]string `yaml:"values" json:"values"`
 }
 
 type Param struct {
-	Description string `yaml:"description" json:"description"`
-	Type        string `yaml:"type" json:"type"`
-	Default     string `yaml:"default" json:"default"`
-	Required    bool   `yaml:"required" json:"required"`
+	Description string   `yaml:"description" json:"description"`
+	Type        string   `yaml:"type" json:"type"`
+	Default     string   `yaml:"default" json:"default"`
+	Required    bool     `yaml:"required" json:"required"`
+	Enum        []string `yaml:"enum,omitempty" json:"enum,omitempty"`
+	Pattern     string   `yaml:"pattern,omitempty" json:"pattern,omitempty"`
+	Min         *float64 `yaml:"min,omitempty" json:"min,omitempty"`
+	Max         *float64 `yaml:"max,omitempty" json:"max,omitempty"`
+	MinLength   int      `yaml:"minLength,omitempty" json:"minLength,omitempty"`
+	MaxLength   int      `yaml:"maxLength,omitempty" json:"maxLength,omitempty"`
+	RequiredIf  string   `yaml:"requiredIf,omitempty" json:"requiredIf,omitempty"`
+	VisibleIf   string   `yaml:"visibleIf,omitempty" json:"visibleIf,omitempty"`
+}
+
+type Asset struct {
+	URL    string `yaml:"url" json:"url"`
+	Path   string `yaml:"path" json:"path"`
+	SHA256 string `yaml:"sha256" json:"sha256"`
 }
 
 type InstalledPackage struct {
-	Package     Package           `json:"package"`
-	InstallTime time.Time         `json:"install_time"`
-	Values      map[string]string `json:"values"`
-	Status      string            `json:"status"`
+	Package       Package           `json:"package"`
+	Pak           string            `json:"pak,omitempty"`
+	InstallTime   time.Time         `json:"install_time"`
+	Values        map[string]string `json:"values"`
+	Status        string            `json:"status"`
+	Overlays      []Overlay         `json:"overlays,omitempty"`
+	ComposeFiles  []string          `json:"compose_files,omitempty"`
+	SourceDigest  string            `json:"source_digest,omitempty"`
+	IndexCommit   string            `json:"index_commit,omitempty"`
+	ComposeDigest string            `json:"compose_digest,omitempty"`
+	Images        map[string]string `json:"images,omitempty"`
+	ImagesPinned  bool              `json:"images_pinned,omitempty"`
+	Ports         []PortBinding     `json:"ports,omitempty"`
+	Checksums     map[string]string `json:"checksums,omitempty"`
+	Files         []string          `json:"files,omitempty"`
+}
+
+type Overlay struct {
+	Name    string `json:"name"`
+	Content string `json:"content"`
+}
+
+type DeployOptions struct {
+	SourcePath    string
+	Overlays      []Overlay
+	QuietUnpinned bool
+	Policy        policy.Policy
+	AcceptRisk    bool
+	Wait          bool
+	WaitTimeout   time.Duration
+	Pak           string
+	IndexCommit   string
+	Lock          *LockEntry
+	PinImages     bool
+	RemoteHost    bool
 }
 
 type Client struct {
@@ -0,0 +1,222 @@
+package pkg
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const maxParameterValueLength = 1000
+
+var (
+	numberPattern   = regexp.MustCompile(`^-?\d+(\.\d+)?$`)
+	integerPattern  = regexp.MustCompile(`^-?\d+$`)
+	booleanPattern  = regexp.MustCompile(`^(true|false|yes|no|1|0)$`)
+	portPattern     = regexp.MustCompile(`^([1-9]\d{0,3}|[1-5]\d{4}|6[0-4]\d{3}|65[0-4]\d{2}|655[0-2]\d|6553[0-5])$`)
+	hostnamePattern = regexp.MustCompile(`^([a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?)(\.[a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?)*$`)
+)
+
+var typeValidators = map[string]func(value string) error{
+	"string":   func(string) error { return nil },
+	"path":     validatePathValue,
+	"number":   matchValidator(numberPattern, "must be a valid number"),
+	"integer":  matchValidator(integerPattern, "must be a valid integer"),
+	"port":     matchValidator(portPattern, "must be a valid port number (1-65535)"),
+	"hostname": validateHostnameValue,
+	"url":      validateURLValue,
+	"email":    validateEmailValue,
+	"duration": validateDurationValue,
+	"boolean": func(value string) error {
+		if !booleanPattern.MatchString(strings.ToLower(value)) {
+			return errors.New("must be a boolean value (true/false)")
+		}
+		return nil
+	},
+}
+
+var numericTypes = []string{"number", "integer", "port"}
+
+func ValidateParamDefinitions(params map[string]Param) error {
+	for name, param := range params {
+		if err := validateParamDefinition(name, param); err != nil {
+			return err
+		}
+		if err := validateConditionRefs(name, "requiredIf", param.RequiredIf, params); err != nil {
+			return err
+		}
+		if err := validateConditionRefs(name, "visibleIf", param.VisibleIf, params); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateParamDefinition(name string, param Param) error {
+	if _, known := typeValidators[paramType(param)]; !known {
+		return fmt.Errorf("parameter '%s' has unknown type %q", name, param.Type)
+	}
+
+	if param.Pattern != "" {
+		if _, err := compilePattern(param.Pattern); err != nil {
+			return fmt.Errorf("parameter '%s' has invalid pattern: %w", name, err)
+		}
+	}
+
+	if (param.Min != nil || param.Max != nil) && !slices.Contains(numericTypes, paramType(param)) {
+		return fmt.Errorf("parameter '%s' uses min/max but type %q is not numeric", name, paramType(param))
+	}
+	if param.Min != nil && param.Max != nil && *param.Min > *param.Max {
+		return fmt.Errorf("parameter '%s' has min greater than max", name)
+	}
+
+	if param.MinLength < 0 || param.MaxLength < 0 {
+		return fmt.Errorf("parameter '%s' has negative length constraint", name)
+	}
+	if param.MaxLength > 0 && param.MinLength > param.MaxLength {
+		return fmt.Errorf("parameter '%s' has minLength greater than maxLength", name)
+	}
+
+	if param.Default != "" {
+		if err := ValidateParameterValue(name, param.Default, param); err != nil {
+			return fmt.Errorf("invalid default: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func ValidateParameterValue(name, value string, param Param) error {
+	if len(value) > maxParameterValueLength {
+		return fmt.Errorf("parameter '%s' value too long (max %d characters)", name, maxParameterValueLength)
+	}
+
+	if strings.ContainsAny(value, "\x00\r\n") {
+		return fmt.Errorf("parameter '%s' contains invalid characters", name)
+	}
+
+	validate, known := typeValidators[paramType(param)]
+	if !known {
+		return fmt.Errorf("parameter '%s' has unknown type %q", name, param.Type)
+	}
+	if err := validate(value); err != nil {
+		return fmt.Errorf("parameter '%s' %w", name, err)
+	}
+
+	if err := validateConstraints(value, param); err != nil {
+		return fmt.Errorf("parameter '%s' %w", name, err)
+	}
+
+	return nil
+}
+
+func validateConstraints(value string, param Param) error {
+	if len(param.Enum) > 0 && !slices.Contains(param.Enum, value) {
+		return fmt.Errorf("must be one of: %s", strings.Join(param.Enum, ", "))
+	}
+
+	if param.Pattern != "" {
+		re, err := compilePattern(param.Pattern)
+		if err != nil {
+			return fmt.Errorf("has invalid pattern: %w", err)
+		}
+		if !re.MatchString(value) {
+			return fmt.Errorf("must match pattern %s", param.Pattern)
+		}
+	}
+
+	length := utf8.RuneCountInString(value)
+	if param.MinLength > 0 && length < param.MinLength {
+		return fmt.Errorf("must be at least %d characters", param.MinLength)
+	}
+	if param.MaxLength > 0 && length > param.MaxLength {
+		return fmt.Errorf("must be at most %d characters", param.MaxLength)
+	}
+
+	return validateRange(value, param)
+}
+
+func validateRange(value string, param Param) error {
+	if param.Min == nil && param.Max == nil {
+		return nil
+	}
+
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return errors.New("must be numeric to check min/max")
+	}
+	if param.Min != nil && number < *param.Min {
+		return fmt.Errorf("must be >= %s", formatBound(*param.Min))
+	}
+	if param.Max != nil && number > *param.Max {
+		return fmt.Errorf("must be <= %s", formatBound(*param.Max))
+	}
+	return nil
+}
+
+func paramType(param Param) string {
+	if param.Type == "" {
+		return "string"
+	}
+	return param.Type
+}
+
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	return regexp.Compile(`^(?:` + pattern + `)$`)
+}
+
+func formatBound(bound float64) string {
+	return strconv.FormatFloat(bound, 'f', -1, 64)
+}
+
+func matchValidator(re *regexp.Regexp, message string) func(string) error {
+	return func(value string) error {
+		if !re.MatchString(value) {
+			return errors.New(message)
+		}
+		return nil
+	}
+}
+
+func validatePathValue(value string) error {
+	if strings.TrimSpace(value) == "" {
+		return errors.New("must be a valid path")
+	}
+	return nil
+}
+
+func validateHostnameValue(value string) error {
+	if len(value) > 253 || !hostnamePattern.MatchString(value) {
+		return errors.New("must be a valid hostname")
+	}
+	return nil
+}
+
+func validateURLValue(value string) error {
+	u, err := url.Parse(value)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return errors.New("must be a valid absolute URL")
+	}
+	return nil
+}
+
+func validateEmailValue(value string) error {
+	addr, err := mail.ParseAddress(value)
+	if err != nil || addr.Address != value {
+		return errors.New("must be a valid email address")
+	}
+	return nil
+}
+
+func validateDurationValue(value string) error {
+	if _, err := time.ParseDuration(value); err != nil {
+		return errors.New("must be a valid duration (e.g. 30s, 5m, 1h)")
+	}
+	return nil
+}
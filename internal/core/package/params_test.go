@@ -0,0 +1,90 @@
+package pkg
+
+import (
+	"testing"
+)
+
+func float64Ptr(v float64) *float64 {
+	return &v
+}
+
+func TestValidateParameterValue(t *testing.T) {
+	tests := []struct {
+		name      string
+		param     Param
+		value     string
+		expectErr bool
+	}{
+		{name: "untyped defaults to string", param: Param{}, value: "anything"},
+		{name: "valid integer", param: Param{Type: "integer"}, value: "42"},
+		{name: "decimal is not integer", param: Param{Type: "integer"}, value: "4.2", expectErr: true},
+		{name: "valid number", param: Param{Type: "number"}, value: "-4.2"},
+		{name: "invalid number", param: Param{Type: "number"}, value: "auto", expectErr: true},
+		{name: "valid boolean", param: Param{Type: "boolean"}, value: "Yes"},
+		{name: "invalid boolean", param: Param{Type: "boolean"}, value: "maybe", expectErr: true},
+		{name: "valid port", param: Param{Type: "port"}, value: "8080"},
+		{name: "port out of range", param: Param{Type: "port"}, value: "70000", expectErr: true},
+		{name: "valid url", param: Param{Type: "url"}, value: "https://example.com/path"},
+		{name: "relative url", param: Param{Type: "url"}, value: "/path", expectErr: true},
+		{name: "valid path", param: Param{Type: "path"}, value: "./library"},
+		{name: "blank path", param: Param{Type: "path"}, value: "   ", expectErr: true},
+		{name: "valid email", param: Param{Type: "email"}, value: "admin@example.com"},
+		{name: "invalid email", param: Param{Type: "email"}, value: "Admin <admin@example.com>", expectErr: true},
+		{name: "valid duration", param: Param{Type: "duration"}, value: "1h30m"},
+		{name: "invalid duration", param: Param{Type: "duration"}, value: "soon", expectErr: true},
+		{name: "valid hostname", param: Param{Type: "hostname"}, value: "photos.example.com"},
+		{name: "invalid hostname", param: Param{Type: "hostname"}, value: "-bad.example.com", expectErr: true},
+		{name: "unknown type", param: Param{Type: "color"}, value: "red", expectErr: true},
+		{name: "enum match", param: Param{Enum: []string{"debug", "info"}}, value: "info"},
+		{name: "enum mismatch", param: Param{Enum: []string{"debug", "info"}}, value: "trace", expectErr: true},
+		{name: "pattern match", param: Param{Pattern: `v\d+\.\d+`}, value: "v1.144"},
+		{name: "pattern must match fully", param: Param{Pattern: `v\d+`}, value: "release-v1", expectErr: true},
+		{name: "min length", param: Param{MinLength: 12}, value: "short", expectErr: true},
+		{name: "max length", param: Param{MaxLength: 3}, value: "long", expectErr: true},
+		{name: "within range", param: Param{Type: "integer", Min: float64Ptr(1), Max: float64Ptr(16)}, value: "4"},
+		{name: "below min", param: Param{Type: "integer", Min: float64Ptr(1)}, value: "0", expectErr: true},
+		{name: "above max", param: Param{Type: "port", Max: float64Ptr(1024)}, value: "8080", expectErr: true},
+		{name: "newline rejected", param: Param{}, value: "a\nb", expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateParameterValue("PARAM", tt.value, tt.param)
+			if tt.expectErr && err == nil {
+				t.Error("Expected error but got none")
+			}
+			if !tt.expectErr && err != nil {
+				t.Errorf("Expected no error but got: %v", err)
+			}
+		})
+	}
+}
+
+func TestValidateParamDefinitions(t *testing.T) {
+	tests := []struct {
+		name      string
+		param     Param
+		expectErr bool
+	}{
+		{name: "valid definition", param: Param{Type: "integer", Default: "4", Min: float64Ptr(1)}},
+		{name: "unknown type", param: Param{Type: "int"}, expectErr: true},
+		{name: "invalid pattern", param: Param{Pattern: "(["}, expectErr: true},
+		{name: "min greater than max", param: Param{Type: "number", Min: float64Ptr(5), Max: float64Ptr(1)}, expectErr: true},
+		{name: "min on non-numeric type", param: Param{Type: "string", Min: float64Ptr(1)}, expectErr: true},
+		{name: "minLength greater than maxLength", param: Param{MinLength: 5, MaxLength: 2}, expectErr: true},
+		{name: "default outside enum", param: Param{Enum: []string{"a", "b"}, Default: "c"}, expectErr: true},
+		{name: "default with wrong type", param: Param{Type: "port", Default: "http"}, expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateParamDefinitions(map[string]Param{"PARAM": tt.param})
+			if tt.expectErr && err == nil {
+				t.Error("Expected error but got none")
+			}
+			if !tt.expectErr && err != nil {
+				t.Errorf("Expected no error but got: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,32 @@
+package pkg
+
+import (
+	"fmt"
+
+	"github.com/compose-spec/compose-go/v2/types"
+
+	"github.com/LoriKarikari/compak/internal/core/policy"
+)
+
+func enforcePolicy(packageName string, project *types.Project, opts DeployOptions) error {
+	findings := opts.Policy.Evaluate(project)
+	if len(findings) == 0 {
+		return nil
+	}
+
+	fmt.Printf("Security policy findings for %s:\n", packageName)
+	for _, finding := range findings {
+		fmt.Printf("  %s\n", finding)
+	}
+
+	denied := policy.Denied(findings)
+	switch {
+	case len(denied) == 0:
+		return nil
+	case opts.AcceptRisk:
+		fmt.Printf("Proceeding despite %d denied finding(s) because --accept-risk was given\n", len(denied))
+		return nil
+	}
+
+	return fmt.Errorf("deployment of %s blocked by security policy (%d denied finding(s)); adjust the policy in config.yaml or use --accept-risk", packageName, len(denied))
+}
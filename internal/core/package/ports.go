@@ -0,0 +1,248 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/docker/compose/v2/pkg/api"
+)
+
+var variableReference = regexp.MustCompile(`\$\{?([A-Za-z_][A-Za-z0-9_]*)`)
+
+type PortBinding struct {
+	Service  string `json:"service"`
+	HostIP   string `json:"host_ip,omitempty"`
+	Port     int    `json:"port"`
+	Protocol string `json:"protocol"`
+}
+
+func (b PortBinding) String() string {
+	if b.HostIP != "" {
+		return fmt.Sprintf("%s/%s", net.JoinHostPort(b.HostIP, strconv.Itoa(b.Port)), b.Protocol)
+	}
+	return fmt.Sprintf("%d/%s", b.Port, b.Protocol)
+}
+
+func (b PortBinding) overlaps(other PortBinding) bool {
+	if b.Port != other.Port || b.Protocol != other.Protocol {
+		return false
+	}
+	return isWildcardIP(b.HostIP) || isWildcardIP(other.HostIP) || b.HostIP == other.HostIP
+}
+
+type portConflict struct {
+	binding PortBinding
+	owner   string
+}
+
+func publishedPorts(project *types.Project) ([]PortBinding, error) {
+	var bindings []PortBinding
+	for _, name := range project.ServiceNames() {
+		for _, port := range project.Services[name].Ports {
+			if port.Published == "" {
+				continue
+			}
+
+			start, end, err := parsePortRange(port.Published)
+			if err != nil {
+				return nil, fmt.Errorf("service %s: %w", name, err)
+			}
+
+			protocol := port.Protocol
+			if protocol == "" {
+				protocol = "tcp"
+			}
+			for p := start; p <= end; p++ {
+				bindings = append(bindings, PortBinding{
+					Service:  name,
+					HostIP:   port.HostIP,
+					Port:     p,
+					Protocol: protocol,
+				})
+			}
+		}
+	}
+	return bindings, nil
+}
+
+func parsePortRange(published string) (int, int, error) {
+	first, last, isRange := strings.Cut(published, "-")
+	start, err := strconv.Atoi(first)
+	if err != nil || start < 1 || start > 65535 {
+		return 0, 0, fmt.Errorf("invalid published port %q", published)
+	}
+	if !isRange {
+		return start, start, nil
+	}
+	end, err := strconv.Atoi(last)
+	if err != nil || end < start || end > 65535 {
+		return 0, 0, fmt.Errorf("invalid published port range %q", published)
+	}
+	return start, end, nil
+}
+
+func findPortConflicts(bindings []PortBinding, installed []InstalledPackage, self string, inUse func(PortBinding) bool) []portConflict {
+	var conflicts []portConflict
+	for _, binding := range bindings {
+		owner := ""
+		for _, other := range installed {
+			if slices.ContainsFunc(other.Ports, binding.overlaps) {
+				owner = other.Package.Name
+				break
+			}
+		}
+
+		switch {
+		case owner == self:
+			continue
+		case owner != "":
+			conflicts = append(conflicts, portConflict{binding: binding, owner: owner})
+		case inUse(binding):
+			conflicts = append(conflicts, portConflict{binding: binding})
+		}
+	}
+	return conflicts
+}
+
+func (m *Manager) withRunningPorts(installed []InstalledPackage, name string, project *types.Project) []InstalledPackage {
+	index := slices.IndexFunc(installed, func(p InstalledPackage) bool { return p.Package.Name == name })
+	if index < 0 || len(installed[index].Ports) > 0 || m.engine == nil {
+		return installed
+	}
+
+	containers, err := m.engine.PS(context.Background(), project)
+	if err != nil {
+		return installed
+	}
+
+	installed = slices.Clone(installed)
+	installed[index].Ports = runningPorts(containers)
+	return installed
+}
+
+func runningPorts(containers []api.ContainerSummary) []PortBinding {
+	var bindings []PortBinding
+	for _, container := range containers {
+		for _, publisher := range container.Publishers {
+			if publisher.PublishedPort == 0 {
+				continue
+			}
+			protocol := publisher.Protocol
+			if protocol == "" {
+				protocol = "tcp"
+			}
+			bindings = append(bindings, PortBinding{
+				Service:  container.Service,
+				HostIP:   publisher.URL,
+				Port:     publisher.PublishedPort,
+				Protocol: protocol,
+			})
+		}
+	}
+	return bindings
+}
+
+func portInUse(binding PortBinding) bool {
+	address := net.JoinHostPort(binding.HostIP, strconv.Itoa(binding.Port))
+
+	var err error
+	if binding.Protocol == "udp" {
+		var conn net.PacketConn
+		if conn, err = net.ListenPacket("udp", address); err == nil {
+			_ = conn.Close()
+		}
+	} else {
+		var listener net.Listener
+		if listener, err = net.Listen("tcp", address); err == nil {
+			_ = listener.Close()
+		}
+	}
+	return errors.Is(err, syscall.EADDRINUSE)
+}
+
+func (m *Manager) checkPortConflicts(pkg Package, d *deployment, packageDir string, opts DeployOptions) ([]PortBinding, error) {
+	bindings, err := publishedPorts(d.project)
+	if err != nil {
+		return nil, err
+	}
+
+	installed, err := m.client.List()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list installed packages: %w", err)
+	}
+
+	installed = m.withRunningPorts(installed, pkg.Name, d.project)
+
+	inUse := portInUse
+	if opts.RemoteHost && len(bindings) > 0 {
+		fmt.Printf("Warning: not probing host ports for %s because the Docker host is remote\n", pkg.Name)
+		inUse = func(PortBinding) bool { return false }
+	}
+
+	conflicts := findPortConflicts(bindings, installed, pkg.Name, inUse)
+	if len(conflicts) == 0 {
+		return bindings, nil
+	}
+
+	composeText := readComposeText(packageDir, d.composeFiles)
+	messages := make([]string, 0, len(conflicts))
+	for _, conflict := range conflicts {
+		message := fmt.Sprintf("port %s (service %s) is already in use", conflict.binding, conflict.binding.Service)
+		if conflict.owner != "" {
+			message = fmt.Sprintf("port %s (service %s) is already used by pak %s", conflict.binding, conflict.binding.Service, conflict.owner)
+		}
+		if param := portParameter(pkg, d.values, composeText, conflict.binding.Port); param != "" {
+			message += fmt.Sprintf("; choose another with --set %s=<port>", param)
+		}
+		messages = append(messages, message)
+	}
+
+	return nil, fmt.Errorf("host port conflict:\n  %s", strings.Join(messages, "\n  "))
+}
+
+func readComposeText(packageDir string, composeFiles []string) string {
+	var builder strings.Builder
+	for _, file := range composeFiles {
+		data, err := os.ReadFile(filepath.Join(packageDir, file))
+		if err != nil {
+			continue
+		}
+		builder.Write(data)
+		builder.WriteByte('\n')
+	}
+	return builder.String()
+}
+
+func portParameter(pkg Package, values map[string]string, composeText string, port int) string {
+	referenced := make(map[string]bool)
+	for _, match := range variableReference.FindAllStringSubmatch(composeText, -1) {
+		referenced[match[1]] = true
+	}
+
+	names := make([]string, 0, len(pkg.Parameters))
+	for name := range pkg.Parameters {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	for _, name := range names {
+		if values[name] == strconv.Itoa(port) && referenced[name] {
+			return name
+		}
+	}
+	return ""
+}
+
+func isWildcardIP(ip string) bool {
+	return ip == "" || ip == "0.0.0.0" || ip == "::"
+}
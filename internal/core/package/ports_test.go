@@ -0,0 +1,142 @@
+package pkg
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/compose-spec/compose-go/v2/types"
+
+	"github.com/LoriKarikari/compak/internal/core/compose/composetest"
+)
+
+func TestPublishedPorts(t *testing.T) {
+	project := &types.Project{Services: types.Services{
+		"web": {Ports: []types.ServicePortConfig{
+			{Target: 80, Published: "8080"},
+			{Target: 53, Published: "5353", Protocol: "udp", HostIP: "127.0.0.1"},
+			{Target: 9000},
+		}},
+		"rtc": {Ports: []types.ServicePortConfig{{Target: 10000, Published: "10000-10002"}}},
+	}}
+
+	bindings, err := publishedPorts(project)
+	if err != nil {
+		t.Fatalf("publishedPorts failed: %v", err)
+	}
+
+	expected := []string{"10000/tcp", "10001/tcp", "10002/tcp", "8080/tcp", "127.0.0.1:5353/udp"}
+	if len(bindings) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, bindings)
+	}
+	for i, binding := range bindings {
+		if binding.String() != expected[i] {
+			t.Errorf("Expected %s, got %s", expected[i], binding)
+		}
+	}
+
+	invalid := &types.Project{Services: types.Services{"web": {Ports: []types.ServicePortConfig{{Published: "90-80"}}}}}
+	if _, err := publishedPorts(invalid); err == nil {
+		t.Error("Expected error for invalid port range")
+	}
+}
+
+func TestFindPortConflicts(t *testing.T) {
+	installed := []InstalledPackage{
+		{Package: Package{Name: "nginx"}, Ports: []PortBinding{{Service: "web", Port: 8080, Protocol: "tcp"}}},
+		{Package: Package{Name: "dns"}, Ports: []PortBinding{{Service: "dns", HostIP: "127.0.0.1", Port: 53, Protocol: "udp"}}},
+		{Package: Package{Name: "self"}, Ports: []PortBinding{{Service: "app", Port: 3000, Protocol: "tcp"}}},
+	}
+	busy := func(b PortBinding) bool { return b.Port == 9999 || b.Port == 3000 }
+
+	tests := []struct {
+		name     string
+		binding  PortBinding
+		owner    string
+		conflict bool
+	}{
+		{name: "owned by another pak", binding: PortBinding{Port: 8080, Protocol: "tcp"}, owner: "nginx", conflict: true},
+		{name: "different protocol", binding: PortBinding{Port: 8080, Protocol: "udp"}},
+		{name: "different host ip", binding: PortBinding{HostIP: "10.0.0.1", Port: 53, Protocol: "udp"}},
+		{name: "wildcard overlaps specific ip", binding: PortBinding{Port: 53, Protocol: "udp"}, owner: "dns", conflict: true},
+		{name: "own running ports ignored", binding: PortBinding{Port: 3000, Protocol: "tcp"}},
+		{name: "listening on host", binding: PortBinding{Port: 9999, Protocol: "tcp"}, conflict: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conflicts := findPortConflicts([]PortBinding{tt.binding}, installed, "self", busy)
+			if !tt.conflict {
+				if len(conflicts) != 0 {
+					t.Errorf("Expected no conflict, got %+v", conflicts)
+				}
+				return
+			}
+			if len(conflicts) != 1 {
+				t.Fatalf("Expected one conflict, got %+v", conflicts)
+			}
+			if conflicts[0].owner != tt.owner {
+				t.Errorf("Expected owner %q, got %q", tt.owner, conflicts[0].owner)
+			}
+		})
+	}
+}
+
+func TestPortInUse(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer func() { _ = listener.Close() }()
+
+	port := listener.Addr().(*net.TCPAddr).Port
+	if !portInUse(PortBinding{HostIP: "127.0.0.1", Port: port, Protocol: "tcp"}) {
+		t.Errorf("Expected port %d to be reported in use", port)
+	}
+}
+
+func TestPortParameter(t *testing.T) {
+	pkg := Package{Parameters: map[string]Param{
+		"HTTP_PORT":  {Type: "port"},
+		"ADMIN_PORT": {Type: "port"},
+	}}
+	values := map[string]string{"HTTP_PORT": "8080", "ADMIN_PORT": "8080"}
+	composeText := "services:\n  web:\n    ports:\n      - \"${HTTP_PORT:-8080}:80\"\n"
+
+	if param := portParameter(pkg, values, composeText, 8080); param != "HTTP_PORT" {
+		t.Errorf("Expected HTTP_PORT, got %q", param)
+	}
+	if param := portParameter(pkg, values, composeText, 9090); param != "" {
+		t.Errorf("Expected no parameter, got %q", param)
+	}
+}
+
+func TestWithRunningPorts(t *testing.T) {
+	engine := composetest.NewEngine()
+	manager := NewManager(NewClient(t.TempDir()), engine, t.TempDir())
+	project := &types.Project{Name: "compak-legacy", Services: types.Services{
+		"web": {Name: "web", Ports: []types.ServicePortConfig{{Target: 80, Published: "8080", Protocol: "tcp"}}},
+	}}
+	if err := engine.Up(context.Background(), project, true, nil); err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+
+	installed := []InstalledPackage{
+		{Package: Package{Name: "legacy"}},
+		{Package: Package{Name: "other"}},
+	}
+	busy := func(b PortBinding) bool { return b.Port == 8080 }
+	binding := PortBinding{Service: "web", Port: 8080, Protocol: "tcp"}
+
+	if conflicts := findPortConflicts([]PortBinding{binding}, installed, "legacy", busy); len(conflicts) != 1 {
+		t.Fatalf("Expected the probe to report the legacy port, got %+v", conflicts)
+	}
+
+	withPorts := manager.withRunningPorts(installed, "legacy", project)
+	if conflicts := findPortConflicts([]PortBinding{binding}, withPorts, "legacy", busy); len(conflicts) != 0 {
+		t.Errorf("Expected own running port to be ignored, got %+v", conflicts)
+	}
+	if len(installed[0].Ports) != 0 {
+		t.Error("Expected the installed list not to be modified")
+	}
+}
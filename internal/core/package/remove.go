@@ -0,0 +1,219 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/docker/compose/v2/pkg/api"
+)
+
+type RemoveOptions struct {
+	KeepData bool
+	Purge    bool
+}
+
+type RemovalPlan struct {
+	Package       string
+	PackageDir    string
+	TrashDir      string
+	Purge         bool
+	BindMounts    []string
+	ExternalBinds []string
+	Volumes       []string
+	Images        []string
+	Networks      []string
+}
+
+func (p RemovalPlan) String() string {
+	var b strings.Builder
+	list := func(label string, items []string) {
+		if len(items) > 0 {
+			fmt.Fprintf(&b, "  %s: %s\n", label, strings.Join(items, ", "))
+		}
+	}
+
+	if p.Purge {
+		fmt.Fprintf(&b, "Uninstalling %s with --purge will permanently delete:\n", p.Package)
+		fmt.Fprintf(&b, "  containers of %s\n", p.Package)
+		if p.PackageDir != "" {
+			fmt.Fprintf(&b, "  package directory: %s\n", p.PackageDir)
+		}
+		list("bind-mounted data in the package directory", p.BindMounts)
+		list("volumes", p.Volumes)
+		list("images", p.Images)
+		list("networks", p.Networks)
+	} else {
+		fmt.Fprintf(&b, "Uninstalling %s will remove its containers and networks:\n", p.Package)
+		switch {
+		case p.TrashDir != "":
+			fmt.Fprintf(&b, "  package directory moves to: %s\n", p.TrashDir)
+			list("bind-mounted data moved with it", p.BindMounts)
+		case p.PackageDir != "":
+			fmt.Fprintf(&b, "  package directory is deleted: %s\n", p.PackageDir)
+			list("bind-mounted data deleted with it", p.BindMounts)
+		}
+		list("volumes kept", p.Volumes)
+	}
+	list("bind mounts outside the package directory (not touched)", p.ExternalBinds)
+
+	return b.String()
+}
+
+func (p RemovalPlan) DeletesData() bool {
+	return p.Purge || (p.PackageDir != "" && p.TrashDir == "")
+}
+
+func (m *Manager) PlanRemoval(packageName string, opts RemoveOptions) (RemovalPlan, error) {
+	if err := validatePackageName(packageName); err != nil {
+		return RemovalPlan{}, fmt.Errorf("invalid package name: %w", err)
+	}
+
+	installedPkg, err := m.client.GetInstalledPackage(packageName)
+	if err != nil {
+		return RemovalPlan{}, fmt.Errorf("package not found: %w", err)
+	}
+
+	plan := RemovalPlan{Package: packageName, Purge: opts.Purge}
+
+	packageDir := filepath.Join(m.packagesDir, packageName)
+	if _, err := os.Stat(packageDir); err != nil {
+		return plan, nil
+	}
+	plan.PackageDir = packageDir
+	if opts.KeepData && !opts.Purge {
+		plan.TrashDir = filepath.Join(m.trashDir, fmt.Sprintf("%s-%s", packageName, time.Now().Format("20060102-150405")))
+	}
+
+	project, err := m.installedProject(installedPkg)
+	if err != nil {
+		return plan, err
+	}
+	plan.BindMounts, plan.ExternalBinds = bindMounts(project, packageDir)
+	plan.Volumes = namedVolumes(project.Volumes)
+	plan.Networks = namedNetworks(project.Networks)
+	for _, name := range project.ServiceNames() {
+		if image := project.Services[name].Image; image != "" && !slices.Contains(plan.Images, image) {
+			plan.Images = append(plan.Images, image)
+		}
+	}
+
+	return plan, nil
+}
+
+func (m *Manager) Remove(packageName string, opts RemoveOptions) error {
+	plan, err := m.PlanRemoval(packageName, opts)
+	if err != nil {
+		return err
+	}
+	return m.RemoveWithPlan(plan)
+}
+
+func (m *Manager) RemoveWithPlan(plan RemovalPlan) error {
+	installedPkg, err := m.client.GetInstalledPackage(plan.Package)
+	if err != nil {
+		return fmt.Errorf("package not found: %w", err)
+	}
+
+	if plan.PackageDir == "" {
+		fmt.Printf("Warning: package directory not found, cleaning up metadata only\n")
+		return m.client.Uninstall(installedPkg.Package.Name)
+	}
+
+	options := api.DownOptions{}
+	if plan.Purge {
+		options.Volumes = true
+		options.Images = "all"
+	}
+
+	project, err := m.installedProject(installedPkg)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Stopping %s...\n", plan.Package)
+	if err := m.engine.Down(context.Background(), project, options); err != nil {
+		return fmt.Errorf("failed to stop services: %w", err)
+	}
+
+	if plan.TrashDir != "" {
+		if err := m.moveToTrash(plan.PackageDir, plan.TrashDir); err != nil {
+			return err
+		}
+		fmt.Printf("Moved %s to %s\n", plan.PackageDir, plan.TrashDir)
+	} else {
+		fmt.Printf("Cleaning up %s...\n", plan.Package)
+		if err := os.RemoveAll(plan.PackageDir); err != nil {
+			fmt.Printf("Warning: failed to remove package directory: %v\n", err)
+		}
+	}
+
+	return m.client.Uninstall(installedPkg.Package.Name)
+}
+
+func (m *Manager) moveToTrash(packageDir, trashDir string) error {
+	if err := os.MkdirAll(filepath.Dir(trashDir), 0o750); err != nil {
+		return fmt.Errorf("failed to create trash directory: %w", err)
+	}
+	if err := os.Rename(packageDir, trashDir); err != nil {
+		return fmt.Errorf("failed to move package directory to trash: %w", err)
+	}
+	return nil
+}
+
+func bindMounts(project *types.Project, packageDir string) (inside, outside []string) {
+	for _, name := range project.ServiceNames() {
+		for _, volume := range project.Services[name].Volumes {
+			if volume.Type != types.VolumeTypeBind {
+				continue
+			}
+			rel, err := filepath.Rel(packageDir, volume.Source)
+			switch {
+			case err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)):
+				if !slices.Contains(inside, "./"+filepath.ToSlash(rel)) {
+					inside = append(inside, "./"+filepath.ToSlash(rel))
+				}
+			case !slices.Contains(outside, volume.Source):
+				outside = append(outside, volume.Source)
+			}
+		}
+	}
+	return inside, outside
+}
+
+func namedVolumes(volumes types.Volumes) []string {
+	var names []string
+	for key, volume := range volumes {
+		if volume.External {
+			continue
+		}
+		name := volume.Name
+		if name == "" {
+			name = key
+		}
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+func namedNetworks(networks types.Networks) []string {
+	var names []string
+	for key, network := range networks {
+		if network.External {
+			continue
+		}
+		name := network.Name
+		if name == "" {
+			name = key
+		}
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
@@ -0,0 +1,280 @@
+package pkg
+
+import (
+	"archive/tar"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/klauspost/compress/zstd"
+)
+
+func (m *Manager) Restore(ctx context.Context, r io.Reader) (InstalledPackage, error) {
+	decoder, err := zstd.NewReader(r)
+	if err != nil {
+		return InstalledPackage{}, fmt.Errorf("failed to open backup archive: %w", err)
+	}
+	defer decoder.Close()
+	archive := tar.NewReader(decoder)
+
+	manifest, installedPkg, err := readBackupHeader(archive)
+	if err != nil {
+		return InstalledPackage{}, err
+	}
+	packageDir, err := m.prepareRestore(manifest.Package)
+	if err != nil {
+		return InstalledPackage{}, err
+	}
+
+	project, err := m.restoreArchive(ctx, archive, packageDir, installedPkg, manifest)
+	if err == nil {
+		fmt.Printf("Starting %s...\n", manifest.Package)
+		if err = m.engine.Up(ctx, project, true, nil); err != nil {
+			err = fmt.Errorf("failed to start services: %w", err)
+		}
+	}
+	if err != nil {
+		_ = os.RemoveAll(packageDir)
+		return InstalledPackage{}, err
+	}
+
+	installedPkg.Status = "installed"
+	if err := m.client.saveInstalledPackage(installedPkg); err != nil {
+		return InstalledPackage{}, fmt.Errorf("failed to save package state: %w", err)
+	}
+	return installedPkg, nil
+}
+
+func readBackupHeader(archive *tar.Reader) (BackupManifest, InstalledPackage, error) {
+	var manifest BackupManifest
+	if err := readJSONEntry(archive, backupManifestFile, &manifest); err != nil {
+		return BackupManifest{}, InstalledPackage{}, err
+	}
+	if manifest.Format != backupFormat {
+		return BackupManifest{}, InstalledPackage{}, fmt.Errorf("unsupported backup format %d", manifest.Format)
+	}
+
+	var installedPkg InstalledPackage
+	if err := readJSONEntry(archive, backupStateFile, &installedPkg); err != nil {
+		return BackupManifest{}, InstalledPackage{}, err
+	}
+	if installedPkg.Package.Name != manifest.Package {
+		return BackupManifest{}, InstalledPackage{}, fmt.Errorf("backup manifest is for %s but contains %s", manifest.Package, installedPkg.Package.Name)
+	}
+	if err := validateInstalledPackage(installedPkg); err != nil {
+		return BackupManifest{}, InstalledPackage{}, fmt.Errorf("invalid package data: %w", err)
+	}
+	return manifest, installedPkg, nil
+}
+
+func readJSONEntry(archive *tar.Reader, name string, v any) error {
+	header, err := archive.Next()
+	if err != nil {
+		return fmt.Errorf("failed to read backup archive: %w", err)
+	}
+	if header.Name != name {
+		return fmt.Errorf("invalid backup archive: expected %s, found %s", name, header.Name)
+	}
+	if err := json.NewDecoder(io.LimitReader(archive, maxDownloadSize)).Decode(v); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", name, err)
+	}
+	return nil
+}
+
+func (m *Manager) prepareRestore(packageName string) (string, error) {
+	if err := validatePackageName(packageName); err != nil {
+		return "", fmt.Errorf("invalid package name: %w", err)
+	}
+	if _, err := m.client.GetInstalledPackage(packageName); err == nil {
+		return "", fmt.Errorf("package %s is already installed", packageName)
+	}
+
+	packageDir := filepath.Join(m.packagesDir, packageName)
+	if _, err := os.Stat(packageDir); err == nil {
+		return "", fmt.Errorf("package directory %s already exists", packageDir)
+	}
+	if err := os.MkdirAll(packageDir, 0o750); err != nil {
+		return "", fmt.Errorf("failed to create package directory: %w", err)
+	}
+	return packageDir, nil
+}
+
+func (m *Manager) restoreArchive(ctx context.Context, archive *tar.Reader, packageDir string, installedPkg InstalledPackage, manifest BackupManifest) (*types.Project, error) {
+	root, err := os.OpenRoot(packageDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open package directory: %w", err)
+	}
+	defer func() { _ = root.Close() }()
+
+	var project *types.Project
+	volumes := &volumeRestore{manager: m, ctx: ctx}
+	for {
+		header, err := archive.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.Join(fmt.Errorf("failed to read backup archive: %w", err), volumes.finish())
+		}
+
+		if rel, ok := strings.CutPrefix(header.Name, backupPackageDir+"/"); ok {
+			if err := extractEntry(root, rel, header, archive); err != nil {
+				return nil, errors.Join(err, volumes.finish())
+			}
+			continue
+		}
+
+		rel, ok := strings.CutPrefix(header.Name, backupVolumesDir+"/")
+		key, name, _ := strings.Cut(rel, "/")
+		if !ok || key == "" {
+			return nil, errors.Join(fmt.Errorf("unexpected backup entry %s", header.Name), volumes.finish())
+		}
+		if project == nil {
+			if project, err = m.installedProject(installedPkg); err != nil {
+				return nil, errors.Join(err, volumes.finish())
+			}
+		}
+		if err := volumes.write(project, key, name, header, archive); err != nil {
+			return nil, errors.Join(err, volumes.finish())
+		}
+	}
+	if err := volumes.finish(); err != nil {
+		return nil, err
+	}
+
+	if project == nil {
+		if project, err = m.installedProject(installedPkg); err != nil {
+			return nil, err
+		}
+	}
+	return project, volumes.createMissing(project, manifest.Volumes)
+}
+
+func extractEntry(root *os.Root, name string, header *tar.Header, r io.Reader) error {
+	name = strings.TrimSuffix(name, "/")
+	if name == "" || name == "." {
+		return nil
+	}
+	if !fs.ValidPath(name) {
+		return fmt.Errorf("invalid path in backup archive: %s", header.Name)
+	}
+
+	mode := fs.FileMode(header.Mode).Perm()
+	switch header.Typeflag {
+	case tar.TypeDir:
+		if err := root.MkdirAll(name, 0o750); err != nil {
+			return fmt.Errorf("failed to restore %s: %w", name, err)
+		}
+		return root.Chmod(name, mode)
+	case tar.TypeSymlink:
+		if err := root.MkdirAll(path.Dir(name), 0o750); err != nil {
+			return fmt.Errorf("failed to restore %s: %w", name, err)
+		}
+		return root.Symlink(header.Linkname, name)
+	case tar.TypeReg:
+		if err := root.MkdirAll(path.Dir(name), 0o750); err != nil {
+			return fmt.Errorf("failed to restore %s: %w", name, err)
+		}
+		return writeRootFile(root, name, mode, r)
+	default:
+		return fmt.Errorf("unsupported entry in backup archive: %s", header.Name)
+	}
+}
+
+func writeRootFile(root *os.Root, name string, mode fs.FileMode, r io.Reader) error {
+	f, err := root.OpenFile(name, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+	if err != nil {
+		return fmt.Errorf("failed to restore %s: %w", name, err)
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("failed to restore %s: %w", name, err)
+	}
+	return f.Close()
+}
+
+type volumeRestore struct {
+	manager  *Manager
+	ctx      context.Context
+	key      string
+	writer   *io.PipeWriter
+	archive  *tar.Writer
+	done     chan error
+	restored []string
+}
+
+func (v *volumeRestore) write(project *types.Project, key, name string, header *tar.Header, r io.Reader) error {
+	if key != v.key {
+		if err := v.finish(); err != nil {
+			return err
+		}
+		v.start(project, key)
+	}
+
+	if name == "" {
+		return nil
+	}
+	header.Name = name
+	if err := v.archive.WriteHeader(header); err != nil {
+		return fmt.Errorf("failed to restore volume %s: %w", key, err)
+	}
+	if _, err := io.Copy(v.archive, r); err != nil {
+		return fmt.Errorf("failed to restore volume %s: %w", key, err)
+	}
+	return nil
+}
+
+func (v *volumeRestore) start(project *types.Project, key string) {
+	reader, writer := io.Pipe()
+	v.key = key
+	v.writer = writer
+	v.archive = tar.NewWriter(writer)
+	v.done = make(chan error, 1)
+	v.restored = append(v.restored, key)
+
+	fmt.Printf("Restoring volume %s...\n", key)
+	go func() {
+		err := v.manager.engine.ImportVolume(v.ctx, project, key, reader)
+		if err == nil {
+			_, _ = io.Copy(io.Discard, reader)
+		}
+		_ = reader.CloseWithError(err)
+		v.done <- err
+	}()
+}
+
+func (v *volumeRestore) finish() error {
+	if v.writer == nil {
+		return nil
+	}
+	err := v.archive.Close()
+	_ = v.writer.CloseWithError(err)
+	err = errors.Join(err, <-v.done)
+	v.key, v.writer, v.archive = "", nil, nil
+	if err != nil {
+		return fmt.Errorf("failed to restore volume: %w", err)
+	}
+	return nil
+}
+
+func (v *volumeRestore) createMissing(project *types.Project, volumes []BackupVolume) error {
+	for _, volume := range volumes {
+		if slices.Contains(v.restored, volume.Key) {
+			continue
+		}
+		v.start(project, volume.Key)
+		if err := v.finish(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+package pkg
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const digestPrefix = "sha256:"
+
+type Sources []string
+
+func (s *Sources) UnmarshalYAML(node *yaml.Node) error {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		var single string
+		if err := node.Decode(&single); err != nil {
+			return err
+		}
+		*s = sourcesFromString(single)
+		return nil
+	case yaml.SequenceNode:
+		var list []string
+		if err := node.Decode(&list); err != nil {
+			return err
+		}
+		*s = list
+		return nil
+	}
+	return fmt.Errorf("source must be a URL or a list of URLs")
+}
+
+func (s Sources) MarshalYAML() (any, error) {
+	if len(s) == 1 {
+		return s[0], nil
+	}
+	return []string(s), nil
+}
+
+func (s *Sources) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*s = sourcesFromString(single)
+		return nil
+	}
+
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return fmt.Errorf("source must be a URL or a list of URLs")
+	}
+	*s = list
+	return nil
+}
+
+func (s Sources) MarshalJSON() ([]byte, error) {
+	if len(s) == 1 {
+		return json.Marshal(s[0])
+	}
+	return json.Marshal([]string(s))
+}
+
+func (s Sources) String() string {
+	return strings.Join(s, ", ")
+}
+
+func sourcesFromString(source string) Sources {
+	if source == "" {
+		return nil
+	}
+	return Sources{source}
+}
+
+func sourceFileNames(sources Sources) ([]string, error) {
+	names := make([]string, 0, len(sources))
+	seen := make(map[string]bool, len(sources))
+
+	for i, source := range sources {
+		u, err := url.Parse(source)
+		if err != nil {
+			return nil, fmt.Errorf("invalid source URL %s: %w", source, err)
+		}
+
+		name := path.Base(u.Path)
+		if !strings.HasSuffix(name, ".yml") && !strings.HasSuffix(name, ".yaml") {
+			name = "docker-compose.yaml"
+			if i > 0 {
+				name = fmt.Sprintf("compose-%d.yaml", i+1)
+			}
+		}
+		if seen[name] {
+			name = fmt.Sprintf("%d-%s", i+1, name)
+		}
+
+		seen[name] = true
+		names = append(names, name)
+	}
+
+	return names, nil
+}
+
+func validateSourceDigest(pkg Package) error {
+	if pkg.SourceDigest == "" {
+		return nil
+	}
+	if len(pkg.Source) == 0 {
+		return fmt.Errorf("sourceDigest set but package has no source")
+	}
+	if !strings.HasPrefix(pkg.SourceDigest, digestPrefix) || !isSHA256Hex(strings.TrimPrefix(pkg.SourceDigest, digestPrefix)) {
+		return fmt.Errorf("sourceDigest must have the form sha256:<64 hex characters>")
+	}
+	return nil
+}
+
+func SourcesDigest(contents [][]byte) string {
+	checksums := make([]string, 0, len(contents))
+	for _, content := range contents {
+		sum := sha256.Sum256(content)
+		checksums = append(checksums, hex.EncodeToString(sum[:]))
+	}
+
+	if len(checksums) == 1 {
+		return digestPrefix + checksums[0]
+	}
+	sum := sha256.Sum256([]byte(strings.Join(checksums, "\n")))
+	return digestPrefix + hex.EncodeToString(sum[:])
+}
+
+func verifySourceDigest(pkg Package, actual string, quietUnpinned bool) error {
+	switch {
+	case pkg.SourceDigest == "" && quietUnpinned:
+	case pkg.SourceDigest == "":
+		fmt.Printf("Warning: %s has no sourceDigest, compose file is not verified (computed %s); pass --quiet-unpinned to silence this warning\n", pkg.Name, actual)
+	case !strings.EqualFold(pkg.SourceDigest, actual):
+		return fmt.Errorf("source digest mismatch for %s: expected %s, got %s", pkg.Name, pkg.SourceDigest, actual)
+	}
+	return nil
+}
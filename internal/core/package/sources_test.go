@@ -0,0 +1,122 @@
+package pkg
+
+import (
+	"encoding/json"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestSources_Unmarshal(t *testing.T) {
+	tests := []struct {
+		name     string
+		yamlDoc  string
+		jsonDoc  string
+		expected Sources
+	}{
+		{
+			name:     "single URL",
+			yamlDoc:  "source: https://example.com/compose.yaml",
+			jsonDoc:  `{"source": "https://example.com/compose.yaml"}`,
+			expected: Sources{"https://example.com/compose.yaml"},
+		},
+		{
+			name:     "list of URLs",
+			yamlDoc:  "source:\n  - https://example.com/compose.yaml\n  - https://example.com/compose.override.yaml",
+			jsonDoc:  `{"source": ["https://example.com/compose.yaml", "https://example.com/compose.override.yaml"]}`,
+			expected: Sources{"https://example.com/compose.yaml", "https://example.com/compose.override.yaml"},
+		},
+		{
+			name:     "empty",
+			yamlDoc:  "source: \"\"",
+			jsonDoc:  `{"source": ""}`,
+			expected: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var fromYAML Package
+			if err := yaml.Unmarshal([]byte(tt.yamlDoc), &fromYAML); err != nil {
+				t.Fatalf("yaml.Unmarshal failed: %v", err)
+			}
+			assertSources(t, fromYAML.Source, tt.expected)
+
+			var fromJSON Package
+			if err := json.Unmarshal([]byte(tt.jsonDoc), &fromJSON); err != nil {
+				t.Fatalf("json.Unmarshal failed: %v", err)
+			}
+			assertSources(t, fromJSON.Source, tt.expected)
+		})
+	}
+}
+
+func TestSources_MarshalSingleAsString(t *testing.T) {
+	data, err := json.Marshal(Package{Source: Sources{"https://example.com/compose.yaml"}})
+	if err != nil {
+		t.Fatalf("json.Marshal failed: %v", err)
+	}
+
+	var raw map[string]any
+	if err := json.Unmarshal(data, &raw); err != nil {
+		t.Fatalf("json.Unmarshal failed: %v", err)
+	}
+	if _, ok := raw["source"].(string); !ok {
+		t.Errorf("Expected single source to be marshaled as string, got %T", raw["source"])
+	}
+}
+
+func TestSourceFileNames(t *testing.T) {
+	names, err := sourceFileNames(Sources{
+		"https://raw.githubusercontent.com/immich-app/immich/main/docker/docker-compose.yml",
+		"https://raw.githubusercontent.com/immich-app/immich/main/docker/hwaccel.yml",
+		"https://example.com/other/docker-compose.yml",
+		"https://example.com/raw?file=compose",
+	})
+	if err != nil {
+		t.Fatalf("sourceFileNames failed: %v", err)
+	}
+
+	expected := []string{"docker-compose.yml", "hwaccel.yml", "3-docker-compose.yml", "compose-4.yaml"}
+	assertSources(t, names, expected)
+}
+
+func assertSources(t *testing.T, got, want []string) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("Expected %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Expected %v, got %v", want, got)
+		}
+	}
+}
+
+func TestValidateSourceDigest(t *testing.T) {
+	valid := SourcesDigest([][]byte{[]byte("content")})
+
+	tests := []struct {
+		name      string
+		pkg       Package
+		expectErr bool
+	}{
+		{name: "no digest", pkg: Package{Source: Sources{"https://example.com/compose.yaml"}}},
+		{name: "valid digest", pkg: Package{Source: Sources{"https://example.com/compose.yaml"}, SourceDigest: valid}},
+		{name: "missing prefix", pkg: Package{Source: Sources{"https://example.com/compose.yaml"}, SourceDigest: valid[len(digestPrefix):]}, expectErr: true},
+		{name: "short digest", pkg: Package{Source: Sources{"https://example.com/compose.yaml"}, SourceDigest: "sha256:abc"}, expectErr: true},
+		{name: "digest without source", pkg: Package{SourceDigest: valid}, expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateSourceDigest(tt.pkg)
+			if tt.expectErr && err == nil {
+				t.Error("Expected error but got none")
+			}
+			if !tt.expectErr && err != nil {
+				t.Errorf("Expected no error but got: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,167 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/docker/compose/v2/pkg/api"
+)
+
+const (
+	StateRunning  = "running"
+	StateDegraded = "degraded"
+	StateStopped  = "stopped"
+	StateMissing  = "missing"
+)
+
+type PackageStatus struct {
+	Package      string
+	Version      string
+	State        string
+	ImagesPinned bool
+	Services     []ServiceStatus
+}
+
+type ServiceStatus struct {
+	Service   string
+	Container string
+	Image     string
+	Digest    string
+	State     string
+	Health    string
+	Ports     []string
+	StartedAt time.Time
+	Restarts  int
+	ExitCode  int
+}
+
+func (s ServiceStatus) Uptime(now time.Time) time.Duration {
+	if s.State != "running" || s.StartedAt.IsZero() {
+		return 0
+	}
+	return now.Sub(s.StartedAt)
+}
+
+func (m *Manager) Status(ctx context.Context, packageName string) (PackageStatus, error) {
+	if err := validatePackageName(packageName); err != nil {
+		return PackageStatus{}, fmt.Errorf("invalid package name: %w", err)
+	}
+
+	installedPkg, err := m.client.GetInstalledPackage(packageName)
+	if err != nil {
+		return PackageStatus{}, fmt.Errorf("package %s is not installed", packageName)
+	}
+
+	project, err := m.installedProject(installedPkg)
+	if err != nil {
+		return PackageStatus{}, err
+	}
+	containers, err := m.engine.PS(ctx, project)
+	if err != nil {
+		return PackageStatus{}, fmt.Errorf("failed to get status: %w", err)
+	}
+
+	ids := make([]string, 0, len(containers))
+	for _, container := range containers {
+		ids = append(ids, container.ID)
+	}
+	details, err := m.engine.Inspect(ctx, ids...)
+	if err != nil {
+		return PackageStatus{}, fmt.Errorf("failed to inspect containers: %w", err)
+	}
+
+	status := PackageStatus{
+		Package:      packageName,
+		Version:      installedPkg.Package.Version,
+		State:        packageState(project, containers),
+		ImagesPinned: installedPkg.ImagesPinned,
+	}
+	for _, container := range containers {
+		status.Services = append(status.Services, ServiceStatus{
+			Service:   container.Service,
+			Container: container.Name,
+			Image:     container.Image,
+			Digest:    ImageDigest(installedPkg.Images[container.Service]),
+			State:     container.State,
+			Health:    container.Health,
+			Ports:     formatPorts(container.Publishers),
+			StartedAt: details[container.ID].StartedAt,
+			Restarts:  details[container.ID].RestartCount,
+			ExitCode:  container.ExitCode,
+		})
+	}
+	for _, name := range missingServices(project, containers) {
+		status.Services = append(status.Services, ServiceStatus{
+			Service: name,
+			Image:   project.Services[name].Image,
+			Digest:  ImageDigest(installedPkg.Images[name]),
+			State:   StateMissing,
+		})
+	}
+	slices.SortStableFunc(status.Services, func(a, b ServiceStatus) int { return strings.Compare(a.Service, b.Service) })
+
+	return status, nil
+}
+
+func packageState(project *types.Project, containers []api.ContainerSummary) string {
+	if len(containers) == 0 {
+		return StateMissing
+	}
+
+	running := slices.ContainsFunc(containers, func(c api.ContainerSummary) bool { return c.State == "running" })
+	if !running {
+		return StateStopped
+	}
+
+	byService := make(map[string][]api.ContainerSummary)
+	for _, container := range containers {
+		byService[container.Service] = append(byService[container.Service], container)
+	}
+	for _, name := range project.ServiceNames() {
+		service := project.Services[name]
+		if service.GetScale() > 0 && serviceProblem(service, byService[name]) != "" {
+			return StateDegraded
+		}
+	}
+	return StateRunning
+}
+
+func missingServices(project *types.Project, containers []api.ContainerSummary) []string {
+	var missing []string
+	for _, name := range project.ServiceNames() {
+		service := project.Services[name]
+		if service.GetScale() == 0 {
+			continue
+		}
+		if !slices.ContainsFunc(containers, func(c api.ContainerSummary) bool { return c.Service == name }) {
+			missing = append(missing, name)
+		}
+	}
+	return missing
+}
+
+func formatPorts(publishers api.PortPublishers) []string {
+	var ports []string
+	for _, publisher := range publishers {
+		protocol := publisher.Protocol
+		if protocol == "" {
+			protocol = "tcp"
+		}
+		port := fmt.Sprintf("%d/%s", publisher.TargetPort, protocol)
+		if publisher.PublishedPort != 0 {
+			host := publisher.URL
+			if strings.Contains(host, ":") {
+				host = "[" + host + "]"
+			}
+			port = fmt.Sprintf("%s:%d->%s", host, publisher.PublishedPort, port)
+		}
+		if !slices.Contains(ports, port) {
+			ports = append(ports, port)
+		}
+	}
+	return ports
+}
@@ -0,0 +1,118 @@
+package pkg
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/docker/compose/v2/pkg/api"
+
+	"github.com/LoriKarikari/compak/internal/core/compose/composetest"
+)
+
+func TestPackageState(t *testing.T) {
+	project := &types.Project{Name: "compak-demo", Services: types.Services{
+		"web": {Name: "web"},
+		"db":  {Name: "db"},
+	}}
+
+	tests := []struct {
+		name       string
+		containers []api.ContainerSummary
+		expected   string
+	}{
+		{name: "no containers", expected: StateMissing},
+		{
+			name:       "all running",
+			containers: []api.ContainerSummary{{Service: "web", State: "running"}, {Service: "db", State: "running", Health: "healthy"}},
+			expected:   StateRunning,
+		},
+		{
+			name:       "all exited",
+			containers: []api.ContainerSummary{{Service: "web", State: "exited", ExitCode: 137}, {Service: "db", State: "exited"}},
+			expected:   StateStopped,
+		},
+		{
+			name:       "one exited",
+			containers: []api.ContainerSummary{{Service: "web", State: "running"}, {Service: "db", State: "exited", ExitCode: 1}},
+			expected:   StateDegraded,
+		},
+		{
+			name:       "unhealthy",
+			containers: []api.ContainerSummary{{Service: "web", State: "running"}, {Service: "db", State: "running", Health: "unhealthy"}},
+			expected:   StateDegraded,
+		},
+		{
+			name:       "service missing",
+			containers: []api.ContainerSummary{{Service: "web", State: "running"}},
+			expected:   StateDegraded,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := packageState(project, tt.containers); got != tt.expected {
+				t.Errorf("Expected state %s, got %s", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestFormatPorts(t *testing.T) {
+	publishers := api.PortPublishers{
+		{URL: "0.0.0.0", PublishedPort: 8080, TargetPort: 80, Protocol: "tcp"},
+		{URL: "::", PublishedPort: 8080, TargetPort: 80, Protocol: "tcp"},
+		{TargetPort: 53, Protocol: "udp"},
+		{URL: "0.0.0.0", PublishedPort: 8080, TargetPort: 80, Protocol: "tcp"},
+	}
+
+	expected := []string{"0.0.0.0:8080->80/tcp", "[::]:8080->80/tcp", "53/udp"}
+	if got := formatPorts(publishers); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+}
+
+func TestManagerStatus(t *testing.T) {
+	compose := "services:\n  web:\n    image: nginx:alpine\n    ports:\n      - \"8080:80\"\n  worker:\n    image: busybox\n    restart: unless-stopped\n"
+	engine := composetest.NewEngine()
+	manager, _ := setupRunningPackage(t, engine, InstalledPackage{Package: Package{Name: "demo", Version: "1.0.0"}}, map[string]string{
+		testComposeFilename: compose,
+	})
+	ctx := context.Background()
+
+	if err := engine.SetRestartCount("compak-demo", "web", 4); err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+	if err := engine.SetState("compak-demo", "worker", "exited"); err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+
+	status, err := manager.Status(ctx, "demo")
+	if err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+	if status.State != StateDegraded || status.Version != "1.0.0" {
+		t.Errorf("Expected degraded demo@1.0.0, got %+v", status)
+	}
+
+	web := status.Services[0]
+	if web.Service != "web" || web.Image != "nginx:alpine" || web.Restarts != 4 || web.StartedAt.IsZero() ||
+		!reflect.DeepEqual(web.Ports, []string{":8080->80/tcp"}) {
+		t.Errorf("Unexpected web status: %+v", web)
+	}
+
+	engine.RemoveContainer("compak-demo", "web")
+	engine.RemoveContainer("compak-demo", "worker")
+	status, err = manager.Status(ctx, "demo")
+	if err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+	if status.State != StateMissing || len(status.Services) != 2 || status.Services[0].State != StateMissing {
+		t.Errorf("Expected missing package with missing services, got %+v", status)
+	}
+
+	if _, err := manager.Status(ctx, "other"); err == nil {
+		t.Error("Expected error for a package that is not installed")
+	}
+}
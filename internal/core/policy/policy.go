@@ -0,0 +1,170 @@
+package policy
+
+import (
+	"fmt"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/compose-spec/compose-go/v2/types"
+)
+
+type Action string
+
+const (
+	ActionOff  Action = "off"
+	ActionWarn Action = "warn"
+	ActionDeny Action = "deny"
+)
+
+type Rule string
+
+const (
+	RulePrivileged   Rule = "privileged"
+	RuleHostNetwork  Rule = "host-network"
+	RuleDockerSocket Rule = "docker-socket"
+	RuleSysAdmin     Rule = "sys-admin"
+	RuleHostBind     Rule = "host-bind"
+	RuleLatestImage  Rule = "latest-image"
+)
+
+var defaultActions = map[Rule]Action{
+	RulePrivileged:   ActionDeny,
+	RuleHostNetwork:  ActionWarn,
+	RuleDockerSocket: ActionDeny,
+	RuleSysAdmin:     ActionDeny,
+	RuleHostBind:     ActionWarn,
+	RuleLatestImage:  ActionWarn,
+}
+
+var socketNames = []string{"docker.sock", "podman.sock", "containerd.sock"}
+
+type Policy struct {
+	Rules             map[Rule]Action `yaml:"rules"`
+	AllowedBindRoots  []string        `yaml:"allowedBindRoots"`
+	RequireSignatures bool            `yaml:"requireSignatures"`
+}
+
+type Finding struct {
+	Rule    Rule
+	Action  Action
+	Service string
+	Message string
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("[%s] %s: %s (%s)", f.Action, f.Service, f.Message, f.Rule)
+}
+
+func (p Policy) Validate() error {
+	for rule, action := range p.Rules {
+		if _, known := defaultActions[rule]; !known {
+			return fmt.Errorf("unknown policy rule %q", rule)
+		}
+		switch action {
+		case ActionOff, ActionWarn, ActionDeny:
+		default:
+			return fmt.Errorf("invalid action %q for policy rule %s: must be off, warn or deny", action, rule)
+		}
+	}
+	for _, root := range p.AllowedBindRoots {
+		if !filepath.IsAbs(root) {
+			return fmt.Errorf("allowed bind root %s must be an absolute path", root)
+		}
+	}
+	return nil
+}
+
+func (p Policy) action(rule Rule) Action {
+	if action, ok := p.Rules[rule]; ok {
+		return action
+	}
+	return defaultActions[rule]
+}
+
+func (p Policy) Evaluate(project *types.Project) []Finding {
+	var findings []Finding
+	add := func(rule Rule, service, format string, args ...any) {
+		action := p.action(rule)
+		if action == ActionOff {
+			return
+		}
+		findings = append(findings, Finding{
+			Rule:    rule,
+			Action:  action,
+			Service: service,
+			Message: fmt.Sprintf(format, args...),
+		})
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(project.Services)) {
+		service := project.Services[name]
+
+		if service.Privileged {
+			add(RulePrivileged, name, "runs in privileged mode")
+		}
+		if service.NetworkMode == "host" {
+			add(RuleHostNetwork, name, "uses the host network")
+		}
+		for _, capability := range service.CapAdd {
+			switch strings.TrimPrefix(strings.ToUpper(capability), "CAP_") {
+			case "SYS_ADMIN", "ALL":
+				add(RuleSysAdmin, name, "adds capability %s", capability)
+			}
+		}
+		for _, volume := range service.Volumes {
+			if volume.Type != types.VolumeTypeBind {
+				continue
+			}
+			switch {
+			case isSocket(volume.Source):
+				add(RuleDockerSocket, name, "mounts the container runtime socket %s", volume.Source)
+			case !p.bindAllowed(volume.Source, project.WorkingDir):
+				add(RuleHostBind, name, "mounts host path %s", volume.Source)
+			}
+		}
+		if service.Image != "" && usesLatestTag(service.Image) {
+			add(RuleLatestImage, name, "image %s is not pinned to a version", service.Image)
+		}
+	}
+
+	return findings
+}
+
+func Denied(findings []Finding) []Finding {
+	var denied []Finding
+	for _, finding := range findings {
+		if finding.Action == ActionDeny {
+			denied = append(denied, finding)
+		}
+	}
+	return denied
+}
+
+func (p Policy) bindAllowed(source, projectDir string) bool {
+	roots := p.AllowedBindRoots
+	if projectDir != "" {
+		roots = append([]string{projectDir}, roots...)
+	}
+	for _, root := range roots {
+		rel, err := filepath.Rel(filepath.Clean(root), filepath.Clean(source))
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+func isSocket(source string) bool {
+	return slices.Contains(socketNames, filepath.Base(source))
+}
+
+func usesLatestTag(image string) bool {
+	if strings.Contains(image, "@") {
+		return false
+	}
+	name := image[strings.LastIndex(image, "/")+1:]
+	tagIndex := strings.LastIndex(name, ":")
+	return tagIndex == -1 || name[tagIndex+1:] == "latest"
+}
@@ -0,0 +1,127 @@
+package policy
+
+import (
+	"testing"
+
+	"github.com/compose-spec/compose-go/v2/types"
+)
+
+func TestPolicy_Evaluate(t *testing.T) {
+	tests := []struct {
+		name     string
+		service  types.ServiceConfig
+		policy   Policy
+		expected []Rule
+		denied   int
+	}{
+		{
+			name:    "pinned image with named volume",
+			service: types.ServiceConfig{Image: "nginx:1.27", Volumes: []types.ServiceVolumeConfig{{Type: types.VolumeTypeVolume, Source: "data", Target: "/data"}}},
+		},
+		{
+			name:     "privileged",
+			service:  types.ServiceConfig{Image: "nginx:1.27", Privileged: true},
+			expected: []Rule{RulePrivileged},
+			denied:   1,
+		},
+		{
+			name:     "host network",
+			service:  types.ServiceConfig{Image: "nginx:1.27", NetworkMode: "host"},
+			expected: []Rule{RuleHostNetwork},
+		},
+		{
+			name:     "sys admin capability",
+			service:  types.ServiceConfig{Image: "nginx:1.27", CapAdd: []string{"NET_ADMIN", "CAP_SYS_ADMIN"}},
+			expected: []Rule{RuleSysAdmin},
+			denied:   1,
+		},
+		{
+			name:     "docker socket",
+			service:  types.ServiceConfig{Image: "traefik:v3.1", Volumes: []types.ServiceVolumeConfig{{Type: types.VolumeTypeBind, Source: "/var/run/docker.sock", Target: "/var/run/docker.sock"}}},
+			expected: []Rule{RuleDockerSocket},
+			denied:   1,
+		},
+		{
+			name:     "bind outside allowed roots",
+			service:  types.ServiceConfig{Image: "nginx:1.27", Volumes: []types.ServiceVolumeConfig{{Type: types.VolumeTypeBind, Source: "/etc", Target: "/host-etc"}}},
+			policy:   Policy{AllowedBindRoots: []string{"/srv"}},
+			expected: []Rule{RuleHostBind},
+		},
+		{
+			name: "bind inside allowed root and package dir",
+			service: types.ServiceConfig{Image: "nginx:1.27", Volumes: []types.ServiceVolumeConfig{
+				{Type: types.VolumeTypeBind, Source: "/srv/media", Target: "/media"},
+				{Type: types.VolumeTypeBind, Source: "/state/packages/app/config", Target: "/config"},
+			}},
+			policy: Policy{AllowedBindRoots: []string{"/srv"}},
+		},
+		{
+			name:     "latest and untagged images",
+			service:  types.ServiceConfig{Image: "registry.example.com:5000/app"},
+			expected: []Rule{RuleLatestImage},
+		},
+		{
+			name:    "digest pinned image",
+			service: types.ServiceConfig{Image: "nginx@sha256:0000000000000000000000000000000000000000000000000000000000000000"},
+		},
+		{
+			name:     "rule overridden to warn",
+			service:  types.ServiceConfig{Image: "nginx:latest", Privileged: true},
+			policy:   Policy{Rules: map[Rule]Action{RulePrivileged: ActionWarn}},
+			expected: []Rule{RulePrivileged, RuleLatestImage},
+		},
+		{
+			name:    "rule turned off",
+			service: types.ServiceConfig{Image: "nginx:latest"},
+			policy:  Policy{Rules: map[Rule]Action{RuleLatestImage: ActionOff}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			project := &types.Project{
+				WorkingDir: "/state/packages/app",
+				Services:   types.Services{"app": tt.service},
+			}
+
+			findings := tt.policy.Evaluate(project)
+			if len(findings) != len(tt.expected) {
+				t.Fatalf("Expected findings %v, got %v", tt.expected, findings)
+			}
+			for i, finding := range findings {
+				if finding.Rule != tt.expected[i] {
+					t.Errorf("Expected rule %s, got %s", tt.expected[i], finding.Rule)
+				}
+			}
+			if denied := Denied(findings); len(denied) != tt.denied {
+				t.Errorf("Expected %d denied findings, got %v", tt.denied, denied)
+			}
+		})
+	}
+}
+
+func TestPolicy_Validate(t *testing.T) {
+	tests := []struct {
+		name      string
+		policy    Policy
+		expectErr bool
+	}{
+		{name: "empty policy"},
+		{name: "valid rules", policy: Policy{Rules: map[Rule]Action{RuleHostBind: ActionDeny}, AllowedBindRoots: []string{"/srv"}}},
+		{name: "unknown rule", policy: Policy{Rules: map[Rule]Action{"nope": ActionWarn}}, expectErr: true},
+		{name: "unknown action", policy: Policy{Rules: map[Rule]Action{RulePrivileged: "block"}}, expectErr: true},
+		{name: "relative bind root", policy: Policy{AllowedBindRoots: []string{"srv"}}, expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.policy.Validate()
+			if tt.expectErr && err == nil {
+				t.Error("Expected error but got none")
+			}
+			if !tt.expectErr && err != nil {
+				t.Errorf("Expected no error but got: %v", err)
+			}
+		})
+	}
+}
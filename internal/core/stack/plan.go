@@ -0,0 +1,174 @@
+package stack
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+
+	pkg "github.com/LoriKarikari/compak/internal/core/package"
+)
+
+type Action string
+
+const (
+	ActionInstall     Action = "install"
+	ActionUpgrade     Action = "upgrade"
+	ActionReconfigure Action = "reconfigure"
+	ActionUninstall   Action = "uninstall"
+)
+
+type Desired struct {
+	Entry   Entry
+	Package pkg.Package
+	Values  map[string]string
+}
+
+type Step struct {
+	Action    Action
+	Name      string
+	From      string
+	To        string
+	Changed   []string
+	Desired   *Desired
+	Installed *pkg.InstalledPackage
+}
+
+func (s Step) String() string {
+	switch s.Action {
+	case ActionInstall:
+		return fmt.Sprintf("+ install     %s %s", s.Name, s.To)
+	case ActionUpgrade:
+		verb := "upgrade    "
+		if older(s.To, s.From) {
+			verb = "downgrade  "
+		}
+		return fmt.Sprintf("~ %s %s %s → %s%s", verb, s.Name, s.From, s.To, changedSuffix(s.Changed))
+	case ActionReconfigure:
+		return fmt.Sprintf("~ reconfigure %s %s%s", s.Name, s.To, changedSuffix(s.Changed))
+	default:
+		return fmt.Sprintf("- uninstall   %s %s", s.Name, s.From)
+	}
+}
+
+type Plan struct {
+	Steps     []Step
+	Unchanged []string
+	Unmanaged []string
+}
+
+func (p Plan) Empty() bool {
+	return len(p.Steps) == 0
+}
+
+func (p Plan) String() string {
+	var b strings.Builder
+	for _, step := range p.Steps {
+		fmt.Fprintln(&b, step)
+	}
+	if len(p.Unchanged) > 0 {
+		fmt.Fprintf(&b, "  unchanged: %s\n", strings.Join(p.Unchanged, ", "))
+	}
+	if len(p.Unmanaged) > 0 {
+		fmt.Fprintf(&b, "  not in stack file (use --prune to uninstall): %s\n", strings.Join(p.Unmanaged, ", "))
+	}
+	return b.String()
+}
+
+func NewPlan(desired []Desired, installed []pkg.InstalledPackage, prune bool) (Plan, error) {
+	byName := make(map[string]*pkg.InstalledPackage, len(installed))
+	for i := range installed {
+		byName[installed[i].Package.Name] = &installed[i]
+	}
+
+	var plan Plan
+	for i := range desired {
+		d := &desired[i]
+		current, ok := byName[d.Package.Name]
+		delete(byName, d.Package.Name)
+
+		step, err := planPackage(d, current, ok)
+		if err != nil {
+			return Plan{}, err
+		}
+		if step == nil {
+			plan.Unchanged = append(plan.Unchanged, d.Package.Name)
+			continue
+		}
+		plan.Steps = append(plan.Steps, *step)
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(byName)) {
+		if !prune {
+			plan.Unmanaged = append(plan.Unmanaged, name)
+			continue
+		}
+		current := byName[name]
+		plan.Steps = append(plan.Steps, Step{Action: ActionUninstall, Name: name, From: current.Package.Version, Installed: current})
+	}
+	return plan, nil
+}
+
+func planPackage(d *Desired, current *pkg.InstalledPackage, installed bool) (*Step, error) {
+	step := &Step{Name: d.Package.Name, To: d.Package.Version, Desired: d}
+	if !installed || current.Status == "failed" {
+		step.Action = ActionInstall
+		return step, nil
+	}
+
+	if pak := d.Entry.PakName(); pak != "" && pak != installedPak(*current) {
+		return nil, fmt.Errorf("%s is installed from %s but the stack file wants %s; uninstall it first", d.Package.Name, installedPak(*current), pak)
+	}
+
+	step.Installed = current
+	step.From = current.Package.Version
+	step.Changed = changedValues(pkg.MergeValues(d.Package, d.Values), current.Values)
+
+	switch {
+	case step.From != step.To:
+		step.Action = ActionUpgrade
+	case len(step.Changed) > 0:
+		step.Action = ActionReconfigure
+	default:
+		return nil, nil
+	}
+	return step, nil
+}
+
+func installedPak(installed pkg.InstalledPackage) string {
+	if installed.Pak != "" {
+		return installed.Pak
+	}
+	return installed.Package.Name
+}
+
+func changedValues(desired, current map[string]string) []string {
+	var changed []string
+	for key, value := range desired {
+		if existing, ok := current[key]; !ok || existing != value {
+			changed = append(changed, key)
+		}
+	}
+	for key := range current {
+		if _, ok := desired[key]; !ok {
+			changed = append(changed, key)
+		}
+	}
+	slices.Sort(changed)
+	return changed
+}
+
+func changedSuffix(changed []string) string {
+	if len(changed) == 0 {
+		return ""
+	}
+	return " (" + strings.Join(changed, ", ") + ")"
+}
+
+func older(a, b string) bool {
+	va, errA := semver.NewVersion(a)
+	vb, errB := semver.NewVersion(b)
+	return errA == nil && errB == nil && va.LessThan(vb)
+}
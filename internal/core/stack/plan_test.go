@@ -0,0 +1,114 @@
+package stack
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	pkg "github.com/LoriKarikari/compak/internal/core/package"
+)
+
+func desiredPackage(name, version string, values map[string]string) Desired {
+	return Desired{
+		Entry: Entry{Name: name},
+		Package: pkg.Package{Name: name, Version: version, Parameters: map[string]pkg.Param{
+			"PORT": {Type: "port", Default: "80"},
+		}},
+		Values: values,
+	}
+}
+
+func installedPackage(name, version string, values map[string]string) pkg.InstalledPackage {
+	return pkg.InstalledPackage{Package: pkg.Package{Name: name, Version: version}, Values: values, Status: "installed"}
+}
+
+func TestNewPlan(t *testing.T) {
+	installed := []pkg.InstalledPackage{
+		installedPackage("web", "1.0.0", map[string]string{"PORT": "80"}),
+		installedPackage("api", "1.0.0", map[string]string{"PORT": "80"}),
+		installedPackage("old", "0.9.0", nil),
+	}
+
+	tests := []struct {
+		name      string
+		desired   []Desired
+		prune     bool
+		steps     []string
+		unchanged []string
+		unmanaged []string
+	}{
+		{
+			name:      "in sync",
+			desired:   []Desired{desiredPackage("web", "1.0.0", nil), desiredPackage("api", "1.0.0", map[string]string{"PORT": "80"})},
+			unchanged: []string{"web", "api"},
+			unmanaged: []string{"old"},
+		},
+		{
+			name: "install, upgrade and reconfigure",
+			desired: []Desired{
+				desiredPackage("web", "1.1.0", nil),
+				desiredPackage("api", "1.0.0", map[string]string{"PORT": "8080"}),
+				desiredPackage("db", "2.0.0", nil),
+			},
+			steps:     []string{"upgrade web", "reconfigure api", "install db"},
+			unmanaged: []string{"old"},
+		},
+		{
+			name:    "prune",
+			desired: []Desired{desiredPackage("web", "1.0.0", nil)},
+			prune:   true,
+			steps:   []string{"uninstall api", "uninstall old"},
+			unchanged: []string{
+				"web",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan, err := NewPlan(tt.desired, installed, tt.prune)
+			if err != nil {
+				t.Fatalf("Expected no error but got: %v", err)
+			}
+
+			var steps []string
+			for _, step := range plan.Steps {
+				steps = append(steps, string(step.Action)+" "+step.Name)
+			}
+			if !reflect.DeepEqual(steps, tt.steps) {
+				t.Errorf("Expected steps %v, got %v", tt.steps, steps)
+			}
+			if !reflect.DeepEqual(plan.Unchanged, tt.unchanged) || !reflect.DeepEqual(plan.Unmanaged, tt.unmanaged) {
+				t.Errorf("Expected unchanged %v and unmanaged %v, got %v and %v", tt.unchanged, tt.unmanaged, plan.Unchanged, plan.Unmanaged)
+			}
+		})
+	}
+}
+
+func TestNewPlanRetriesFailedAndRejectsOtherPak(t *testing.T) {
+	failed := installedPackage("web", "1.0.0", nil)
+	failed.Status = "failed"
+	plan, err := NewPlan([]Desired{desiredPackage("web", "1.0.0", nil)}, []pkg.InstalledPackage{failed}, false)
+	if err != nil || len(plan.Steps) != 1 || plan.Steps[0].Action != ActionInstall {
+		t.Errorf("Expected a failed package to be reinstalled, got %+v (%v)", plan, err)
+	}
+
+	other := desiredPackage("web", "1.0.0", nil)
+	other.Entry.Pak = "caddy"
+	if _, err := NewPlan([]Desired{other}, []pkg.InstalledPackage{installedPackage("web", "1.0.0", nil)}, false); err == nil {
+		t.Error("Expected error when an instance changes pak")
+	}
+}
+
+func TestPlanStringHidesValues(t *testing.T) {
+	desired := desiredPackage("web", "1.0.0", map[string]string{"PORT": "8443"})
+	plan, err := NewPlan([]Desired{desired}, []pkg.InstalledPackage{installedPackage("web", "1.0.0", map[string]string{"PORT": "80"})}, false)
+	if err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+
+	output := plan.String()
+	if !strings.Contains(output, "reconfigure web 1.0.0 (PORT)") || strings.Contains(output, "8443") {
+		t.Errorf("Expected changed keys without values, got:\n%s", output)
+	}
+}
@@ -0,0 +1,200 @@
+package stack
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	"gopkg.in/yaml.v3"
+)
+
+const DefaultFile = "compak.yaml"
+
+type File struct {
+	Packages []Entry `yaml:"packages"`
+}
+
+type Entry struct {
+	Name    string           `yaml:"name"`
+	Pak     string           `yaml:"pak,omitempty"`
+	Version string           `yaml:"version,omitempty"`
+	Path    string           `yaml:"path,omitempty"`
+	Values  map[string]Value `yaml:"values,omitempty"`
+}
+
+type Value struct {
+	Literal string
+	Env     string
+	File    string
+}
+
+func (v *Value) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		return node.Decode(&v.Literal)
+	}
+
+	var ref struct {
+		Env  string `yaml:"env"`
+		File string `yaml:"file"`
+	}
+	if err := node.Decode(&ref); err != nil {
+		return fmt.Errorf("line %d: value must be a string or a secret reference with env or file: %w", node.Line, err)
+	}
+	if (ref.Env == "") == (ref.File == "") {
+		return fmt.Errorf("line %d: secret reference needs exactly one of env or file", node.Line)
+	}
+	v.Env, v.File = ref.Env, ref.File
+	return nil
+}
+
+func (v Value) Secret() bool {
+	return v.Env != "" || v.File != ""
+}
+
+func (v Value) Resolve() (string, error) {
+	switch {
+	case v.Env != "":
+		value, ok := os.LookupEnv(v.Env)
+		if !ok {
+			return "", fmt.Errorf("environment variable %s is not set", v.Env)
+		}
+		return value, nil
+	case v.File != "":
+		data, err := os.ReadFile(v.File)
+		if err != nil {
+			return "", fmt.Errorf("failed to read secret: %w", err)
+		}
+		return strings.TrimRight(string(data), "\r\n"), nil
+	default:
+		return v.Literal, nil
+	}
+}
+
+func (e Entry) PakName() string {
+	if e.Pak != "" {
+		return e.Pak
+	}
+	if e.Path != "" {
+		return ""
+	}
+	return e.Name
+}
+
+func (e Entry) ResolveValues() (map[string]string, error) {
+	values := make(map[string]string, len(e.Values))
+	for key, value := range e.Values {
+		resolved, err := value.Resolve()
+		if err != nil {
+			return nil, fmt.Errorf("%s: value %s: %w", e.Name, key, err)
+		}
+		values[key] = resolved
+	}
+	return values, nil
+}
+
+func Load(path string) (File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return File{}, fmt.Errorf("failed to read stack file: %w", err)
+	}
+	return Parse(data, filepath.Dir(path))
+}
+
+func Parse(data []byte, baseDir string) (File, error) {
+	var file File
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&file); err != nil && !errors.Is(err, io.EOF) {
+		return File{}, fmt.Errorf("failed to parse stack file: %w", err)
+	}
+
+	seen := make(map[string]bool, len(file.Packages))
+	for i := range file.Packages {
+		entry := &file.Packages[i]
+		if entry.Name == "" {
+			entry.Name = entry.Pak
+		}
+		if err := validateEntry(*entry); err != nil {
+			return File{}, fmt.Errorf("packages[%d]: %w", i, err)
+		}
+		if seen[entry.Name] {
+			return File{}, fmt.Errorf("packages[%d]: %s is listed more than once", i, entry.Name)
+		}
+		seen[entry.Name] = true
+
+		entry.Path = resolvePath(baseDir, entry.Path)
+		for key, value := range entry.Values {
+			value.File = resolvePath(baseDir, value.File)
+			entry.Values[key] = value
+		}
+	}
+	return file, nil
+}
+
+func validateEntry(entry Entry) error {
+	switch {
+	case entry.Name == "":
+		return fmt.Errorf("name or pak is required")
+	case entry.Pak != "" && entry.Path != "":
+		return fmt.Errorf("%s: pak and path are mutually exclusive", entry.Name)
+	case entry.Path != "" && entry.Version != "":
+		return fmt.Errorf("%s: version cannot be used with path", entry.Name)
+	}
+
+	if entry.Version != "" && entry.Version != "latest" && !IsExactVersion(entry.Version) {
+		if _, err := semver.NewConstraint(entry.Version); err != nil {
+			return fmt.Errorf("%s: invalid version %q: %w", entry.Name, entry.Version, err)
+		}
+	}
+	return nil
+}
+
+func resolvePath(baseDir, path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(baseDir, path)
+}
+
+func IsExactVersion(version string) bool {
+	if strings.ContainsAny(version, "<>=~^*xX|, ") {
+		return false
+	}
+	_, err := semver.StrictNewVersion(strings.TrimPrefix(version, "v"))
+	return err == nil
+}
+
+func ResolveVersion(spec, latest string, versions []string) (string, error) {
+	if spec == "" || spec == "latest" || spec == latest {
+		return latest, nil
+	}
+	if IsExactVersion(spec) {
+		return spec, nil
+	}
+
+	constraint, err := semver.NewConstraint(spec)
+	if err != nil {
+		return "", fmt.Errorf("invalid version %q: %w", spec, err)
+	}
+
+	var best *semver.Version
+	var resolved string
+	for _, candidate := range append([]string{latest}, versions...) {
+		v, err := semver.NewVersion(candidate)
+		if err != nil || !constraint.Check(v) {
+			continue
+		}
+		if best == nil || v.GreaterThan(best) {
+			best, resolved = v, candidate
+		}
+	}
+	if best == nil {
+		return "", fmt.Errorf("no version in the index satisfies %q (latest is %s)", spec, latest)
+	}
+	return resolved, nil
+}
@@ -0,0 +1,133 @@
+package stack
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{
+			name: "valid",
+			content: `packages:
+  - pak: nginx
+    version: ^1.2
+    values:
+      PORT: "8080"
+      PASSWORD: {env: NGINX_PASSWORD}
+  - name: blog
+    path: ./paks/blog
+`,
+		},
+		{name: "empty", content: ""},
+		{name: "missing name", content: "packages:\n  - version: 1.0.0\n", wantErr: "name or pak is required"},
+		{name: "duplicate", content: "packages:\n  - pak: nginx\n  - name: nginx\n", wantErr: "more than once"},
+		{name: "pak and path", content: "packages:\n  - pak: nginx\n    path: ./nginx\n", wantErr: "mutually exclusive"},
+		{name: "version with path", content: "packages:\n  - name: blog\n    path: ./blog\n    version: 1.0.0\n", wantErr: "cannot be used with path"},
+		{name: "invalid version", content: "packages:\n  - pak: nginx\n    version: \">>1\"\n", wantErr: "invalid version"},
+		{name: "unknown field", content: "packages:\n  - pak: nginx\n    vesion: 1.0.0\n", wantErr: "vesion"},
+		{name: "empty secret", content: "packages:\n  - pak: nginx\n    values:\n      A: {}\n", wantErr: "exactly one of env or file"},
+		{name: "two secrets", content: "packages:\n  - pak: nginx\n    values:\n      A: {env: X, file: y}\n", wantErr: "exactly one of env or file"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.content), "/srv/stack")
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Expected no error but got: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestParseResolvesEntries(t *testing.T) {
+	file, err := Parse([]byte(`packages:
+  - pak: nginx
+    values:
+      KEY: {file: secrets/key}
+  - name: blog
+    path: ./paks/blog
+`), "/srv/stack")
+	if err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+
+	nginx, blog := file.Packages[0], file.Packages[1]
+	if nginx.Name != "nginx" || nginx.PakName() != "nginx" {
+		t.Errorf("Expected name to default to the pak, got %+v", nginx)
+	}
+	if nginx.Values["KEY"].File != "/srv/stack/secrets/key" || !nginx.Values["KEY"].Secret() {
+		t.Errorf("Expected secret file relative to the stack file, got %+v", nginx.Values["KEY"])
+	}
+	if blog.Path != "/srv/stack/paks/blog" || blog.PakName() != "" {
+		t.Errorf("Expected local path relative to the stack file, got %+v", blog)
+	}
+}
+
+func TestResolveValues(t *testing.T) {
+	secret := filepath.Join(t.TempDir(), "secret")
+	if err := os.WriteFile(secret, []byte("s3cret\n"), 0o600); err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+	t.Setenv("STACK_TEST_TOKEN", "token")
+
+	entry := Entry{Name: "demo", Values: map[string]Value{
+		"PLAIN": {Literal: "value"},
+		"TOKEN": {Env: "STACK_TEST_TOKEN"},
+		"KEY":   {File: secret},
+	}}
+	values, err := entry.ResolveValues()
+	if err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+	if values["PLAIN"] != "value" || values["TOKEN"] != "token" || values["KEY"] != "s3cret" {
+		t.Errorf("Unexpected values: %v", values)
+	}
+
+	entry.Values["MISSING"] = Value{Env: "STACK_TEST_UNSET"}
+	if _, err := entry.ResolveValues(); err == nil || !strings.Contains(err.Error(), "STACK_TEST_UNSET") {
+		t.Errorf("Expected error for an unset variable, got %v", err)
+	}
+}
+
+func TestResolveVersion(t *testing.T) {
+	versions := []string{"1.4.0", "1.2.3", "2.0.0-rc.1", "1.2.0", "1.1.0"}
+	tests := []struct {
+		name     string
+		spec     string
+		expected string
+		wantErr  bool
+	}{
+		{name: "latest", spec: "", expected: "1.4.0"},
+		{name: "exact", spec: "1.2.0", expected: "1.2.0"},
+		{name: "constraint matches latest", spec: "^1.2", expected: "1.4.0"},
+		{name: "constraint picks highest match", spec: "~1.2.0", expected: "1.2.3"},
+		{name: "constraint matches older version", spec: "<1.2", expected: "1.1.0"},
+		{name: "constraint unsatisfied", spec: "~1.3.0", wantErr: true},
+		{name: "invalid constraint", spec: "^^1", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ResolveVersion(tt.spec, "1.4.0", versions)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Expected error=%v, got %v", tt.wantErr, err)
+			}
+			if got != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, got)
+			}
+		})
+	}
+}
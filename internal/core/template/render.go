@@ -0,0 +1,286 @@
+package template
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"strconv"
+	"strings"
+	"text/template"
+	"unicode"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	TemplateExt       = ".tmpl"
+	maxTemplateSize   = 1024 * 1024
+	maxRenderedOutput = 10 * 1024 * 1024
+)
+
+type renderData struct {
+	Values map[string]string
+}
+
+func FindTemplates(dir string) ([]string, error) {
+	var templates []string
+	err := fs.WalkDir(os.DirFS(dir), ".", func(path string, d fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+		if !d.IsDir() && strings.HasSuffix(path, TemplateExt) {
+			templates = append(templates, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find templates: %w", err)
+	}
+	return templates, nil
+}
+
+func (e *Engine) RenderFiles(dir string, paths []string) (err error) {
+	root, err := os.OpenRoot(dir)
+	if err != nil {
+		return fmt.Errorf("failed to open root: %w", err)
+	}
+	defer func() {
+		if closeErr := root.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}()
+
+	for _, path := range paths {
+		if !strings.HasSuffix(path, TemplateExt) {
+			continue
+		}
+		if err := e.renderFile(root, path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *Engine) renderFile(root *os.Root, path string) error {
+	info, err := root.Stat(path)
+	if err != nil {
+		return err
+	}
+	if info.Size() > maxTemplateSize {
+		return fmt.Errorf("template %s too large", path)
+	}
+
+	src, err := root.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read template %s: %w", path, err)
+	}
+
+	out, err := e.Render(path, string(src))
+	if err != nil {
+		return err
+	}
+
+	target := strings.TrimSuffix(path, TemplateExt)
+	if err := root.WriteFile(target, out, 0o600); err != nil {
+		return fmt.Errorf("failed to write %s: %w", target, err)
+	}
+	return nil
+}
+
+func (e *Engine) Render(name, src string) ([]byte, error) {
+	tmpl, err := template.New(name).
+		Option("missingkey=zero").
+		Funcs(funcMap()).
+		Parse(src)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse: %w", err)
+	}
+
+	var buf limitedBuffer
+	if err := tmpl.Execute(&buf, renderData{Values: e.values}); err != nil {
+		return nil, fmt.Errorf("failed to render: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+type limitedBuffer struct {
+	bytes.Buffer
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if b.Len()+len(p) > maxRenderedOutput {
+		return 0, fmt.Errorf("rendered output exceeds %d bytes", maxRenderedOutput)
+	}
+	return b.Buffer.Write(p)
+}
+
+func funcMap() template.FuncMap {
+	return template.FuncMap{
+		"default":    defaultValue,
+		"required":   required,
+		"empty":      func(v any) bool { return isEmpty(v) },
+		"coalesce":   coalesce,
+		"ternary":    func(a, b any, cond bool) any { return map[bool]any{true: a, false: b}[cond] },
+		"quote":      func(s any) string { return strconv.Quote(fmt.Sprint(s)) },
+		"squote":     func(s any) string { return "'" + strings.ReplaceAll(fmt.Sprint(s), "'", "''") + "'" },
+		"upper":      strings.ToUpper,
+		"lower":      strings.ToLower,
+		"title":      title,
+		"trim":       strings.TrimSpace,
+		"trimPrefix": func(prefix, s string) string { return strings.TrimPrefix(s, prefix) },
+		"trimSuffix": func(suffix, s string) string { return strings.TrimSuffix(s, suffix) },
+		"replace":    func(old, replacement, s string) string { return strings.ReplaceAll(s, old, replacement) },
+		"contains":   func(substr, s string) bool { return strings.Contains(s, substr) },
+		"hasPrefix":  func(prefix, s string) bool { return strings.HasPrefix(s, prefix) },
+		"hasSuffix":  func(suffix, s string) bool { return strings.HasSuffix(s, suffix) },
+		"split":      func(sep, s string) []string { return splitList(s, sep) },
+		"join":       func(sep string, items []string) string { return strings.Join(items, sep) },
+		"list":       func(items ...any) []any { return items },
+		"seq":        seq,
+		"bool":       toBool,
+		"int":        toInt,
+		"add":        func(a, b int) int { return a + b },
+		"sub":        func(a, b int) int { return a - b },
+		"indent":     indent,
+		"nindent":    func(n int, s string) string { return "\n" + indent(n, s) },
+		"b64enc":     func(s string) string { return base64.StdEncoding.EncodeToString([]byte(s)) },
+		"b64dec":     b64dec,
+		"toJson":     toJSON,
+		"toYaml":     toYAML,
+	}
+}
+
+func defaultValue(def, v any) any {
+	if isEmpty(v) {
+		return def
+	}
+	return v
+}
+
+func required(msg string, v any) (any, error) {
+	if isEmpty(v) {
+		return nil, fmt.Errorf("%s", msg)
+	}
+	return v, nil
+}
+
+func coalesce(values ...any) any {
+	for _, v := range values {
+		if !isEmpty(v) {
+			return v
+		}
+	}
+	return nil
+}
+
+func isEmpty(v any) bool {
+	switch val := v.(type) {
+	case nil:
+		return true
+	case string:
+		return val == ""
+	case bool:
+		return !val
+	case int:
+		return val == 0
+	case []string:
+		return len(val) == 0
+	case []any:
+		return len(val) == 0
+	}
+	return false
+}
+
+func title(s string) string {
+	words := strings.Fields(s)
+	for i, w := range words {
+		r, size := utf8.DecodeRuneInString(w)
+		words[i] = string(unicode.ToUpper(r)) + w[size:]
+	}
+	return strings.Join(words, " ")
+}
+
+func splitList(s, sep string) []string {
+	if s == "" {
+		return []string{}
+	}
+	parts := strings.Split(s, sep)
+	for i, p := range parts {
+		parts[i] = strings.TrimSpace(p)
+	}
+	return parts
+}
+
+func seq(n int) ([]int, error) {
+	if n < 0 || n > 1000 {
+		return nil, fmt.Errorf("seq: count %d out of range (0-1000)", n)
+	}
+	out := make([]int, n)
+	for i := range out {
+		out[i] = i
+	}
+	return out, nil
+}
+
+func toBool(v any) bool {
+	switch val := v.(type) {
+	case bool:
+		return val
+	case string:
+		switch strings.ToLower(strings.TrimSpace(val)) {
+		case "true", "yes", "1", "on":
+			return true
+		}
+	}
+	return false
+}
+
+func toInt(v any) (int, error) {
+	switch val := v.(type) {
+	case int:
+		return val, nil
+	case string:
+		if val == "" {
+			return 0, nil
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(val))
+		if err != nil {
+			return 0, fmt.Errorf("int: %q is not an integer", val)
+		}
+		return n, nil
+	}
+	return 0, fmt.Errorf("int: unsupported type %T", v)
+}
+
+func indent(n int, s string) string {
+	pad := strings.Repeat(" ", n)
+	return pad + strings.ReplaceAll(s, "\n", "\n"+pad)
+}
+
+func b64dec(s string) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return "", fmt.Errorf("b64dec: %w", err)
+	}
+	return string(data), nil
+}
+
+func toJSON(v any) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", fmt.Errorf("toJson: %w", err)
+	}
+	return string(data), nil
+}
+
+func toYAML(v any) (string, error) {
+	data, err := yaml.Marshal(v)
+	if err != nil {
+		return "", fmt.Errorf("toYaml: %w", err)
+	}
+	return strings.TrimSuffix(string(data), "\n"), nil
+}
@@ -0,0 +1,158 @@
+package template
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEngine_Render(t *testing.T) {
+	engine := NewEngine(map[string]string{
+		"ENABLE_MAIL": "true",
+		"DOMAINS":     "a.example.com, b.example.com",
+		"PORT":        "8080",
+		"GREETING":    "it's here",
+	})
+
+	tests := []struct {
+		name     string
+		src      string
+		expected string
+		errMatch string
+	}{
+		{
+			name:     "values",
+			src:      "port: {{ .Values.PORT }}",
+			expected: "port: 8080",
+		},
+		{
+			name:     "missing value renders empty",
+			src:      "[{{ .Values.MISSING }}]",
+			expected: "[]",
+		},
+		{
+			name:     "default helper",
+			src:      `{{ .Values.MISSING | default "fallback" }}`,
+			expected: "fallback",
+		},
+		{
+			name:     "conditional service",
+			src:      `{{ if bool .Values.ENABLE_MAIL }}mail{{ end }}`,
+			expected: "mail",
+		},
+		{
+			name:     "loop over list",
+			src:      `{{ range split "," .Values.DOMAINS }}{{ . }};{{ end }}`,
+			expected: "a.example.com;b.example.com;",
+		},
+		{
+			name:     "arithmetic",
+			src:      `{{ add (int .Values.PORT) 1 }}`,
+			expected: "8081",
+		},
+		{
+			name:     "squote escapes single quotes",
+			src:      "greeting: {{ squote .Values.GREETING }}",
+			expected: "greeting: 'it''s here'",
+		},
+		{
+			name:     "title keeps multi-byte runes",
+			src:      `{{ title "élan vital" }}`,
+			expected: "Élan Vital",
+		},
+		{
+			name:     "nindent",
+			src:      "labels:{{ nindent 2 \"a: 1\\nb: 2\" }}",
+			expected: "labels:\n  a: 1\n  b: 2",
+		},
+		{
+			name:     "required helper fails",
+			src:      "line1\n{{ required \"DB_HOST is required\" .Values.DB_HOST }}",
+			errMatch: "compose.tmpl:2",
+		},
+		{
+			name:     "parse error reports line",
+			src:      "a\nb\n{{ if }}",
+			errMatch: "compose.tmpl:3",
+		},
+		{
+			name:     "env access is not available",
+			src:      `{{ env "HOME" }}`,
+			errMatch: `function "env" not defined`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := engine.Render("compose.tmpl", tt.src)
+			if tt.errMatch != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errMatch) {
+					t.Fatalf("expected error containing %q, got %v", tt.errMatch, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Render failed: %v", err)
+			}
+			if string(out) != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, string(out))
+			}
+		})
+	}
+}
+
+func TestEngine_RenderFiles(t *testing.T) {
+	tempDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(tempDir, "config"), 0o750); err != nil {
+		t.Fatalf("failed to create config dir: %v", err)
+	}
+
+	files := map[string]string{
+		"docker-compose.yaml.tmpl": "services:\n  web:\n    image: nginx:{{ .Values.TAG }}\n",
+		"config/nginx.conf.tmpl":   "server_name {{ .Values.HOST }};\n",
+		"static.txt":               "{{ .Values.TAG }}",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte(content), 0o600); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+
+	templates, err := FindTemplates(tempDir)
+	if err != nil {
+		t.Fatalf("FindTemplates failed: %v", err)
+	}
+
+	engine := NewEngine(map[string]string{"TAG": "alpine", "HOST": "example.com"})
+	if err := engine.RenderFiles(tempDir, templates); err != nil {
+		t.Fatalf("RenderFiles failed: %v", err)
+	}
+
+	expected := map[string]string{
+		"docker-compose.yaml": "services:\n  web:\n    image: nginx:alpine\n",
+		"config/nginx.conf":   "server_name example.com;\n",
+		"static.txt":          "{{ .Values.TAG }}",
+	}
+	for name, want := range expected {
+		got, err := os.ReadFile(filepath.Join(tempDir, name))
+		if err != nil {
+			t.Fatalf("failed to read %s: %v", name, err)
+		}
+		if string(got) != want {
+			t.Errorf("%s: expected %q, got %q", name, want, string(got))
+		}
+	}
+}
+
+func TestEngine_RenderFilesReportsFile(t *testing.T) {
+	tempDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tempDir, "Caddyfile.tmpl"), []byte("ok\n{{ .Values.X | nope }}\n"), 0o600); err != nil {
+		t.Fatalf("failed to write template: %v", err)
+	}
+
+	err := NewEngine(nil).RenderFiles(tempDir, []string{"Caddyfile.tmpl"})
+	if err == nil || !strings.Contains(err.Error(), "Caddyfile.tmpl:2") {
+		t.Fatalf("expected error pointing at Caddyfile.tmpl:2, got %v", err)
+	}
+}
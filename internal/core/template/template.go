@@ -29,11 +29,7 @@ func (e *Engine) WriteEnvFile(packageDir string) error {
 	sort.Strings(keys)
 
 	for _, k := range keys {
-		v := e.values[k]
-		if strings.Contains(v, "\n") || strings.Contains(v, "\"") {
-			v = fmt.Sprintf("%q", v)
-		}
-		lines = append(lines, fmt.Sprintf("%s=%s", k, v))
+		lines = append(lines, fmt.Sprintf("%s=%s", k, quoteEnvValue(e.values[k])))
 	}
 
 	content := strings.Join(lines, "\n")
@@ -44,6 +40,14 @@ func (e *Engine) WriteEnvFile(packageDir string) error {
 	return os.WriteFile(envPath, []byte(content), 0o600)
 }
 
+func quoteEnvValue(v string) string {
+	if !strings.ContainsAny(v, "$#\"'\\\r\n") && v == strings.TrimSpace(v) {
+		return v
+	}
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "$", `\$`, "\r", `\r`, "\n", `\n`)
+	return `"` + replacer.Replace(v) + `"`
+}
+
 func (e *Engine) SetEnvironment() func() {
 	originalEnv := make(map[string]string)
 
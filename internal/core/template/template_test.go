@@ -5,6 +5,8 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+
+	"github.com/compose-spec/compose-go/v2/dotenv"
 )
 
 func TestEngine_WriteEnvFile(t *testing.T) {
@@ -107,10 +109,16 @@ func TestEngine_SetEnvironment(t *testing.T) {
 func TestEngine_WriteEnvFile_SpecialCharacters(t *testing.T) {
 	tempDir := t.TempDir()
 	values := map[string]string{
-		"PASSWORD": "p@$$w0rd!",
-		"PATH":     "/usr/bin:/usr/local/bin",
-		"EMPTY":    "",
-		"SPACES":   "hello world",
+		"PASSWORD":  "p@$$w0rd!",
+		"PATH":      "/usr/bin:/usr/local/bin",
+		"EMPTY":     "",
+		"SPACES":    "hello world",
+		"COMMENT":   "value #not-a-comment",
+		"QUOTES":    `it's "quoted"`,
+		"BACKSLASH": `C:\path\`,
+		"PADDED":    "  padded ",
+		"MULTILINE": "line1\nline2",
+		"REFERENCE": "${HOME}",
 	}
 
 	engine := NewEngine(values)
@@ -123,17 +131,17 @@ func TestEngine_WriteEnvFile_SpecialCharacters(t *testing.T) {
 	if err != nil {
 		t.Fatalf("Failed to read .env file: %v", err)
 	}
-
-	contentStr := string(content)
-	if !strings.Contains(contentStr, "PASSWORD=p@$$w0rd!") {
-		t.Error("Password with special characters not written correctly")
+	if !strings.Contains(string(content), "SPACES=hello world\n") {
+		t.Errorf("Expected plain values to stay unquoted, got:\n%s", content)
 	}
 
-	if !strings.Contains(contentStr, "SPACES=hello world") {
-		t.Error("Value with spaces not written correctly")
+	read, err := dotenv.Read(filepath.Join(tempDir, ".env"))
+	if err != nil {
+		t.Fatalf("Failed to parse .env file: %v", err)
 	}
-
-	if !strings.Contains(contentStr, "EMPTY=") {
-		t.Error("Empty value not written correctly")
+	for key, expected := range values {
+		if read[key] != expected {
+			t.Errorf("Expected %s to round-trip as %q, got %q", key, expected, read[key])
+		}
 	}
 }
@@ -0,0 +1,143 @@
+package trust
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/pem"
+	"fmt"
+	"hash"
+
+	"golang.org/x/crypto/ssh"
+)
+
+const (
+	Namespace = "compak"
+
+	sigMagic   = "SSHSIG"
+	sigVersion = 1
+	pemType    = "SSH SIGNATURE"
+)
+
+type sshSignature struct {
+	Version   uint32
+	PublicKey []byte
+	Namespace string
+	Reserved  string
+	HashAlg   string
+	Signature []byte
+}
+
+type signedData struct {
+	Namespace string
+	Reserved  string
+	HashAlg   string
+	Hash      []byte
+}
+
+func Sign(signer ssh.Signer, message []byte) ([]byte, error) {
+	const hashAlg = "sha512"
+
+	digest, err := hashMessage(hashAlg, message)
+	if err != nil {
+		return nil, err
+	}
+
+	data := append([]byte(sigMagic), ssh.Marshal(signedData{
+		Namespace: Namespace,
+		HashAlg:   hashAlg,
+		Hash:      digest,
+	})...)
+
+	algorithm := ""
+	if signer.PublicKey().Type() == ssh.KeyAlgoRSA {
+		algorithm = ssh.KeyAlgoRSASHA512
+	}
+
+	var sig *ssh.Signature
+	if algorithmSigner, ok := signer.(ssh.AlgorithmSigner); ok && algorithm != "" {
+		sig, err = algorithmSigner.SignWithAlgorithm(rand.Reader, data, algorithm)
+	} else {
+		sig, err = signer.Sign(rand.Reader, data)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign: %w", err)
+	}
+
+	blob := append([]byte(sigMagic), ssh.Marshal(sshSignature{
+		Version:   sigVersion,
+		PublicKey: signer.PublicKey().Marshal(),
+		Namespace: Namespace,
+		HashAlg:   hashAlg,
+		Signature: ssh.Marshal(sig),
+	})...)
+
+	return pem.EncodeToMemory(&pem.Block{Type: pemType, Bytes: blob}), nil
+}
+
+func parseSignature(armored []byte) (*sshSignature, ssh.PublicKey, *ssh.Signature, error) {
+	block, _ := pem.Decode(bytes.TrimSpace(armored))
+	if block == nil || block.Type != pemType {
+		return nil, nil, nil, fmt.Errorf("not an armored SSH signature")
+	}
+
+	blob := block.Bytes
+	if !bytes.HasPrefix(blob, []byte(sigMagic)) {
+		return nil, nil, nil, fmt.Errorf("invalid signature preamble")
+	}
+
+	var parsed sshSignature
+	if err := ssh.Unmarshal(blob[len(sigMagic):], &parsed); err != nil {
+		return nil, nil, nil, fmt.Errorf("malformed signature: %w", err)
+	}
+	if parsed.Version != sigVersion {
+		return nil, nil, nil, fmt.Errorf("unsupported signature version %d", parsed.Version)
+	}
+
+	publicKey, err := ssh.ParsePublicKey(parsed.PublicKey)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("invalid signing key: %w", err)
+	}
+
+	var sig ssh.Signature
+	if err := ssh.Unmarshal(parsed.Signature, &sig); err != nil {
+		return nil, nil, nil, fmt.Errorf("malformed signature blob: %w", err)
+	}
+
+	return &parsed, publicKey, &sig, nil
+}
+
+func verifySignature(publicKey ssh.PublicKey, parsed *sshSignature, sig *ssh.Signature, message []byte) error {
+	if parsed.Namespace != Namespace {
+		return fmt.Errorf("signature namespace %q does not match %q", parsed.Namespace, Namespace)
+	}
+
+	digest, err := hashMessage(parsed.HashAlg, message)
+	if err != nil {
+		return err
+	}
+
+	data := append([]byte(sigMagic), ssh.Marshal(signedData{
+		Namespace: parsed.Namespace,
+		Reserved:  parsed.Reserved,
+		HashAlg:   parsed.HashAlg,
+		Hash:      digest,
+	})...)
+
+	return publicKey.Verify(data, sig)
+}
+
+func hashMessage(alg string, message []byte) ([]byte, error) {
+	var h hash.Hash
+	switch alg {
+	case "sha256":
+		h = sha256.New()
+	case "sha512":
+		h = sha512.New()
+	default:
+		return nil, fmt.Errorf("unsupported signature hash algorithm %q", alg)
+	}
+	h.Write(message)
+	return h.Sum(nil), nil
+}
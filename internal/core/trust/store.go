@@ -0,0 +1,171 @@
+package trust
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+)
+
+const keyExt = ".pub"
+
+var (
+	ErrNoTrustedKeys = errors.New("no trusted keys configured")
+	ErrUntrustedKey  = errors.New("signed by a key that is not in the trust store")
+
+	keyNamePattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9._-]*$`)
+)
+
+type Key struct {
+	Name        string
+	Comment     string
+	Fingerprint string
+	PublicKey   ssh.PublicKey
+}
+
+func (k Key) String() string {
+	if k.Comment != "" {
+		return fmt.Sprintf("%s %s (%s)", k.Name, k.Fingerprint, k.Comment)
+	}
+	return fmt.Sprintf("%s %s", k.Name, k.Fingerprint)
+}
+
+type Store struct {
+	dir string
+}
+
+func NewStore(dir string) *Store {
+	return &Store{dir: dir}
+}
+
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+func (s *Store) Keys() ([]Key, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read trust store: %w", err)
+	}
+
+	var keys []Key
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), keyExt) {
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(s.dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read key %s: %w", entry.Name(), err)
+		}
+
+		parsed, err := parseKeys(strings.TrimSuffix(entry.Name(), keyExt), data)
+		if err != nil {
+			return nil, fmt.Errorf("invalid key file %s: %w", entry.Name(), err)
+		}
+		keys = append(keys, parsed...)
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].Name < keys[j].Name
+	})
+	return keys, nil
+}
+
+func (s *Store) Add(name string, data []byte) ([]Key, error) {
+	if !keyNamePattern.MatchString(name) {
+		return nil, fmt.Errorf("invalid key name %q: use letters, digits, '.', '_' and '-'", name)
+	}
+
+	keys, err := parseKeys(name, data)
+	if err != nil {
+		return nil, err
+	}
+
+	path := filepath.Join(s.dir, name+keyExt)
+	if _, err := os.Stat(path); err == nil {
+		return nil, fmt.Errorf("key %s already exists in trust store", name)
+	}
+
+	if err := os.MkdirAll(s.dir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create trust store: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return nil, fmt.Errorf("failed to write key: %w", err)
+	}
+
+	return keys, nil
+}
+
+func (s *Store) Remove(name string) error {
+	if !keyNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid key name %q", name)
+	}
+
+	if err := os.Remove(filepath.Join(s.dir, name+keyExt)); err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("key %s not found in trust store", name)
+		}
+		return fmt.Errorf("failed to remove key: %w", err)
+	}
+	return nil
+}
+
+func (s *Store) Verify(message, signature []byte) (Key, error) {
+	keys, err := s.Keys()
+	if err != nil {
+		return Key{}, err
+	}
+	if len(keys) == 0 {
+		return Key{}, ErrNoTrustedKeys
+	}
+
+	parsed, publicKey, sig, err := parseSignature(signature)
+	if err != nil {
+		return Key{}, err
+	}
+
+	for _, key := range keys {
+		if !bytes.Equal(key.PublicKey.Marshal(), publicKey.Marshal()) {
+			continue
+		}
+		if err := verifySignature(publicKey, parsed, sig, message); err != nil {
+			return Key{}, fmt.Errorf("invalid signature: %w", err)
+		}
+		return key, nil
+	}
+
+	return Key{}, fmt.Errorf("%w (%s)", ErrUntrustedKey, ssh.FingerprintSHA256(publicKey))
+}
+
+func parseKeys(name string, data []byte) ([]Key, error) {
+	var keys []Key
+	rest := data
+	for len(bytes.TrimSpace(rest)) > 0 {
+		publicKey, comment, _, next, err := ssh.ParseAuthorizedKey(rest)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse public key: %w", err)
+		}
+		keys = append(keys, Key{
+			Name:        name,
+			Comment:     comment,
+			Fingerprint: ssh.FingerprintSHA256(publicKey),
+			PublicKey:   publicKey,
+		})
+		rest = next
+	}
+
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no public key found")
+	}
+	return keys, nil
+}
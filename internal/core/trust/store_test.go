@@ -0,0 +1,126 @@
+package trust
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+)
+
+var errAny = errors.New("any error")
+
+func generateSigner(t *testing.T) ssh.Signer {
+	t.Helper()
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	signer, err := ssh.NewSignerFromKey(privateKey)
+	if err != nil {
+		t.Fatalf("Failed to create signer: %v", err)
+	}
+	return signer
+}
+
+func TestStore_Verify(t *testing.T) {
+	trusted := generateSigner(t)
+	untrusted := generateSigner(t)
+
+	store := NewStore(t.TempDir())
+	if _, err := store.Add("index", ssh.MarshalAuthorizedKey(trusted.PublicKey())); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+
+	message := []byte("name: nginx\nversion: 1.0.0\nsourceDigest: sha256:abc\n")
+	validSig, err := Sign(trusted, message)
+	if err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+	untrustedSig, err := Sign(untrusted, message)
+	if err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		message   []byte
+		signature []byte
+		expectErr error
+	}{
+		{name: "valid signature", message: message, signature: validSig},
+		{name: "tampered message", message: append([]byte("# x\n"), message...), signature: validSig, expectErr: errAny},
+		{name: "untrusted key", message: message, signature: untrustedSig, expectErr: ErrUntrustedKey},
+		{name: "garbage signature", message: message, signature: []byte("not a signature"), expectErr: errAny},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := store.Verify(tt.message, tt.signature)
+			if tt.expectErr == nil {
+				if err != nil {
+					t.Fatalf("Expected no error but got: %v", err)
+				}
+				if key.Name != "index" {
+					t.Errorf("Expected key index, got %s", key.Name)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("Expected error but got none")
+			}
+			if tt.expectErr != errAny && !errors.Is(err, tt.expectErr) {
+				t.Errorf("Expected %v, got %v", tt.expectErr, err)
+			}
+		})
+	}
+}
+
+func TestStore_VerifyWithoutKeys(t *testing.T) {
+	signer := generateSigner(t)
+	sig, err := Sign(signer, []byte("data"))
+	if err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+
+	_, err = NewStore(t.TempDir()).Verify([]byte("data"), sig)
+	if !errors.Is(err, ErrNoTrustedKeys) {
+		t.Errorf("Expected ErrNoTrustedKeys, got %v", err)
+	}
+}
+
+func TestStore_AddListRemove(t *testing.T) {
+	store := NewStore(t.TempDir())
+	signer := generateSigner(t)
+	publicKey := ssh.MarshalAuthorizedKey(signer.PublicKey())
+
+	if _, err := store.Add("../escape", publicKey); err == nil {
+		t.Error("Expected error for invalid key name")
+	}
+	if _, err := store.Add("broken", []byte("not a key")); err == nil {
+		t.Error("Expected error for invalid public key")
+	}
+
+	if _, err := store.Add("index", publicKey); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	if _, err := store.Add("index", publicKey); err == nil {
+		t.Error("Expected error when adding a duplicate key name")
+	}
+
+	keys, err := store.Keys()
+	if err != nil {
+		t.Fatalf("Keys failed: %v", err)
+	}
+	if len(keys) != 1 || keys[0].Fingerprint != ssh.FingerprintSHA256(signer.PublicKey()) {
+		t.Fatalf("Unexpected keys: %+v", keys)
+	}
+
+	if err := store.Remove("index"); err != nil {
+		t.Fatalf("Remove failed: %v", err)
+	}
+	if err := store.Remove("index"); err == nil {
+		t.Error("Expected error when removing a missing key")
+	}
+}